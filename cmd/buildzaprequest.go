@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/mistic0xb/pekka/config"
+	"github.com/mistic0xb/pekka/internal/bunker"
+	"github.com/mistic0xb/pekka/internal/nwc"
+	"github.com/mistic0xb/pekka/internal/signer"
+	"github.com/mistic0xb/pekka/internal/zap"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/spf13/cobra"
+)
+
+var buildZapRequestCmd = &cobra.Command{
+	Use:   "build-zap-request",
+	Short: "Print the signed zap request (kind 9734) the bot would send for a note",
+	Long: `Fetches the note and its recipient's profile, then builds and signs the
+NIP-57 zap request exactly as the bot does and prints it as JSON. No LNURL
+endpoint is contacted and nothing is paid, so the amount is not checked
+against the recipient's limits.
+
+Only the JSON goes to stdout, progress and errors go to stderr.`,
+	Example: `  pekka build-zap-request --event note1... --amount 21
+  pekka build-zap-request --event <hex id> | jq .tags`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := GetConfig()
+
+		ref, _ := cmd.Flags().GetString("event")
+		eventID, hints, err := parseEventRef(ref)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --event: %v\n", err)
+			os.Exit(1)
+		}
+
+		amount, _ := cmd.Flags().GetInt("amount")
+		if !cmd.Flags().Changed("amount") {
+			amount = cfg.Zap.Amount
+		}
+		if amount <= 0 {
+			fmt.Fprintln(os.Stderr, "--amount must be positive")
+			os.Exit(1)
+		}
+
+		comment, _ := cmd.Flags().GetString("comment")
+		if !cmd.Flags().Changed("comment") {
+			comment = cfg.Zap.Comment
+		}
+
+		ctx := context.Background()
+		pool := nostr.NewSimplePool(ctx)
+
+		fmt.Fprintln(os.Stderr, "Fetching note...")
+		event, err := fetchEvent(ctx, pool, append(hints, cfg.ReadRelays()...), eventID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error fetching note: %v\n", err)
+			os.Exit(1)
+		}
+
+		// The wallet is never connected, the zapper only needs the relays
+		zapper, err := zap.New(ctx, cfg.NWCUrl, nwc.RetryConfig{}, zap.SignPolicy{
+			Timeout:    cfg.Zap.SigningTimeout(),
+			GraceRetry: cfg.Zap.SignGraceRetry,
+		}, zap.Relays{
+			Read:      cfg.ReadRelays(),
+			Write:     cfg.WriteRelays(),
+			Recipient: cfg.Zap.RecipientRelays,
+		}, pool)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating zapper: %v\n", err)
+			os.Exit(1)
+		}
+
+		var bunkerClient *bunker.ReconnectingClient
+		if cfg.Zap.Signer == "" || cfg.Zap.Signer == config.SignerBunker {
+			fmt.Fprintln(os.Stderr, "Connecting to bunker to sign...")
+			bunkerClient, err = bunker.NewReconnectingClient(ctx, cfg.Author.BunkerURL, pool, bunker.Options{
+				OnAuth:        bunker.NewAuthHandler(ctx, cfg.Bunker.AuthURLFile),
+				MaxConcurrent: cfg.Bunker.MaxConcurrent,
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error connecting to bunker: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		zapSigner, err := signer.New(cfg.Zap.Signer, bunkerClient, cfg.Author.NSec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating signer: %v\n", err)
+			os.Exit(1)
+		}
+
+		buildCtx, cancel := context.WithTimeout(ctx, cfg.Zap.ZapTimeout())
+		defer cancel()
+
+		zapRequest, err := zapper.BuildZapRequest(buildCtx, event, amount, comment, zapSigner)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error building zap request: %v\n", err)
+			os.Exit(1)
+		}
+
+		out, err := json.MarshalIndent(zapRequest, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding zap request: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(out))
+	},
+}
+
+func init() {
+	buildZapRequestCmd.Flags().String("event", "", "note to zap: note1, nevent1 or hex event id")
+	buildZapRequestCmd.Flags().Int("amount", 0, "sats to request (defaults to zap.amount)")
+	buildZapRequestCmd.Flags().String("comment", "", "zap comment (defaults to zap.comment)")
+	buildZapRequestCmd.MarkFlagRequired("event")
+	rootCmd.AddCommand(buildZapRequestCmd)
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/mistic0xb/pekka/internal/bunker"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+	"github.com/spf13/cobra"
+)
+
+var bunkerCmd = &cobra.Command{
+	Use:   "bunker",
+	Short: "Manage the remote signer (bunker) connection",
+}
+
+var bunkerConnectCmd = &cobra.Command{
+	Use:   "connect",
+	Short: "Connect and approve pekka in your bunker ahead of time",
+	Long: `Connects to author.bunker_url, completes any approval the signer asks for
+and exits. The client key is kept in ` + bunker.ClientKeyFile + `, so later runs of
+start reuse the permissions granted here instead of prompting mid-startup.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := GetConfig()
+
+		ctx := context.Background()
+		pool := nostr.NewSimplePool(ctx)
+
+		bunkerClient, err := bunker.NewReconnectingClient(ctx, cfg.Author.BunkerURL, pool, bunker.Options{
+			OnAuth:        bunker.NewAuthHandler(ctx, cfg.Bunker.AuthURLFile),
+			MaxConcurrent: cfg.Bunker.MaxConcurrent,
+		})
+		if err != nil {
+			fmt.Printf("❌ Error connecting to bunker: %v\n", err)
+			os.Exit(1)
+		}
+
+		pubkey, err := bunkerClient.GetPublicKey(ctx)
+		if err != nil {
+			fmt.Printf("❌ Connected, but the bunker did not return a pubkey: %v\n", err)
+			os.Exit(1)
+		}
+
+		npub, _ := nip19.EncodePublicKey(pubkey)
+		fmt.Printf("✅ Bunker approved pekka for %s\n", npub)
+		fmt.Printf("Client key saved in %s\n", bunker.ClientKeyFile)
+
+		if cfg.Author.NPub != "" && cfg.Author.NPub != npub {
+			fmt.Printf("⚠️  This is not author.npub (%s), list decryption and signing would use a different key\n", cfg.Author.NPub)
+		}
+	},
+}
+
+func init() {
+	bunkerCmd.AddCommand(bunkerConnectCmd)
+	rootCmd.AddCommand(bunkerCmd)
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mistic0xb/pekka/internal/db"
+	"github.com/mistic0xb/pekka/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Database maintenance",
+}
+
+var dbVacuumCmd = &cobra.Command{
+	Use:   "vacuum",
+	Short: "Compact the database and refresh query statistics",
+	Long:  `Runs VACUUM and ANALYZE on the database. Stop the bot first, the command refuses to run while a bot holds the database.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := GetConfig()
+
+		before, err := fileSize(cfg.Database.Path)
+		if err != nil {
+			fmt.Printf("Error reading database: %v\n", err)
+			return
+		}
+
+		database, err := db.Open(cfg.Database.Path)
+		if err != nil {
+			fmt.Printf("Error opening database: %v\n", err)
+			return
+		}
+		defer database.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+
+		fmt.Println("Running VACUUM and ANALYZE...")
+		if err := database.Maintain(ctx); err != nil {
+			if errors.Is(err, db.ErrDatabaseBusy) {
+				fmt.Printf("%v. Stop the bot and try again.\n", err)
+				return
+			}
+			logger.Log.Error().Err(err).Msg("database maintenance failed")
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		after, err := fileSize(cfg.Database.Path)
+		if err != nil {
+			fmt.Printf("Error reading database: %v\n", err)
+			return
+		}
+
+		logger.Log.Info().
+			Int64("size_before", before).
+			Int64("size_after", after).
+			Msg("database maintenance complete")
+
+		fmt.Printf("Size before: %s\n", formatBytes(before))
+		fmt.Printf("Size after:  %s\n", formatBytes(after))
+	},
+}
+
+// fileSize returns the size of a file in bytes
+func fileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// formatBytes renders a byte count in KB/MB for display
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+func init() {
+	dbCmd.AddCommand(dbVacuumCmd)
+	rootCmd.AddCommand(dbCmd)
+}
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mistic0xb/pekka/internal/db"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/spf13/cobra"
+)
+
+// Import formats
+const (
+	importFormatCSV = "csv" // header row naming the zapCSVColumns
+	importFormatDB  = "db"  // another pekka SQLite database
+)
+
+// zapCSVColumns are the columns read from CSV imports; invoice is optional
+var zapCSVColumns = []string{"event_id", "author_pubkey", "amount", "event_created_at", "zapped_at", "invoice"}
+
+// maxReportedInvalid caps how many invalid rows are listed individually
+const maxReportedInvalid = 10
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import zap history from a CSV file or another database",
+	Long: `Adds zaps recorded elsewhere to this database, skipping events that are
+already recorded. CSV files need a header row with the columns
+event_id, author_pubkey, amount, event_created_at, zapped_at and optionally
+invoice (timestamps in unix seconds).`,
+	Example: `  pekka import --format csv --in zaps.csv
+  pekka import --format db --in old/pekka.db`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := GetConfig()
+
+		format, _ := cmd.Flags().GetString("format")
+		in, _ := cmd.Flags().GetString("in")
+		if in == "" {
+			fmt.Println("--in is required")
+			return
+		}
+
+		var zaps []db.ZappedEvent
+		var invalid []error
+		var err error
+		switch format {
+		case importFormatCSV:
+			zaps, invalid, err = readZapsCSV(in)
+		case importFormatDB:
+			zaps, err = readZapsDB(in)
+		default:
+			fmt.Printf("Unknown --format %q (use %s or %s)\n", format, importFormatCSV, importFormatDB)
+			return
+		}
+		if err != nil {
+			fmt.Printf("Error reading %s: %v\n", in, err)
+			return
+		}
+
+		database, err := db.Open(cfg.Database.Path)
+		if err != nil {
+			fmt.Printf("Error opening database: %v\n", err)
+			return
+		}
+		defer database.Close()
+
+		imported, skipped := 0, 0
+		for _, z := range zaps {
+			inserted, err := database.ImportZap(z)
+			if err != nil {
+				fmt.Printf("Error importing %s: %v\n", z.EventID, err)
+				return
+			}
+			if inserted {
+				imported++
+			} else {
+				skipped++
+			}
+		}
+
+		for i, rowErr := range invalid {
+			if i == maxReportedInvalid {
+				fmt.Printf("  ... and %d more\n", len(invalid)-maxReportedInvalid)
+				break
+			}
+			fmt.Printf("  ⚠️  %v\n", rowErr)
+		}
+
+		fmt.Printf("✅ Imported %d zap(s), skipped %d already recorded, %d invalid\n", imported, skipped, len(invalid))
+	},
+}
+
+// readZapsCSV parses a CSV export, returning the valid rows and an error per
+// invalid row
+func readZapsCSV(path string) ([]db.ZappedEvent, []error, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range zapCSVColumns[:5] {
+		if _, ok := columns[name]; !ok {
+			return nil, nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	var zaps []db.ZappedEvent
+	var invalid []error
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			invalid = append(invalid, fmt.Errorf("line %d: %w", line, err))
+			continue
+		}
+
+		z, err := parseZapRecord(record, columns)
+		if err != nil {
+			invalid = append(invalid, fmt.Errorf("line %d: %w", line, err))
+			continue
+		}
+		zaps = append(zaps, z)
+	}
+
+	return zaps, invalid, nil
+}
+
+// parseZapRecord validates one CSV row
+func parseZapRecord(record []string, columns map[string]int) (db.ZappedEvent, error) {
+	field := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	z := db.ZappedEvent{
+		EventID:      strings.ToLower(field("event_id")),
+		AuthorPubkey: strings.ToLower(field("author_pubkey")),
+		Invoice:      field("invoice"),
+	}
+
+	if !nostr.IsValid32ByteHex(z.EventID) {
+		return z, fmt.Errorf("invalid event_id %q", z.EventID)
+	}
+	if !nostr.IsValid32ByteHex(z.AuthorPubkey) {
+		return z, fmt.Errorf("invalid author_pubkey %q", z.AuthorPubkey)
+	}
+
+	amount, err := strconv.Atoi(field("amount"))
+	if err != nil || amount <= 0 {
+		return z, fmt.Errorf("invalid amount %q", field("amount"))
+	}
+	z.Amount = amount
+
+	if z.EventCreatedAt, err = parseUnix(field("event_created_at")); err != nil {
+		return z, fmt.Errorf("invalid event_created_at: %w", err)
+	}
+	if z.ZappedAt, err = parseUnix(field("zapped_at")); err != nil {
+		return z, fmt.Errorf("invalid zapped_at: %w", err)
+	}
+
+	return z, nil
+}
+
+// parseUnix parses a non-negative unix timestamp in seconds
+func parseUnix(value string) (int64, error) {
+	ts, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || ts < 0 {
+		return 0, fmt.Errorf("%q is not a unix timestamp", value)
+	}
+	return ts, nil
+}
+
+// readZapsDB reads every zap from another pekka database
+func readZapsDB(path string) ([]db.ZappedEvent, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	return db.ReadZaps(path)
+}
+
+func init() {
+	importCmd.Flags().String("format", importFormatCSV, "input format: csv or db")
+	importCmd.Flags().String("in", "", "file to import from")
+	rootCmd.AddCommand(importCmd)
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/mistic0xb/pekka/config"
+	"github.com/mistic0xb/pekka/internal/bunker"
+	"github.com/mistic0xb/pekka/internal/nostrlist"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/spf13/cobra"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Inspect the monitored list",
+}
+
+var listExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Print the npubs the bot would monitor",
+	Long: `Resolves the configured list source the same way start does, decrypting
+private members through the bunker unless list.public_only is set, and prints
+one npub per line to stdout. Progress and warnings go to stderr so the output
+can be piped into other tools.`,
+	Example: `  pekka list export > npubs.txt
+  pekka list export --json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := GetConfig()
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		list, err := resolveList(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading list: %v\n", err)
+			os.Exit(1)
+		}
+
+		if list.PrivateUnreadable() {
+			fmt.Fprintln(os.Stderr, "⚠️  Private members could not be read, only public members are listed")
+		}
+		if invalid := list.InvalidMembers(); invalid > 0 {
+			fmt.Fprintf(os.Stderr, "⚠️  %d invalid member tag(s) ignored\n", invalid)
+		}
+
+		if !asJSON {
+			for _, npub := range list.NPubs {
+				fmt.Println(npub)
+			}
+			return
+		}
+
+		out, err := json.MarshalIndent(struct {
+			List    string   `json:"list"`
+			Title   string   `json:"title"`
+			EventID string   `json:"event_id,omitempty"`
+			NPubs   []string `json:"npubs"`
+		}{list.ID, list.Title, list.EventID, list.NPubs}, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding list: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(out))
+	},
+}
+
+// resolveList loads the monitored npubs from zap.direct_npubs or list.source,
+// connecting to the bunker only when private members have to be decrypted
+func resolveList(cfg *config.Config) (*nostrlist.PrivateList, error) {
+	ctx := context.Background()
+	pool := nostr.NewSimplePool(ctx)
+
+	if cfg.Zap.UsesDirectNPubs() {
+		return nostrlist.DirectList(cfg.Zap.DirectNPubs), nil
+	}
+
+	if cfg.List.UsesFollows() {
+		npubs, err := nostrlist.FetchFollows(ctx, cfg.ListRelays(), cfg.Author.NPub, pool)
+		if err != nil {
+			return nil, err
+		}
+		return &nostrlist.PrivateList{ID: config.ListSourceFollows, Title: "follows", NPubs: npubs}, nil
+	}
+
+	if cfg.SelectedList == "" {
+		return nil, fmt.Errorf("no list selected, run pekka start to select one")
+	}
+
+	var bunkerClient *bunker.ReconnectingClient
+	if !cfg.List.PublicOnly {
+		var err error
+		fmt.Fprintln(os.Stderr, "Connecting to bunker to decrypt private members...")
+		bunkerClient, err = bunker.NewReconnectingClient(ctx, cfg.Author.BunkerURL, pool, bunker.Options{
+			OnAuth:        bunker.NewAuthHandler(ctx, cfg.Bunker.AuthURLFile),
+			MaxConcurrent: cfg.Bunker.MaxConcurrent,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to bunker: %w", err)
+		}
+	}
+
+	return nostrlist.GetList(ctx, cfg.ListRelays(), cfg.Author.NPub, bunkerClient, pool, &cfg.List, cfg.SelectedList)
+}
+
+func init() {
+	listExportCmd.Flags().Bool("json", false, "print the list as JSON")
+	listCmd.AddCommand(listExportCmd)
+	rootCmd.AddCommand(listCmd)
+}
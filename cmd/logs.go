@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/mistic0xb/pekka/internal/logger"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+// logPollInterval is how often --follow checks the log file for new lines
+const logPollInterval = 500 * time.Millisecond
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Show the bot's logs in human-readable form",
+	Long:  `Pretty-prints the JSON log file, optionally following it as new entries are written.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		follow, _ := cmd.Flags().GetBool("follow")
+		levelName, _ := cmd.Flags().GetString("level")
+
+		level, err := zerolog.ParseLevel(levelName)
+		if err != nil {
+			fmt.Printf("Invalid level %q: %v\n", levelName, err)
+			return
+		}
+
+		out := zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: "2006-01-02 15:04:05"}
+		if err := tailLogs(logger.FilePath(), level, follow, out); err != nil {
+			fmt.Printf("Error reading logs: %v\n", err)
+		}
+	},
+}
+
+// tailLogs prints every entry at or above level, and with follow keeps
+// printing new entries, reopening the file when lumberjack rotates it
+func tailLogs(path string, level zerolog.Level, follow bool, out zerolog.ConsoleWriter) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { file.Close() }()
+
+	reader := bufio.NewReader(file)
+	var partial []byte
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+
+		partial = append(partial, line...)
+		if err == nil {
+			printLogLine(partial, level, out)
+			partial = partial[:0]
+			continue
+		}
+
+		// Reached the end of the file
+		if !follow {
+			if len(partial) > 0 {
+				printLogLine(partial, level, out)
+			}
+			return nil
+		}
+
+		time.Sleep(logPollInterval)
+
+		rotated, err := logRotated(file, path)
+		if err != nil {
+			return err
+		}
+		if rotated {
+			file.Close()
+			if file, err = os.Open(path); err != nil {
+				return err
+			}
+			reader.Reset(file)
+			partial = partial[:0]
+		}
+	}
+}
+
+// logRotated reports whether path no longer refers to the open file, or the
+// file was truncated below the current read position
+func logRotated(file *os.File, path string) (bool, error) {
+	current, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// Between lumberjack's rename and create; try again next poll
+			return false, nil
+		}
+		return false, err
+	}
+
+	opened, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+	if !os.SameFile(current, opened) {
+		return true, nil
+	}
+
+	pos, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return false, err
+	}
+	return current.Size() < pos, nil
+}
+
+// printLogLine pretty-prints a single JSON log entry if it meets the level
+func printLogLine(line []byte, level zerolog.Level, out zerolog.ConsoleWriter) {
+	var entry struct {
+		Level string `json:"level"`
+	}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		// Not a JSON entry, show it as-is
+		out.Out.Write(line)
+		return
+	}
+
+	entryLevel, err := zerolog.ParseLevel(entry.Level)
+	if err == nil && entryLevel < level {
+		return
+	}
+
+	out.Write(line)
+}
+
+func init() {
+	logsCmd.Flags().BoolP("follow", "f", false, "keep printing new log entries")
+	logsCmd.Flags().String("level", "info", "minimum level to show (trace, debug, info, warn, error)")
+	rootCmd.AddCommand(logsCmd)
+}
@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mistic0xb/pekka/internal/db"
+	"github.com/mistic0xb/pekka/internal/logger"
+	"github.com/mistic0xb/pekka/internal/nwc"
+	"github.com/mistic0xb/pekka/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// legacyMatchWindow is how far apart a wallet payment and a zap recorded
+// without an invoice may be and still be considered the same payment
+const legacyMatchWindow = 10 * time.Minute
+
+// transactionPageSize is how many transactions are requested per page
+const transactionPageSize = 50
+
+// maxTransactionPages bounds paging for wallets that ignore the offset and
+// keep returning full pages
+const maxTransactionPages = 200
+
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Compare wallet payments against recorded zaps",
+	Long: `Fetches outgoing payments from the wallet via list_transactions and compares
+them to the zaps recorded in the database, listing payments that were never
+recorded and zaps that have no matching payment.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := GetConfig()
+		days, _ := cmd.Flags().GetInt("days")
+		if days <= 0 {
+			fmt.Println("--days must be positive")
+			return
+		}
+
+		since := time.Now().Add(-time.Duration(days) * 24 * time.Hour).Unix()
+
+		database, err := db.Open(cfg.Database.Path)
+		if err != nil {
+			fmt.Printf("Error opening database: %v\n", err)
+			return
+		}
+		defer database.Close()
+
+		zaps, err := database.GetZapsSince(since)
+		if err != nil {
+			fmt.Printf("Error reading zaps: %v\n", err)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+
+		client, err := nwc.NewClient(ctx, cfg.NWCUrl, nwc.RetryConfig{
+			Attempts: cfg.NWC.ConnectRetries,
+			Timeout:  cfg.NWC.ConnectTimeout,
+		})
+		if err != nil {
+			fmt.Printf("Error creating wallet client: %v\n", err)
+			return
+		}
+
+		s := ui.NewSpinner("Connecting to wallet", 11, "yellow")
+		err = client.Connect(ctx)
+		s.Stop()
+		if err != nil {
+			fmt.Printf("Error connecting to wallet: %v\n", err)
+			return
+		}
+		defer client.Close()
+
+		s = ui.NewSpinner("Fetching wallet transactions", 11, "blue")
+		payments, truncated, err := fetchOutgoing(ctx, client, since)
+		s.Stop()
+		if err != nil {
+			fmt.Printf("Error listing transactions: %v\n", err)
+			return
+		}
+		if truncated {
+			fmt.Printf("⚠️  Stopped after %d pages of wallet payments, older ones were not checked. Try a smaller --days.\n", maxTransactionPages)
+		}
+
+		unrecorded, unpaid := reconcile(payments, zaps)
+
+		fmt.Println("=== Reconciliation ===")
+		fmt.Println()
+		fmt.Printf("Period: last %d day(s)\n", days)
+		fmt.Printf("Wallet Payments: %d\n", len(payments))
+		fmt.Printf("Recorded Zaps: %d\n", len(zaps))
+		fmt.Println()
+
+		if len(unrecorded) == 0 && len(unpaid) == 0 {
+			fmt.Println("✅ Wallet and database agree")
+			fmt.Println()
+			fmt.Println("======================")
+			return
+		}
+
+		if len(unrecorded) > 0 {
+			fmt.Printf("Paid but not recorded (%d):\n", len(unrecorded))
+			for _, tx := range unrecorded {
+				fmt.Printf("  - %d sats at %s  %s\n",
+					tx.Amount/1000,
+					time.Unix(paidAt(tx), 0).Format("2006-01-02 15:04:05"),
+					truncateInvoice(tx.Invoice),
+				)
+			}
+			fmt.Println()
+		}
+
+		if len(unpaid) > 0 {
+			fmt.Printf("Recorded but not paid (%d):\n", len(unpaid))
+			for _, z := range unpaid {
+				fmt.Printf("  - %d sats at %s  event %s\n",
+					z.Amount,
+					time.Unix(z.ZappedAt, 0).Format("2006-01-02 15:04:05"),
+					z.EventID,
+				)
+			}
+			fmt.Println()
+		}
+
+		fmt.Println("Note: payments made outside Pekka with the same wallet appear as unrecorded.")
+		fmt.Println("======================")
+	},
+}
+
+// fetchOutgoing pages through the wallet's outgoing transactions since the
+// given time. It stops at a page that adds no new payment, and after
+// maxTransactionPages, reporting whether the listing was cut short.
+func fetchOutgoing(ctx context.Context, client *nwc.Client, since int64) ([]nwc.Transaction, bool, error) {
+	var all []nwc.Transaction
+	seen := make(map[string]bool)
+
+	for pages := 0; pages < maxTransactionPages; pages++ {
+		page, err := client.ListTransactions(ctx, nwc.ListTransactionsParams{
+			Type:   "outgoing",
+			From:   since,
+			Limit:  transactionPageSize,
+			Offset: pages * transactionPageSize,
+		})
+		if err != nil {
+			return nil, false, err
+		}
+
+		added := false
+		for _, tx := range page {
+			key := cmp.Or(tx.PaymentHash, tx.Invoice)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			added = true
+
+			// Some wallets ignore the type filter or return pending payments
+			if tx.Type == "outgoing" && tx.SettledAt > 0 {
+				all = append(all, tx)
+			}
+		}
+
+		// A short page is the last one. A page of payments already seen
+		// means the wallet ignores the offset.
+		if len(page) < transactionPageSize || !added {
+			return all, false, nil
+		}
+	}
+
+	logger.Log.Warn().
+		Int("pages", maxTransactionPages).
+		Int("payments", len(all)).
+		Msg("stopped listing wallet transactions at the page limit")
+	return all, true, nil
+}
+
+// reconcile matches wallet payments to recorded zaps. Zaps that stored their
+// invoice are matched exactly; older rows fall back to amount and time.
+func reconcile(payments []nwc.Transaction, zaps []db.ZappedEvent) ([]nwc.Transaction, []db.ZappedEvent) {
+	matched := make([]bool, len(payments))
+	byInvoice := make(map[string]int, len(payments))
+	for i, tx := range payments {
+		if tx.Invoice != "" {
+			byInvoice[tx.Invoice] = i
+		}
+	}
+
+	var unpaid []db.ZappedEvent
+	var legacy []db.ZappedEvent
+
+	for _, z := range zaps {
+		if z.Invoice == "" {
+			legacy = append(legacy, z)
+			continue
+		}
+		if i, ok := byInvoice[z.Invoice]; ok && !matched[i] {
+			matched[i] = true
+			continue
+		}
+		unpaid = append(unpaid, z)
+	}
+
+	for _, z := range legacy {
+		found := false
+		for i, tx := range payments {
+			if matched[i] || tx.Amount != int64(z.Amount)*1000 {
+				continue
+			}
+			diff := time.Duration(paidAt(tx)-z.ZappedAt) * time.Second
+			if diff < 0 {
+				diff = -diff
+			}
+			if diff <= legacyMatchWindow {
+				matched[i] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			unpaid = append(unpaid, z)
+		}
+	}
+
+	var unrecorded []nwc.Transaction
+	for i, tx := range payments {
+		if !matched[i] {
+			unrecorded = append(unrecorded, tx)
+		}
+	}
+
+	return unrecorded, unpaid
+}
+
+// paidAt returns when the payment settled, falling back to its creation time
+func paidAt(tx nwc.Transaction) int64 {
+	if tx.SettledAt > 0 {
+		return tx.SettledAt
+	}
+	return tx.CreatedAt
+}
+
+// truncateInvoice shortens a bolt11 invoice for display
+func truncateInvoice(invoice string) string {
+	if len(invoice) <= 24 {
+		return invoice
+	}
+	return invoice[:24] + "..."
+}
+
+func init() {
+	reconcileCmd.Flags().Int("days", 7, "how many days back to reconcile")
+	rootCmd.AddCommand(reconcileCmd)
+}
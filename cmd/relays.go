@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mistic0xb/pekka/config"
+	"github.com/mistic0xb/pekka/internal/logger"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var relaysCmd = &cobra.Command{
+	Use:   "relays",
+	Short: "Manage configured relays",
+	Long:  `List, add or remove the relays Pekka uses for lists, profiles and events.`,
+}
+
+var relaysListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List configured relays",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := GetConfig()
+
+		fmt.Println("Relays:")
+		for i, relay := range cfg.RelayList {
+			fmt.Printf("  %d. %s\n", i+1, relay)
+		}
+	},
+}
+
+var relaysAddCmd = &cobra.Command{
+	Use:   "add <url>",
+	Short: "Add a relay",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := GetConfig()
+
+		relayURL, err := validateRelayURL(args[0])
+		if err != nil {
+			fmt.Printf("Invalid relay URL: %v\n", err)
+			return
+		}
+
+		if slices.Contains(normalizeRelays(cfg.Relays), relayURL) {
+			fmt.Printf("Relay %s is already configured\n", relayURL)
+			return
+		}
+
+		if ping, _ := cmd.Flags().GetBool("ping"); ping {
+			fmt.Printf("Pinging %s...\n", relayURL)
+			if err := pingRelay(relayURL); err != nil {
+				logger.Log.Warn().Err(err).Str("relay", relayURL).Msg("relay unreachable while adding")
+				fmt.Printf("⚠️  Warning: relay unreachable: %v\n", err)
+			} else {
+				fmt.Println("Relay is reachable")
+			}
+		}
+
+		relays := append(cfg.RelayList, config.RelayEntry{URL: relayURL})
+		if err := saveRelays(relays); err != nil {
+			fmt.Printf("Error saving config: %v\n", err)
+			return
+		}
+
+		fmt.Printf("Added %s\n", relayURL)
+	},
+}
+
+var relaysRemoveCmd = &cobra.Command{
+	Use:   "remove <url>",
+	Short: "Remove a relay",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := GetConfig()
+
+		target := nostr.NormalizeURL(args[0])
+
+		relays := make([]config.RelayEntry, 0, len(cfg.RelayList))
+		for _, relay := range cfg.RelayList {
+			if nostr.NormalizeURL(relay.URL) != target {
+				relays = append(relays, relay)
+			}
+		}
+
+		if len(relays) == len(cfg.RelayList) {
+			fmt.Printf("Relay %s is not configured\n", target)
+			return
+		}
+
+		if len(relays) == 0 {
+			fmt.Println("Cannot remove the last relay, at least one relay is required")
+			return
+		}
+		if !slices.ContainsFunc(relays, config.RelayEntry.CanRead) || !slices.ContainsFunc(relays, config.RelayEntry.CanWrite) {
+			fmt.Println("Cannot remove this relay, at least one read and one write relay are required")
+			return
+		}
+
+		if err := saveRelays(relays); err != nil {
+			fmt.Printf("Error saving config: %v\n", err)
+			return
+		}
+
+		fmt.Printf("Removed %s\n", target)
+	},
+}
+
+// validateRelayURL checks the URL is a ws:// or wss:// relay and normalizes it
+func validateRelayURL(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", err
+	}
+
+	if u.Scheme != "wss" && u.Scheme != "ws" {
+		return "", fmt.Errorf("scheme must be wss or ws, got %q", u.Scheme)
+	}
+
+	if u.Host == "" {
+		return "", fmt.Errorf("missing host")
+	}
+
+	return nostr.NormalizeURL(u.String()), nil
+}
+
+// normalizeRelays returns the normalized form of every relay URL
+func normalizeRelays(relays []string) []string {
+	normalized := make([]string, len(relays))
+	for i, relay := range relays {
+		normalized[i] = nostr.NormalizeURL(relay)
+	}
+	return normalized
+}
+
+// pingRelay tries to open a connection to the relay
+func pingRelay(relayURL string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	relay, err := nostr.RelayConnect(ctx, relayURL)
+	if err != nil {
+		return err
+	}
+	return relay.Close()
+}
+
+// saveRelays writes the relay list back to the config file, keeping plain
+// URLs for read+write relays and objects for the others
+func saveRelays(relays []config.RelayEntry) error {
+	entries := make([]any, 0, len(relays))
+	urls := make([]string, 0, len(relays))
+	for _, relay := range relays {
+		urls = append(urls, relay.URL)
+		if relay.CanRead() && relay.CanWrite() {
+			entries = append(entries, relay.URL)
+			continue
+		}
+		entries = append(entries, map[string]any{"url": relay.URL, "read": relay.CanRead(), "write": relay.CanWrite()})
+	}
+
+	viper.Set("relays", entries)
+	if err := viper.WriteConfig(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	logger.Log.Info().Strs("relays", urls).Msg("relay list updated")
+	return nil
+}
+
+func init() {
+	relaysAddCmd.Flags().Bool("ping", false, "check the relay is reachable before adding")
+
+	relaysCmd.AddCommand(relaysListCmd)
+	relaysCmd.AddCommand(relaysAddCmd)
+	relaysCmd.AddCommand(relaysRemoveCmd)
+	rootCmd.AddCommand(relaysCmd)
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/mistic0xb/pekka/config"
 	"github.com/spf13/cobra"
@@ -11,8 +13,12 @@ import (
 )
 
 var (
-	cfgFile string
-	cfg     *config.Config
+	cfgFile   string
+	profile   string
+	verbosity int
+	cfg       *config.Config
+
+	profileSettings map[string]any // keys the active profile overrides, for show --effective
 )
 
 // rootCmd represents the base command
@@ -32,6 +38,8 @@ func Execute() {
 
 func init() {
 	cobra.OnInitialize(initConfig)
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "apply config.<name>.yml or profiles.<name> on top of the config")
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "print more to the console: -v for skips and budget decisions, -vv for every note")
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -47,8 +55,9 @@ func initConfig() {
 		viper.SetConfigName("config")
 	}
 
-	// Read in environment variables that match
+	// Read in environment variables that match, e.g. PEKKA_BUDGET_DAILY_LIMIT
 	viper.SetEnvPrefix("PEKKA")
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	viper.AutomaticEnv()
 
 	// Read the config file
@@ -57,10 +66,35 @@ func initConfig() {
 		os.Exit(1)
 	}
 
-	// Unmarshal config into struct
+	// Profiles are overlaid on a copy so settings written back by commands
+	// (selected_list, relays) only ever land in the base config file
+	settings := viper.GetViper()
+	if profile != "" {
+		overlay, err := loadProfile(profile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading profile: %v\n", err)
+			os.Exit(1)
+		}
+
+		// Environment variables still win over the profile
+		settings = viper.New()
+		settings.SetEnvPrefix("PEKKA")
+		settings.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+		settings.AutomaticEnv()
+		if err := settings.MergeConfigMap(viper.AllSettings()); err != nil {
+			log.Fatalf("Error parsing config: %v\n", err)
+		}
+		if err := settings.MergeConfigMap(overlay); err != nil {
+			log.Fatalf("Error parsing profile %q: %v\n", profile, err)
+		}
+		profileSettings = overlay
+	}
+
+	// Unmarshal config into struct, rejecting unknown keys so a typo like
+	// "buget:" fails loudly instead of leaving limits at zero
 	cfg = &config.Config{}
-	if err := viper.Unmarshal(cfg); err != nil {
-		log.Fatalf("Error parsing config: %v\n", err)
+	if err := settings.UnmarshalExact(cfg, viper.DecodeHook(config.DecodeHook())); err != nil {
+		log.Fatalf("Error parsing config (check for misspelled keys): %v\n", err)
 	}
 
 	// Validate config
@@ -70,6 +104,34 @@ func initConfig() {
 
 }
 
+// loadProfile returns the settings a profile overrides: the file
+// config.<name>.yml next to the config file if it exists, otherwise the
+// profiles.<name> section of the config itself
+func loadProfile(name string) (map[string]any, error) {
+	base := viper.ConfigFileUsed()
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+
+	for _, candidate := range []string{stem + "." + name + ext, stem + "." + name + ".yml", stem + "." + name + ".yaml"} {
+		if _, err := os.Stat(candidate); err != nil {
+			continue
+		}
+
+		file := viper.New()
+		file.SetConfigFile(candidate)
+		if err := file.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", candidate, err)
+		}
+		return file.AllSettings(), nil
+	}
+
+	if section := viper.Sub("profiles." + name); section != nil {
+		return section.AllSettings(), nil
+	}
+
+	return nil, fmt.Errorf("profile %q not found (no %s.%s%s and no profiles.%s section)", name, filepath.Base(stem), name, ext, name)
+}
+
 // GetConfig returns the loaded configuration
 func GetConfig() *config.Config {
 	return cfg
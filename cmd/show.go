@@ -1,22 +1,124 @@
 package cmd
 
 import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mistic0xb/pekka/config"
+	"github.com/mistic0xb/pekka/internal/logger"
+	"github.com/mistic0xb/pekka/internal/version"
+
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // showCmd prints the current configuration
 var showCmd = &cobra.Command{
 	Use:   "show",
 	Short: "Display current configuration",
-	Long:  `Prints the loaded configuration with sensitive data masked.`,
-	Run:   showConfig,
+	Long: `Prints the loaded configuration with sensitive data masked.
+
+With --effective, every setting is listed with the value actually in use and
+where it came from: env (a PEKKA_* variable), profile (--profile), file (the
+config file) or default (not set anywhere, the built-in default applies).`,
+	Run: showConfig,
 }
 
 func showConfig(cmd *cobra.Command, args []string) {
 	cfg := GetConfig()
-	cfg.Print()
+
+	if effective, _ := cmd.Flags().GetBool("effective"); !effective {
+		cfg.Print()
+		return
+	}
+
+	fmt.Printf("Config file: %s\n", viper.ConfigFileUsed())
+	if profile != "" {
+		fmt.Printf("Profile: %s\n", profile)
+	}
+	fmt.Println()
+
+	writeEffective(os.Stdout, cfg)
+}
+
+// writeEffective lists every setting with its source and value, secrets masked
+func writeEffective(w io.Writer, cfg *config.Config) {
+	settings := cfg.Effective()
+	width := 0
+	for _, setting := range settings {
+		width = max(width, len(setting.Key))
+	}
+
+	for _, setting := range settings {
+		value := setting.Value
+		if value == "" {
+			value = `""`
+		}
+		fmt.Fprintf(w, "%-*s  %-8s %s\n", width, setting.Key, settingSource(setting.Key), value)
+	}
+}
+
+// writeConfigSnapshot saves the effective config to a timestamped file in the
+// logs directory, for audit.config_snapshot, and returns its path
+func writeConfigSnapshot(cfg *config.Config) (string, error) {
+	now := time.Now()
+	path := filepath.Join(logger.Dir, "config-"+now.Format("20060102-150405")+".txt")
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create config snapshot: %w", err)
+	}
+	defer file.Close()
+
+	fmt.Fprintf(file, "# pekka %s started at %s\n", version.Version, now.Format(time.RFC3339))
+	fmt.Fprintf(file, "# config file: %s\n", viper.ConfigFileUsed())
+	if profile != "" {
+		fmt.Fprintf(file, "# profile: %s\n", profile)
+	}
+	fmt.Fprintln(file)
+	writeEffective(file, cfg)
+
+	return path, nil
+}
+
+// settingSource reports where a setting's value came from, in the order
+// they take precedence
+func settingSource(key string) string {
+	switch {
+	case envSet(key):
+		return "env"
+	case hasKey(profileSettings, key):
+		return "profile"
+	case viper.InConfig(key):
+		return "file"
+	default:
+		return "default"
+	}
+}
+
+// envSet reports whether the PEKKA_* variable for key is set
+func envSet(key string) bool {
+	_, ok := os.LookupEnv("PEKKA_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_")))
+	return ok
+}
+
+// hasKey looks up a dotted key in nested settings maps
+func hasKey(settings map[string]any, key string) bool {
+	head, rest, nested := strings.Cut(key, ".")
+	value, ok := settings[head]
+	if !ok || !nested {
+		return ok
+	}
+
+	child, ok := value.(map[string]any)
+	return ok && hasKey(child, rest)
 }
 
 func init() {
+	showCmd.Flags().Bool("effective", false, "list every setting with its value in use and its source (env, profile, file or default)")
 	rootCmd.AddCommand(showCmd)
 }
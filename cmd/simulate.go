@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mistic0xb/pekka/config"
+	"github.com/spf13/cobra"
+)
+
+var simulateCmd = &cobra.Command{
+	Use:   "simulate",
+	Short: "Preview budget spend at a hypothetical posting rate",
+	Long: `Projects how much the bot would spend if the monitored authors posted at the
+given rate, applying the configured sample rate, max_per_zap, daily_limit and
+per_npub_limit the same way the bot does. Budgets reset every 24 hours starting
+from the beginning of the simulation. Nothing is fetched or paid.`,
+	Example: `  pekka simulate --rate 10/hour --amount 21 --hours 24
+  pekka simulate --rate 200/day --authors 20 --hours 72`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := GetConfig()
+
+		rateFlag, _ := cmd.Flags().GetString("rate")
+		perHour, err := parseRate(rateFlag)
+		if err != nil {
+			fmt.Printf("Invalid --rate: %v\n", err)
+			return
+		}
+
+		amount, _ := cmd.Flags().GetInt("amount")
+		if !cmd.Flags().Changed("amount") {
+			amount = cfg.Zap.Amount
+		}
+		hours, _ := cmd.Flags().GetInt("hours")
+		authors, _ := cmd.Flags().GetInt("authors")
+		if amount <= 0 || hours <= 0 || authors <= 0 {
+			fmt.Println("--amount, --hours and --authors must be positive")
+			return
+		}
+
+		result := simulateBudget(&cfg.Budget, cfg.Zap.SampleProbability(), perHour, amount, hours, authors)
+
+		fmt.Println("=== Budget Simulation ===")
+		fmt.Println()
+		fmt.Printf("Rate: %s notes/hour from %d author(s) over %dh\n", formatRate(perHour), authors, hours)
+		fmt.Printf("Amount: %d sats per zap", amount)
+		if p := cfg.Zap.SampleProbability(); p < 1 {
+			fmt.Printf(" (sample rate %.0f%%)", p*100)
+		}
+		fmt.Println()
+		fmt.Printf("Daily Limit: %d sats, Per-NPub Limit: %d sats\n", cfg.Budget.DailyLimit, cfg.Budget.PerNPubLimit)
+		fmt.Println()
+		fmt.Printf("Notes posted: %d\n", result.Notes)
+		fmt.Printf("Notes sampled out: %d\n", result.NotSampled)
+		fmt.Printf("Zaps sent: %d\n", result.Zaps)
+		fmt.Printf("Skipped (daily limit): %d\n", result.DailySkipped)
+		fmt.Printf("Skipped (per-npub limit): %d\n", result.AuthorSkipped)
+		if result.MaxSkipped > 0 {
+			fmt.Printf("Skipped (max_per_zap): %d\n", result.MaxSkipped)
+		}
+		fmt.Println()
+		fmt.Printf("Projected spend: %d sats (%d sats without limits)\n", result.Spent, result.Unlimited)
+		if days := (hours + 23) / 24; days > 1 {
+			fmt.Printf("Average per day: %d sats\n", result.Spent/days)
+		}
+		fmt.Println()
+
+		if result.DailyHitAt >= 0 {
+			fmt.Printf("⚠️  Daily limit first reached after %s\n", result.DailyHitAt)
+		}
+		if result.AuthorHitAt >= 0 {
+			fmt.Printf("⚠️  Per-npub limit first reached after %s\n", result.AuthorHitAt)
+		}
+		if result.MaxSkipped > 0 {
+			fmt.Printf("⚠️  %d sats is above budget.max_per_zap (%d sats), nothing would be zapped\n", amount, cfg.Budget.MaxPerZap)
+		}
+		if result.DailyHitAt < 0 && result.AuthorHitAt < 0 && result.MaxSkipped == 0 {
+			fmt.Println("✅ No budget limit would be reached")
+		}
+	},
+}
+
+// budgetSimulation is the outcome of simulateBudget
+type budgetSimulation struct {
+	Notes         int // Notes posted over the whole run
+	NotSampled    int // Notes dropped by zap.sample_rate
+	Zaps          int
+	DailySkipped  int
+	AuthorSkipped int
+	MaxSkipped    int
+	Spent         int // Sats spent with every limit applied
+	Unlimited     int // Sats that sampled notes would cost without limits
+
+	DailyHitAt  time.Duration // Time of the first daily-limit skip, -1 if never
+	AuthorHitAt time.Duration // Time of the first per-npub skip, -1 if never
+}
+
+// simulateBudget replays evenly spaced notes from authors taking turns and
+// applies the budget checks in the order the bot does
+func simulateBudget(budget *config.BudgetConfig, sampleRate, perHour float64, amount, hours, authors int) budgetSimulation {
+	result := budgetSimulation{DailyHitAt: -1, AuthorHitAt: -1}
+
+	notes := int(perHour * float64(hours))
+	interval := time.Duration(float64(time.Hour) / perHour)
+
+	day := -1
+	var dayTotal int
+	var authorTotals []int
+
+	for i := range notes {
+		at := time.Duration(i) * interval
+		author := i % authors
+		result.Notes++
+
+		// Budgets reset once a day
+		if d := int(at / (24 * time.Hour)); d != day {
+			day = d
+			dayTotal = 0
+			authorTotals = make([]int, authors)
+		}
+
+		// Deterministic stand-in for random sampling: zap whenever the
+		// running expected count crosses a whole number
+		if int(float64(i+1)*sampleRate) == int(float64(i)*sampleRate) {
+			result.NotSampled++
+			continue
+		}
+		result.Unlimited += amount
+
+		switch {
+		case budget.MaxPerZap > 0 && amount > budget.MaxPerZap:
+			result.MaxSkipped++
+		case dayTotal+amount > budget.DailyLimit:
+			result.DailySkipped++
+			if result.DailyHitAt < 0 {
+				result.DailyHitAt = at
+			}
+		case authorTotals[author]+amount > budget.PerNPubLimit:
+			result.AuthorSkipped++
+			if result.AuthorHitAt < 0 {
+				result.AuthorHitAt = at
+			}
+		default:
+			result.Zaps++
+			result.Spent += amount
+			dayTotal += amount
+			authorTotals[author] += amount
+		}
+	}
+
+	return result
+}
+
+// parseRate parses "N/unit" (unit: minute, hour or day) into notes per hour
+func parseRate(rate string) (float64, error) {
+	count, unit, ok := strings.Cut(strings.TrimSpace(rate), "/")
+	if !ok {
+		return 0, fmt.Errorf("expected N/unit, e.g. 10/hour")
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(count), 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%q is not a positive number", count)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(unit)) {
+	case "m", "min", "minute":
+		return n * 60, nil
+	case "h", "hr", "hour":
+		return n, nil
+	case "d", "day":
+		return n / 24, nil
+	default:
+		return 0, fmt.Errorf("unknown unit %q (use minute, hour or day)", unit)
+	}
+}
+
+// formatRate prints a notes-per-hour figure without needless decimals
+func formatRate(perHour float64) string {
+	return strconv.FormatFloat(perHour, 'f', -1, 64)
+}
+
+func init() {
+	simulateCmd.Flags().String("rate", "10/hour", "posting rate across all authors, e.g. 10/hour, 2/minute or 200/day")
+	simulateCmd.Flags().Int("amount", 0, "sats per zap (defaults to zap.amount)")
+	simulateCmd.Flags().Int("hours", 24, "how long to simulate")
+	simulateCmd.Flags().Int("authors", 1, "number of authors sharing the posting rate")
+	rootCmd.AddCommand(simulateCmd)
+}
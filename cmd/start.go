@@ -1,8 +1,8 @@
 package cmd
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -30,8 +30,37 @@ var startCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		cfg := GetConfig()
 
+		// --yes answers nothing, even on a terminal
+		var prompter ui.Prompter = ui.Terminal{}
+		if yes, _ := cmd.Flags().GetBool("yes"); yes {
+			prompter = ui.NoPrompts{}
+		}
+
+		if shadow, _ := cmd.Flags().GetBool("shadow"); shadow {
+			cfg.Mode = config.ModeShadow
+		}
+
+		// Session-only budget override, never written back to the config file
+		if cmd.Flags().Changed("daily-limit") {
+			dailyLimit, _ := cmd.Flags().GetInt("daily-limit")
+			if dailyLimit <= 0 {
+				fmt.Println("Error: --daily-limit must be positive")
+				return
+			}
+			logger.Log.Warn().
+				Int("config_daily_limit", cfg.Budget.DailyLimit).
+				Int("override_daily_limit", dailyLimit).
+				Msg("daily limit override active for this session")
+			fmt.Printf("⚠️  Daily limit override active: %d sats (config: %d sats)\n\n", dailyLimit, cfg.Budget.DailyLimit)
+			cfg.Budget.DailyLimit = dailyLimit
+		}
+
 		// Print the config file
-		fmt.Printf("Using config file: %s\n\n", viper.ConfigFileUsed())
+		fmt.Printf("Using config file: %s\n", viper.ConfigFileUsed())
+		if profile != "" {
+			fmt.Printf("Using profile: %s\n", profile)
+		}
+		fmt.Println()
 		cfg.Print()
 
 		// Open database
@@ -46,8 +75,28 @@ var startCmd = &cobra.Command{
 		}
 		defer database.Close()
 
+		// Keeps `pekka db vacuum` from running underneath the bot
+		if err := database.LockForRun(); err != nil {
+			fmt.Printf("Error: %v. Is another pekka already running on %s?\n", err, cfg.Database.Path)
+			logger.Log.Error().Err(err).Str("db_path", cfg.Database.Path).Msg("failed to lock database")
+			return
+		}
+
 		// Check if list is already selected
-		if cfg.SelectedList == "" {
+		if cfg.Zap.UsesDirectNPubs() {
+			fmt.Println("Using zap.direct_npubs, skipping list selection")
+		} else if cfg.List.UsesFollows() {
+			fmt.Println("Using your follow list (kind 3), skipping list selection")
+		} else if !prompter.Interactive() {
+			// Headless (--yes, systemd, piped stdin): never block on stdin
+			if cfg.SelectedList == "" {
+				logger.Log.Error().Msg("no selected list in non-interactive mode")
+				fmt.Println("Error: no list selected. Set selected_list in the config, or run pekka start in a terminal once to choose one.")
+				return
+			}
+			logger.Log.Info().Str("list_id", cfg.SelectedList).Msg("non-interactive start, using selected list")
+			fmt.Printf("Using selected list: %s\n", cfg.SelectedList)
+		} else if cfg.SelectedList == "" {
 			// No list selected, fetch and prompt user
 			if err := selectList(cfg); err != nil {
 				fmt.Printf("Error selecting list: %v\n", err)
@@ -60,9 +109,14 @@ var startCmd = &cobra.Command{
 			fmt.Printf("Currently selected list: %s\n", cfg.SelectedList)
 			fmt.Print("Use this list? (y/n): ")
 
-			reader := bufio.NewReader(os.Stdin)
-			input, _ := reader.ReadString('\n')
-			input = strings.TrimSpace(strings.ToLower(input))
+			promptCtx, cancel := selectionContext(cfg)
+			input, err := ui.ReadLine(promptCtx)
+			cancel()
+			if err != nil {
+				fmt.Printf("Error selecting list: %v\n", promptError(err, cfg))
+				return
+			}
+			input = strings.ToLower(input)
 
 			if input != "y" && input != "yes" {
 				// User wants to change
@@ -76,20 +130,35 @@ var startCmd = &cobra.Command{
 
 		fmt.Println()
 
+		if cfg.Audit.ConfigSnapshot {
+			if path, err := writeConfigSnapshot(cfg); err != nil {
+				logger.Log.Error().Err(err).Msg("failed to write config snapshot")
+				fmt.Printf("⚠️  Warning: config snapshot not written: %v\n", err)
+			} else {
+				logger.Log.Info().Str("path", path).Msg("config snapshot written")
+			}
+		}
+
 		// Create bot
-		bot, err := bot.New(cfg, database)
+		bot, err := bot.New(cfg, database, bot.Options{Prompter: prompter, Verbosity: verbosity})
 		if err != nil {
 			fmt.Printf("Error creating bot: %v\n", err)
 			return
 		}
 
-		// Handle graceful shutdown
+		// Handle graceful shutdown, SIGUSR2 dumps the session stats
 		sigChan := make(chan os.Signal, 1)
-		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM, syscall.SIGUSR2)
 
 		go func() {
-			<-sigChan
-			bot.Stop()
+			for sig := range sigChan {
+				if sig == syscall.SIGUSR2 {
+					bot.DumpStats()
+					continue
+				}
+				bot.Stop()
+				return
+			}
 		}()
 
 		// Start bot
@@ -99,32 +168,77 @@ var startCmd = &cobra.Command{
 	},
 }
 
+// selectionContext bounds a list selection prompt: Ctrl+C cancels it and
+// list.selection_timeout, when set, ends it
+func selectionContext(cfg *config.Config) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	if cfg.List.SelectionTimeout <= 0 {
+		return ctx, stop
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.List.SelectionTimeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// promptError explains why a selection prompt ended without an answer
+func promptError(err error, cfg *config.Config) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		logger.Log.Error().Dur("timeout", cfg.List.SelectionTimeout).Msg("list selection timed out")
+		return fmt.Errorf("no answer within %s (list.selection_timeout)", cfg.List.SelectionTimeout)
+	}
+	return fmt.Errorf("list selection cancelled")
+}
+
 // selectList fetches lists and prompts user to select one
 func selectList(cfg *config.Config) error {
+	// Never wait on stdin that nobody is typing into
+	if !ui.IsInteractive() {
+		return fmt.Errorf("cannot prompt for a list in non-interactive mode, set selected_list in the config")
+	}
 
 	// Create pool for bunker
 	ctx := context.Background()
 	pool := nostr.NewSimplePool(ctx)
 
 	// Create bunker client
-	bunkerClient, err := bunker.NewReconnectingClient(ctx, cfg.Author.BunkerURL, pool)
+	bunkerClient, err := bunker.NewReconnectingClient(ctx, cfg.Author.BunkerURL, pool, bunker.Options{
+		OnAuth:        bunker.NewAuthHandler(ctx, cfg.Bunker.AuthURLFile),
+		MaxConcurrent: cfg.Bunker.MaxConcurrent,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to connect to bunker: %w\nPlease check your bunker_url in config", err)
 	}
 
+	// Ctrl+C cancels the fetch instead of waiting for slow relays to time out
+	fetchCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Spinner
 	s := ui.NewSpinner("Fetching your private lists from relays", 11, "blue")
 	// Fetch lists
 	lists, err := nostrlist.FetchPrivateLists(
-		cfg.Relays,
+		fetchCtx,
+		cfg.ListRelays(),
 		cfg.Author.NPub,
 		bunkerClient,
 		pool,
+		&cfg.List,
 	)
-	if err != nil {
+	s.Stop()
+	stop()
+	switch {
+	case errors.Is(err, context.Canceled):
+		return fmt.Errorf("list fetch cancelled")
+	case errors.Is(err, nostrlist.ErrNoRelaysResponded):
+		return fmt.Errorf("none of your relays responded. Check your connection or the relays in your config")
+	case errors.Is(err, nostrlist.ErrNoLists):
+		return fmt.Errorf("no private lists found. Create one in your Nostr client first")
+	case err != nil:
 		return fmt.Errorf("failed to fetch lists: %w", err)
 	}
-	s.Stop()
 
 	if len(lists) == 0 {
 		return fmt.Errorf("no private lists found. Create one in your Nostr client first")
@@ -135,19 +249,31 @@ func selectList(cfg *config.Config) error {
 	fmt.Println()
 	for i, list := range lists {
 		privateMarker := ""
-		if list.HasPrivate {
+		if list.PrivateSkipped {
+			privateMarker = " (private members ignored)"
+		} else if list.HasPrivate {
 			privateMarker = " (private)"
 		}
 
 		fmt.Printf("  %d. %s%s (%d people)\n", i+1, list.Title, privateMarker, len(list.NPubs))
+		if list.PrivateUnreadable() {
+			fmt.Println("     ⚠️  private members could not be read (decryption may have failed)")
+		}
+		if invalid := list.InvalidMembers(); invalid > 0 {
+			fmt.Printf("     ⚠️  %d invalid member tag(s) ignored (%d public, %d private)\n",
+				invalid, list.InvalidPublic, list.InvalidPrivate)
+		}
 	}
 	fmt.Println()
 
 	// Ask user to select
-	reader := bufio.NewReader(os.Stdin)
 	fmt.Printf("Select a list (1-%d): ", len(lists))
-	input, _ := reader.ReadString('\n')
-	input = strings.TrimSpace(input)
+	promptCtx, cancel := selectionContext(cfg)
+	input, err := ui.ReadLine(promptCtx)
+	cancel()
+	if err != nil {
+		return promptError(err, cfg)
+	}
 
 	choice, err := strconv.Atoi(input)
 	if err != nil || choice < 1 || choice > len(lists) {
@@ -170,5 +296,8 @@ func selectList(cfg *config.Config) error {
 }
 
 func init() {
+	startCmd.Flags().BoolP("yes", "y", false, "run without prompts (for services): use selected_list as-is and fail instead of asking")
+	startCmd.Flags().Bool("shadow", false, "run without paying, recording would-be zaps to shadow_zaps")
+	startCmd.Flags().Int("daily-limit", 0, "override budget.daily_limit (sats) for this run only")
 	rootCmd.AddCommand(startCmd)
 }
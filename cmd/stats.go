@@ -2,10 +2,11 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
-	"github.com/spf13/cobra"
 	"github.com/mistic0xb/pekka/internal/db"
+	"github.com/spf13/cobra"
 )
 
 var statsCmd = &cobra.Command{
@@ -23,6 +24,12 @@ var statsCmd = &cobra.Command{
 		}
 		defer db.Close()
 
+		if shadow, _ := cmd.Flags().GetBool("shadow"); shadow {
+			db = db.Shadow()
+			fmt.Println("Showing shadow zaps (not paid)")
+			fmt.Println()
+		}
+
 		// Get stats
 		stats, err := db.GetStats()
 		if err != nil {
@@ -40,6 +47,12 @@ var statsCmd = &cobra.Command{
 		fmt.Printf("Today's Total: %d sats\n", stats.TodayTotal)
 		fmt.Printf("Daily Limit: %d sats\n", cfg.Budget.DailyLimit)
 		fmt.Printf("Remaining Today: %d sats\n", cfg.Budget.DailyLimit-stats.TodayTotal)
+		fmt.Printf("Budget Used: %s\n", budgetGauge(stats.TodayTotal, cfg.Budget.DailyLimit, gaugeWidth))
+		if projection, err := projectExhaustion(db, stats.TodayTotal, cfg.Budget.DailyLimit); err != nil {
+			fmt.Printf("Error estimating spend rate: %v\n", err)
+		} else if projection != "" {
+			fmt.Println(projection)
+		}
 		fmt.Println()
 
 		// Get recent zaps
@@ -69,6 +82,68 @@ var statsCmd = &cobra.Command{
 	},
 }
 
+// gaugeWidth is the number of cells in the daily budget gauge
+const gaugeWidth = 20
+
+// rateWindow is how far back the spend rate is measured for the projection
+const rateWindow = 3 * time.Hour
+
+// budgetGauge draws used/limit as a bar, e.g. [████████░░░░░░░░░░░░] 44%
+func budgetGauge(used, limit, width int) string {
+	if limit <= 0 {
+		return "no daily limit"
+	}
+
+	percent := used * 100 / limit
+	filled := min(max(used*width/limit, 0), width)
+	return fmt.Sprintf("[%s%s] %d%%", strings.Repeat("█", filled), strings.Repeat("░", width-filled), percent)
+}
+
+// projectExhaustion estimates when the daily budget runs out at the spend
+// rate of the last rateWindow (today only, as the budget resets at midnight
+// UTC). It returns "" when there is nothing to project.
+func projectExhaustion(database *db.DB, used, limit int) (string, error) {
+	remaining := limit - used
+	if remaining <= 0 {
+		return "⚠️  Daily budget exhausted, it resets at midnight UTC", nil
+	}
+
+	now := time.Now()
+	midnight := now.UTC().Truncate(24 * time.Hour)
+	since := now.Add(-rateWindow)
+	if since.Before(midnight) {
+		since = midnight
+	}
+
+	zaps, err := database.GetZapsSince(since.Unix())
+	if err != nil {
+		return "", err
+	}
+	if len(zaps) == 0 {
+		return "", nil
+	}
+
+	spent := 0
+	for _, z := range zaps {
+		spent += z.Amount
+	}
+
+	// Measure from the first zap in the window so a quiet start does not
+	// dilute the rate, but never over less than a minute
+	elapsed := max(now.Sub(time.Unix(zaps[0].ZappedAt, 0)), time.Minute)
+	perHour := float64(spent) / elapsed.Hours()
+
+	exhausted := now.Add(time.Duration(float64(remaining) / perHour * float64(time.Hour)))
+	reset := midnight.Add(24 * time.Hour)
+	if exhausted.After(reset) {
+		return fmt.Sprintf("Spend Rate: %.0f sats/hour, the budget should last until the daily reset", perHour), nil
+	}
+
+	return fmt.Sprintf("Spend Rate: %.0f sats/hour, projected to run out at %s (in %s)",
+		perHour, exhausted.Local().Format("15:04"), exhausted.Sub(now).Round(time.Minute)), nil
+}
+
 func init() {
+	statsCmd.Flags().Bool("shadow", false, "show statistics for shadow-mode zaps")
 	rootCmd.AddCommand(statsCmd)
-}
\ No newline at end of file
+}
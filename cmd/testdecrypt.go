@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mistic0xb/pekka/internal/bunker"
+	"github.com/mistic0xb/pekka/internal/nostrlist"
+	"github.com/mistic0xb/pekka/internal/ui"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/spf13/cobra"
+)
+
+var testDecryptCmd = &cobra.Command{
+	Use:   "test-decrypt",
+	Short: "Check that your bunker can decrypt a private list",
+	Long: `Connects to the bunker, fetches one of your kind 30000 lists and tries NIP-44
+and NIP-04 decryption separately, reporting which worked, how many private
+members each found and how long each took. Uses the selected list unless
+--list is given.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := GetConfig()
+
+		listID, _ := cmd.Flags().GetString("list")
+		if listID == "" {
+			listID = cfg.SelectedList
+		}
+		if listID == "" {
+			fmt.Println("No list selected. Pass --list <id> or run pekka start to select one.")
+			return
+		}
+
+		ctx := context.Background()
+		pool := nostr.NewSimplePool(ctx)
+
+		s := ui.NewSpinner("Fetching list", 11, "blue")
+		event, err := nostrlist.LatestListEvent(ctx, cfg.ListRelays(), cfg.Author.NPub, pool, listID)
+		s.Stop()
+		if err != nil {
+			fmt.Printf("Error fetching list: %v\n", err)
+			return
+		}
+
+		fmt.Printf("List: %s (event %s, %d public tags)\n", listID, event.ID, len(event.Tags))
+		if event.Content == "" {
+			fmt.Println("This list has no encrypted content, there is nothing to decrypt.")
+			return
+		}
+
+		bunkerClient, err := bunker.NewReconnectingClient(ctx, cfg.Author.BunkerURL, pool, bunker.Options{
+			OnAuth:        bunker.NewAuthHandler(ctx, cfg.Bunker.AuthURLFile),
+			MaxConcurrent: cfg.Bunker.MaxConcurrent,
+		})
+		if err != nil {
+			fmt.Printf("Error connecting to bunker: %v\n", err)
+			return
+		}
+
+		fmt.Println()
+		attempts := nostrlist.DiagnoseDecryption(ctx, bunkerClient, event)
+
+		working := 0
+		for _, attempt := range attempts {
+			elapsed := attempt.Duration.Round(time.Millisecond)
+			switch {
+			case attempt.Err != nil:
+				fmt.Printf("❌ %s failed after %s: %v\n", attempt.Scheme, elapsed, attempt.Err)
+			case !attempt.Parsed:
+				fmt.Printf("⚠️  %s decrypted in %s but the result is not a tag list\n", attempt.Scheme, elapsed)
+			default:
+				working++
+				fmt.Printf("✅ %s decrypted in %s: %d private member(s)", attempt.Scheme, elapsed, attempt.Members)
+				if attempt.Invalid > 0 {
+					fmt.Printf(", %d invalid p tag(s)", attempt.Invalid)
+				}
+				fmt.Println()
+			}
+		}
+
+		fmt.Println()
+		if working == 0 {
+			fmt.Println("Your bunker could not decrypt this list. Check that it allows nip44_decrypt / nip04_decrypt for pekka.")
+		}
+	},
+}
+
+func init() {
+	testDecryptCmd.Flags().String("list", "", "list ID (d tag) to test, defaults to selected_list")
+	rootCmd.AddCommand(testDecryptCmd)
+}
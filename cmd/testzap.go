@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mistic0xb/pekka/config"
+	"github.com/mistic0xb/pekka/internal/bunker"
+	"github.com/mistic0xb/pekka/internal/db"
+	"github.com/mistic0xb/pekka/internal/logger"
+	"github.com/mistic0xb/pekka/internal/nwc"
+	"github.com/mistic0xb/pekka/internal/signer"
+	"github.com/mistic0xb/pekka/internal/ui"
+	"github.com/mistic0xb/pekka/internal/zap"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+	"github.com/spf13/cobra"
+)
+
+var testZapCmd = &cobra.Command{
+	Use:   "test-zap <note|nevent|event-id>",
+	Short: "Manually zap a single note",
+	Long: `Resolves the note's author, lets you pick an amount and confirms before paying.
+
+With --dry-run the zap request is signed and the invoice fetched, then the
+bolt11, its decoded amount and the payee are printed without paying, the same
+way shadow mode stops short of payment.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := GetConfig()
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		eventID, hints, err := parseEventRef(args[0])
+		if err != nil {
+			fmt.Printf("Invalid event: %v\n", err)
+			return
+		}
+
+		ctx := context.Background()
+		pool := nostr.NewSimplePool(ctx)
+
+		s := ui.NewSpinner("Fetching note", 11, "blue")
+		event, err := fetchEvent(ctx, pool, append(hints, cfg.ReadRelays()...), eventID)
+		s.Stop()
+		if err != nil {
+			fmt.Printf("Error fetching note: %v\n", err)
+			return
+		}
+
+		zapper, err := zap.New(ctx, cfg.NWCUrl, nwc.RetryConfig{
+			Attempts: cfg.NWC.ConnectRetries,
+			Timeout:  cfg.NWC.ConnectTimeout,
+		}, zap.SignPolicy{
+			Timeout:    cfg.Zap.SigningTimeout(),
+			GraceRetry: cfg.Zap.SignGraceRetry,
+		}, zap.Relays{
+			Read:      cfg.ReadRelays(),
+			Write:     cfg.WriteRelays(),
+			Recipient: cfg.Zap.RecipientRelays,
+		}, pool)
+		if err != nil {
+			fmt.Printf("Error creating zapper: %v\n", err)
+			return
+		}
+
+		lookupCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		address, err := zapper.LightningAddress(lookupCtx, event.PubKey)
+		cancel()
+		if err != nil {
+			fmt.Printf("Error resolving lightning address: %v\n", err)
+			return
+		}
+
+		reader := bufio.NewReader(os.Stdin)
+
+		amount, err := promptAmount(reader, cfg.Zap.AmountPresets())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		npub, _ := nip19.EncodePublicKey(event.PubKey)
+		fmt.Println()
+		fmt.Println("=== Zap Summary ===")
+		fmt.Printf("Note: %s\n", event.ID)
+		fmt.Printf("Recipient: %s\n", npub)
+		fmt.Printf("Lightning Address: %s\n", address)
+		fmt.Printf("Amount: %d sats\n", amount)
+		fmt.Println("===================")
+
+		if !dryRun {
+			fmt.Print("Send this zap? (y/n): ")
+
+			input, _ := reader.ReadString('\n')
+			input = strings.TrimSpace(strings.ToLower(input))
+			if input != "y" && input != "yes" {
+				fmt.Println("Cancelled.")
+				return
+			}
+		}
+
+		var bunkerClient *bunker.ReconnectingClient
+		if cfg.Zap.Signer == "" || cfg.Zap.Signer == config.SignerBunker {
+			bunkerClient, err = bunker.NewReconnectingClient(ctx, cfg.Author.BunkerURL, pool, bunker.Options{
+				OnAuth:        bunker.NewAuthHandler(ctx, cfg.Bunker.AuthURLFile),
+				MaxConcurrent: cfg.Bunker.MaxConcurrent,
+			})
+			if err != nil {
+				fmt.Printf("Error connecting to bunker: %v\n", err)
+				return
+			}
+		}
+
+		zapSigner, err := signer.New(cfg.Zap.Signer, bunkerClient, cfg.Author.NSec)
+		if err != nil {
+			fmt.Printf("Error creating signer: %v\n", err)
+			return
+		}
+
+		if dryRun {
+			prepareCtx, cancel := context.WithTimeout(ctx, cfg.Zap.ZapTimeout())
+			defer cancel()
+
+			prepared, err := zapper.PrepareZap(prepareCtx, event, amount, 0, cfg.Zap.Comment, zapSigner)
+			if err != nil {
+				fmt.Printf("❌ Zap would fail: %v\n", err)
+				return
+			}
+			printPreparedZap(event, prepared)
+			return
+		}
+
+		s = ui.NewSpinner("Connecting to wallet", 11, "yellow")
+		err = zapper.Connect(ctx)
+		s.Stop()
+		if err != nil {
+			fmt.Printf("Error connecting to wallet: %v\n", err)
+			return
+		}
+		defer zapper.Close()
+
+		zapCtx, cancel := context.WithTimeout(ctx, cfg.Zap.ZapTimeout())
+		defer cancel()
+
+		result, err := zapper.ZapNote(zapCtx, event, amount, 0, cfg.Zap.Comment, zapSigner)
+		if err != nil {
+			fmt.Printf("❌ Zap failed: %v\n", err)
+			return
+		}
+
+		fmt.Printf("✅ Zapped %d sats!\n", result.Amount)
+		recordManualZap(cfg, event, result.Amount, result.Invoice)
+	},
+}
+
+// printPreparedZap shows the invoice a dry run fetched instead of paying it
+func printPreparedZap(event *nostr.Event, prepared *zap.Zap) {
+	payee, _ := nip19.EncodePublicKey(prepared.Payee)
+
+	fmt.Println()
+	fmt.Println("=== Dry Run ===")
+	fmt.Printf("Zap Request: %s\n", prepared.RequestID)
+	fmt.Printf("Payee: %s", payee)
+	if prepared.Payee != event.PubKey {
+		fmt.Print(" (from the note's zap tag)")
+	}
+	fmt.Println()
+	fmt.Printf("Invoice: %s\n", prepared.Invoice)
+
+	msats, err := zap.InvoiceAmount(prepared.Invoice)
+	switch {
+	case err != nil:
+		fmt.Printf("Invoice Amount: could not decode (%v)\n", err)
+	case msats == 0:
+		fmt.Println("Invoice Amount: not set")
+	default:
+		fmt.Printf("Invoice Amount: %d sats\n", msats/1000)
+	}
+	fmt.Println("===============")
+
+	if err == nil && msats != 0 && msats != int64(prepared.Amount)*1000 {
+		fmt.Printf("⚠️  The invoice is for %d msats, but %d sats were requested\n", msats, prepared.Amount)
+	}
+	fmt.Println("Dry run, nothing was paid.")
+}
+
+// promptAmount offers the configured presets plus a custom amount
+func promptAmount(reader *bufio.Reader, presets []int) (int, error) {
+	fmt.Println()
+	fmt.Println("Select an amount:")
+	for i, preset := range presets {
+		fmt.Printf("  %d. %d sats\n", i+1, preset)
+	}
+	fmt.Printf("  %d. custom\n", len(presets)+1)
+	fmt.Printf("Choice (1-%d): ", len(presets)+1)
+
+	input, _ := reader.ReadString('\n')
+	choice, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil || choice < 1 || choice > len(presets)+1 {
+		return 0, fmt.Errorf("invalid selection")
+	}
+
+	if choice <= len(presets) {
+		return presets[choice-1], nil
+	}
+
+	fmt.Print("Amount in sats: ")
+	input, _ = reader.ReadString('\n')
+	amount, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil || amount <= 0 {
+		return 0, fmt.Errorf("invalid amount")
+	}
+
+	return amount, nil
+}
+
+// parseEventRef accepts a note1, nevent1 or hex event id and returns the id
+// along with any relay hints
+func parseEventRef(ref string) (string, []string, error) {
+	ref = strings.TrimPrefix(strings.TrimSpace(ref), "nostr:")
+
+	if nostr.IsValid32ByteHex(ref) {
+		return ref, nil, nil
+	}
+
+	prefix, data, err := nip19.Decode(ref)
+	if err != nil {
+		return "", nil, err
+	}
+
+	switch prefix {
+	case "note":
+		return data.(string), nil, nil
+	case "nevent":
+		pointer := data.(nostr.EventPointer)
+		return pointer.ID, pointer.Relays, nil
+	default:
+		return "", nil, fmt.Errorf("expected note or nevent, got %s", prefix)
+	}
+}
+
+// fetchEvent fetches a single event by id from the given relays
+func fetchEvent(ctx context.Context, pool *nostr.SimplePool, relays []string, eventID string) (*nostr.Event, error) {
+	fetchCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	result := pool.QuerySingle(fetchCtx, relays, nostr.Filter{IDs: []string{eventID}})
+	if result == nil {
+		return nil, fmt.Errorf("event %s not found on relays", eventID)
+	}
+
+	return result.Event, nil
+}
+
+// recordManualZap stores a manual zap so it counts toward budgets and stats
+func recordManualZap(cfg *config.Config, event *nostr.Event, amount int, invoice string) {
+	database, err := db.Open(cfg.Database.Path)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("failed to open database to record manual zap")
+		fmt.Printf("⚠️  Warning: zap not recorded: %v\n", err)
+		return
+	}
+	defer database.Close()
+
+	if err := database.MarkZapped(event.ID, event.PubKey, amount, int64(event.CreatedAt), invoice); err != nil {
+		logger.Log.Error().Err(err).Str("event_id", event.ID).Msg("failed to record manual zap")
+		fmt.Printf("⚠️  Warning: zap not recorded: %v\n", err)
+	}
+}
+
+func init() {
+	testZapCmd.Flags().Bool("dry-run", false, "fetch the invoice and print it without paying")
+	rootCmd.AddCommand(testZapCmd)
+}
@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/mistic0xb/pekka/internal/version"
 	"github.com/spf13/cobra"
@@ -10,11 +12,41 @@ import (
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Show the current version of Pekka",
+	Long: `Shows the current version. With --check it also asks the releases endpoint
+for the latest release and reports whether an update is available. The check
+only runs when requested.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("version:", version.Version)
+
+		if check, _ := cmd.Flags().GetBool("check"); !check {
+			return
+		}
+
+		url, _ := cmd.Flags().GetString("check-url")
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		latest, err := version.Latest(ctx, url)
+		if err != nil {
+			fmt.Printf("latest: unknown (update check failed: %v)\n", err)
+			return
+		}
+		fmt.Println("latest:", latest)
+
+		newer, ok := version.IsNewer(latest, version.Version)
+		switch {
+		case !ok:
+			fmt.Println("Could not compare versions (development build?)")
+		case newer:
+			fmt.Printf("⬆️  A newer version is available: %s\n", latest)
+		default:
+			fmt.Println("✅ You are on the latest version")
+		}
 	},
 }
 
 func init() {
+	versionCmd.Flags().Bool("check", false, "check whether a newer release is available")
+	versionCmd.Flags().String("check-url", version.ReleasesURL, "releases endpoint returning {\"tag_name\": ...}")
 	rootCmd.AddCommand(versionCmd)
 }
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mistic0xb/pekka/internal/nwc"
+	"github.com/mistic0xb/pekka/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var walletCmd = &cobra.Command{
+	Use:   "wallet",
+	Short: "Inspect the configured NWC wallet",
+}
+
+var walletInfoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Show the wallet's supported NIP-47 methods",
+	Long:  `Connects to the wallet and prints its advertised methods, network and budget (if available).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := GetConfig()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+
+		client, err := nwc.NewClient(ctx, cfg.NWCUrl, nwc.RetryConfig{
+			Attempts: cfg.NWC.ConnectRetries,
+			Timeout:  cfg.NWC.ConnectTimeout,
+		})
+		if err != nil {
+			fmt.Printf("Error creating wallet client: %v\n", err)
+			return
+		}
+
+		s := ui.NewSpinner("Connecting to wallet", 11, "yellow")
+		err = client.Connect(ctx)
+		s.Stop()
+		if err != nil {
+			fmt.Printf("Error connecting to wallet: %v\n", err)
+			return
+		}
+		defer client.Close()
+
+		info, err := client.GetInfo(ctx)
+		if err != nil {
+			fmt.Printf("Error fetching wallet info: %v\n", err)
+			return
+		}
+
+		fmt.Println("=== Wallet Info ===")
+		fmt.Println()
+		if info.Alias != "" {
+			fmt.Printf("Alias: %s\n", info.Alias)
+		}
+		if info.Network != "" {
+			fmt.Printf("Network: %s\n", info.Network)
+		}
+		if info.BlockHeight > 0 {
+			fmt.Printf("Block Height: %d\n", info.BlockHeight)
+		}
+		fmt.Printf("Methods: %s\n", strings.Join(info.Methods, ", "))
+		if len(info.Notifications) > 0 {
+			fmt.Printf("Notifications: %s\n", strings.Join(info.Notifications, ", "))
+		}
+		fmt.Println()
+
+		if !slices.Contains(info.Methods, "pay_invoice") {
+			fmt.Println("⚠️  Wallet does not advertise pay_invoice, Pekka cannot zap with it")
+			fmt.Println()
+		}
+
+		if slices.Contains(info.Methods, "get_budget") {
+			budget, err := client.GetBudget(ctx)
+			if err != nil {
+				fmt.Printf("Could not fetch budget: %v\n", err)
+			} else if budget.TotalBudget > 0 {
+				fmt.Printf("Budget: %d/%d sats used", budget.UsedBudget/1000, budget.TotalBudget/1000)
+				if budget.RenewalPeriod != "" {
+					fmt.Printf(" (renews %s)", budget.RenewalPeriod)
+				}
+				fmt.Println()
+			} else {
+				fmt.Println("Budget: unlimited")
+			}
+			fmt.Println()
+		}
+
+		fmt.Println("===================")
+	},
+}
+
+func init() {
+	walletCmd.AddCommand(walletInfoCmd)
+	rootCmd.AddCommand(walletCmd)
+}
@@ -2,51 +2,494 @@ package config
 
 import (
 	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+// List sources
+const (
+	ListSourceNIP51   = "nip51"   // A NIP-51 kind 30000 list (selected_list)
+	ListSourceFollows = "follows" // The author's kind 3 follow list
+)
+
+// BTC price sources for zap.amount_fiat
+const (
+	FiatSourceCoinbase = "coinbase" // api.coinbase.com spot price (default)
+	FiatSourceMempool  = "mempool"  // mempool.space prices (USD, EUR, GBP, ...)
+)
+
+// List decryption preferences
+const (
+	DecryptAuto  = "auto"  // Try NIP-44, then fall back to NIP-04
+	DecryptNIP44 = "nip44" // Only try NIP-44
+	DecryptNIP04 = "nip04" // Only try NIP-04
+)
+
+// Run modes
+const (
+	ModeLive   = "live"   // Pay invoices and record zaps
+	ModeShadow = "shadow" // Do everything except pay, record to shadow_zaps
+)
+
+// Signers, selectable per action
+const (
+	SignerBunker = "bunker" // Remote signing through the NIP-46 bunker (default)
+	SignerAnon   = "anon"   // A throwaway key per event
+	SignerLocal  = "local"  // A private key from author.nsec
+)
+
+// Content warning policies (NIP-36)
+const (
+	ContentWarningZap  = "zap"  // Zap notes whether or not they carry a content warning (default)
+	ContentWarningSkip = "skip" // Never zap notes with a content warning
+	ContentWarningOnly = "only" // Only zap notes with a content warning
+)
+
+// Handling of notes older than one already zapped for the same author
+const (
+	OutOfOrderProcess = "process" // Zap them like any other note not zapped yet (default)
+	OutOfOrderSkip    = "skip"    // Treat them as backfill and skip them
+)
+
+// Content normalization for zap.dedup_by_content
+const (
+	DedupExact = "exact" // Compare content as posted, ignoring surrounding whitespace
+	DedupBasic = "basic" // Also ignore case and whitespace differences (default)
+	DedupLoose = "loose" // Also ignore links, nostr: references and punctuation
 )
 
 // Config holds all bot configuration
 type Config struct {
-	Author        AuthorConfig   `mapstructure:"author"`
-	Relays        []string       `mapstructure:"relays"`
-	SelectedList  string         `mapstructure:"selected_list"`
-	NWCUrl        string         `mapstructure:"nwc_url"`
-	Zap           ZapConfig      `mapstructure:"zap"`
-	Reaction      ReactionConfig `mapstructure:"reaction"`
-	Budget        BudgetConfig   `mapstructure:"budget"`
-	ResponseDelay int            `mapstructure:"response_delay"`
-	Database      DatabaseConfig `mapstructure:"database"`
+	Mode          string           `mapstructure:"mode"`
+	Author        AuthorConfig     `mapstructure:"author"`
+	Bunker        BunkerConfig     `mapstructure:"bunker"`
+	RelayList     []RelayEntry     `mapstructure:"relays"` // URLs or {url, read, write} objects
+	Relays        []string         `mapstructure:"-"`      // Every relay URL, filled from RelayList by Validate
+	SelectedList  string           `mapstructure:"selected_list"`
+	NWCUrl        string           `mapstructure:"nwc_url"`
+	NWC           NWCConfig        `mapstructure:"nwc"`
+	Zap           ZapConfig        `mapstructure:"zap"`
+	Reaction      ReactionConfig   `mapstructure:"reaction"`
+	Budget        BudgetConfig     `mapstructure:"budget"`
+	ResponseDelay int              `mapstructure:"response_delay"`
+	Database      DatabaseConfig   `mapstructure:"database"`
+	Publish       PublishConfig    `mapstructure:"publish"`
+	List          ListConfig       `mapstructure:"list"`
+	Clock         ClockConfig      `mapstructure:"clock"`
+	RelayPrune    RelayPruneConfig `mapstructure:"relay_prune"`
+	CatchUp       CatchUpConfig    `mapstructure:"catch_up"`
+	Audit         AuditConfig      `mapstructure:"audit"`
+	Webhook       WebhookConfig    `mapstructure:"webhook"`
+
+	SummaryInterval time.Duration `mapstructure:"summary_interval"` // Print a one-line session summary this often (0 disables)
+
+	Profiles map[string]any `mapstructure:"profiles"` // Overrides applied by --profile, see cmd/root.go
 }
 
 // Reaction configuration
 type ReactionConfig struct {
 	Enabled   bool   `mapstructure:"enabled"`
-	Content   string `mapstructure:"content"`    // The emoji/reaction text (e.g., ":catJAM:" or "🔥")
+	Content   string `mapstructure:"content"`    // The emoji/reaction text (e.g., ":catJAM:" or "🔥"), {author} mentions the author
 	EmojiName string `mapstructure:"emoji_name"` // Optional custom emoji name
 	EmojiURL  string `mapstructure:"emoji_url"`  // Optional custom emoji URL (gif/image)
+	Signer    string `mapstructure:"signer"`     // "bunker" (default), "anon" or "local"
+
+	Timeout time.Duration `mapstructure:"timeout"` // Per attempt, independent of the zap (default 60s)
+
+	RequireZapSuccess bool `mapstructure:"require_zap_success"` // React only after the zap went through
+
+	Extra   []ReactionEmoji `mapstructure:"extra"`   // More reactions per note, each published as its own kind 7
+	Spacing time.Duration   `mapstructure:"spacing"` // Pause between reactions to the same note (default 1s)
+}
+
+// ReactionEmoji is the content of one kind 7 reaction
+type ReactionEmoji struct {
+	Content   string `mapstructure:"content"`    // The emoji/reaction text, {author} mentions the author
+	EmojiName string `mapstructure:"emoji_name"` // Optional custom emoji name
+	EmojiURL  string `mapstructure:"emoji_url"`  // Optional custom emoji URL (gif/image)
+}
+
+// Emojis returns every reaction published per note, the main one first
+func (r *ReactionConfig) Emojis() []ReactionEmoji {
+	main := ReactionEmoji{Content: r.Content, EmojiName: r.EmojiName, EmojiURL: r.EmojiURL}
+	return append([]ReactionEmoji{main}, r.Extra...)
+}
+
+// DefaultReactionSpacing is used when reaction.spacing is not set
+const DefaultReactionSpacing = time.Second
+
+// ReactionSpacing returns the pause between reactions to the same note, so
+// relays do not rate-limit a burst of them
+func (r *ReactionConfig) ReactionSpacing() time.Duration {
+	if r.Spacing <= 0 {
+		return DefaultReactionSpacing
+	}
+	return r.Spacing
+}
+
+// DefaultReactionTimeout is used when reaction.timeout is not set
+const DefaultReactionTimeout = 60 * time.Second
+
+// AttemptTimeout returns how long one reaction attempt (sign and publish) may take
+func (r *ReactionConfig) AttemptTimeout() time.Duration {
+	if r.Timeout <= 0 {
+		return DefaultReactionTimeout
+	}
+	return r.Timeout
+}
+
+// Validate checks the reaction settings. It is kept separate from
+// Config.Validate because a broken reaction setup only disables reactions,
+// it never stops the bot from zapping.
+func (r *ReactionConfig) Validate() error {
+	if !r.Enabled {
+		return nil
+	}
+
+	for i, emoji := range r.Emojis() {
+		name := "reaction"
+		if i > 0 {
+			name = fmt.Sprintf("reaction.extra[%d]", i-1)
+		}
+
+		if emoji.Content == "" {
+			return fmt.Errorf("%s.content is required when reactions are enabled", name)
+		}
+
+		// If custom emoji is provided, both name and URL are required
+		if (emoji.EmojiName != "" && emoji.EmojiURL == "") ||
+			(emoji.EmojiName == "" && emoji.EmojiURL != "") {
+			return fmt.Errorf("both %s.emoji_name and %s.emoji_url must be provided together", name, name)
+		}
+	}
+
+	if r.Timeout < 0 {
+		return fmt.Errorf("reaction.timeout cannot be negative")
+	}
+
+	if r.Spacing < 0 {
+		return fmt.Errorf("reaction.spacing cannot be negative")
+	}
+
+	return nil
+}
+
+// BunkerConfig controls how the NIP-46 bunker connection is surfaced
+type BunkerConfig struct {
+	AuthURLFile   string `mapstructure:"auth_url_file"`  // Also write the auth URL here when approval is needed
+	MaxConcurrent int    `mapstructure:"max_concurrent"` // Signer requests in flight at once (0 = unlimited)
+
+	KeepaliveInterval time.Duration `mapstructure:"keepalive_interval"` // How often the bunker session is checked (default 4h)
+	KeepaliveJitter   time.Duration `mapstructure:"keepalive_jitter"`   // Random extra wait per check (default a tenth of the interval)
 }
 
 type AuthorConfig struct {
 	NPub      string `mapstructure:"npub"`
 	BunkerURL string `mapstructure:"bunker_url"` // Changed from NSec
+	NSec      string `mapstructure:"nsec"`       // Only for the "local" signer
 
 }
 
+// NWCConfig controls the wallet relay connection
+type NWCConfig struct {
+	ConnectRetries int           `mapstructure:"connect_retries"` // Connection attempts at startup (default 1)
+	ConnectTimeout time.Duration `mapstructure:"connect_timeout"` // Per-attempt timeout (0 = no extra limit)
+
+	BalanceInterval time.Duration `mapstructure:"balance_interval"`   // Re-check the balance this often (0 disables)
+	BalanceEvery    int           `mapstructure:"balance_every_zaps"` // Re-check the balance after this many zaps (0 disables)
+}
+
 type ZapConfig struct {
-	Amount  int    `mapstructure:"amount"`
-	Comment string `mapstructure:"comment"`
+	Amount        int       `mapstructure:"amount"`
+	Comment       string    `mapstructure:"comment"`
+	StoreReceipts bool      `mapstructure:"store_receipts"` // Wait for kind 9735 receipts and save them
+	AllowSelf     bool      `mapstructure:"allow_self"`     // Allow zapping our own notes (testing)
+	Presets       []int     `mapstructure:"presets"`        // Amount choices for interactive zaps
+	Rules         []ZapRule `mapstructure:"rules"`          // Content-based amounts, first match wins
+	SampleRate    *float64  `mapstructure:"sample_rate"`    // Fraction of qualifying notes to zap (unset = all)
+	Signer        string    `mapstructure:"signer"`         // "bunker" (default), "anon" or "local"
+	BumpToMin     bool      `mapstructure:"bump_to_min"`    // Raise amounts below the LNURL minimum, up to budget.max_per_zap
+
+	MaxNoteAge time.Duration `mapstructure:"max_note_age"` // Skip notes created longer ago than this (0 disables)
+	OutOfOrder string        `mapstructure:"out_of_order"` // "process" (default) or "skip" notes older than the author's last zapped one
+
+	SignTimeout    time.Duration `mapstructure:"sign_timeout"`     // How long the signer gets per zap request (default 60s)
+	SignGraceRetry bool          `mapstructure:"sign_grace_retry"` // Ask the signer once more when it times out (pending approval)
+
+	Languages       []string `mapstructure:"languages"`         // ISO 639-1 codes; skip notes labeled with another language
+	SkipMentionOnly bool     `mapstructure:"skip_mention_only"` // Skip notes that are little more than nostr: mentions
+	ContentWarning  string   `mapstructure:"content_warning"`   // "zap" (default), "skip" or "only" for NIP-36 notes
+	RequireHashtags []string `mapstructure:"require_hashtags"`  // Only zap notes with one of these "t" tags (case-insensitive)
+	SkipRepublished bool     `mapstructure:"skip_republished"`  // Skip bridged notes (NIP-48 proxy tag) and "I just zapped" activity echoes
+
+	DedupByContent bool          `mapstructure:"dedup_by_content"` // Skip notes whose content was zapped recently, across authors
+	DedupWindow    time.Duration `mapstructure:"dedup_window"`     // How long zapped content is remembered (default 24h)
+	DedupNormalize string        `mapstructure:"dedup_normalize"`  // "basic" (default), "exact" or "loose"
+
+	AmountFiat string `mapstructure:"amount_fiat"` // Fiat amount per zap, e.g. "$0.10" or "0.10 EUR" (replaces zap.amount)
+	FiatSource string `mapstructure:"fiat_source"` // BTC price API: "coinbase" (default) or "mempool"
+
+	RecipientRelays bool `mapstructure:"recipient_relays"` // Add the recipient's NIP-65 write relays to zap requests
+
+	ConfirmWindow time.Duration `mapstructure:"confirm_window"` // Record zaps as pending until verified within this window (0 disables)
+
+	DirectNPubs []string `mapstructure:"direct_npubs"` // Monitor exactly these npubs, bypassing lists entirely
+
+	Automated AutomatedConfig `mapstructure:"automated"` // Skip notes that look automated
+}
+
+// AutomatedConfig toggles the heuristics that skip automated-looking notes,
+// so a compromised list member cannot farm zaps with a script
+type AutomatedConfig struct {
+	LinkOnly        bool          `mapstructure:"link_only"`        // Skip notes that are nothing but links
+	RepeatedContent int           `mapstructure:"repeated_content"` // Skip once an author posts the same content this many times (0 disables)
+	RegularInterval int           `mapstructure:"regular_interval"` // Skip once this many notes in a row arrive evenly spaced (0 disables)
+	Window          time.Duration `mapstructure:"window"`           // How long an author's notes are remembered (default 24h)
+}
+
+// HistoryWindow returns how long notes are kept for the automation heuristics
+func (a *AutomatedConfig) HistoryWindow() time.Duration {
+	if a.Window <= 0 {
+		return 24 * time.Hour
+	}
+	return a.Window
+}
+
+// UsesDirectNPubs reports whether the monitored set is zap.direct_npubs
+// rather than a list
+func (z ZapConfig) UsesDirectNPubs() bool {
+	return len(z.DirectNPubs) > 0
 }
 
 type BudgetConfig struct {
 	DailyLimit   int `mapstructure:"daily_limit"`
 	PerNPubLimit int `mapstructure:"per_npub_limit"`
+	MinBalance   int `mapstructure:"min_balance"` // Stop zapping when the wallet would drop below this (sats, 0 disables)
+	MaxPerZap    int `mapstructure:"max_per_zap"` // Largest single zap in sats (0 disables)
+
+	LifetimeLimit int `mapstructure:"lifetime_limit"` // Stop zapping for good once this many sats were spent in total (0 disables)
+	ConfirmAbove  int `mapstructure:"confirm_above"`  // Ask before zapping more than this (sats); skipped without a terminal (0 disables)
+
+	MaxAuthorsPerDay int `mapstructure:"max_authors_per_day"` // Only authors already zapped today get zaps once this many were (0 disables)
+
+	PriorityNPubs []string `mapstructure:"priority_npubs"` // Authors exempt from per_npub_limit (daily_limit still applies)
 }
 
 type DatabaseConfig struct {
 	Path string `mapstructure:"path"`
 }
 
+// ListConfig controls where the monitored npubs come from
+type ListConfig struct {
+	Source            string        `mapstructure:"source"`              // "nip51" (default) or "follows"
+	RefreshInterval   time.Duration `mapstructure:"refresh_interval"`    // Re-fetch the list periodically (0 disables)
+	MaxMembers        int           `mapstructure:"max_members"`         // Require confirmation above this size (0 disables)
+	DecryptPreference string        `mapstructure:"decrypt_preference"`  // "auto" (default), "nip44" or "nip04"
+	Relays            []string      `mapstructure:"relays"`              // Relays to fetch lists from (defaults to the main relays)
+	FetchRetries      int           `mapstructure:"fetch_retries"`       // Extra fetch attempts when no list events arrive
+	FetchRetryBackoff time.Duration `mapstructure:"fetch_retry_backoff"` // Wait before the first retry, doubling after (default 5s)
+	PublicOnly        bool          `mapstructure:"public_only"`         // Use public 'p' tags only, never decrypt private members
+	SelectionTimeout  time.Duration `mapstructure:"selection_timeout"`   // Give up on the list selection prompt after this long (0 disables)
+}
+
+// FetchBackoff returns the wait before the first list fetch retry
+func (l ListConfig) FetchBackoff() time.Duration {
+	if l.FetchRetryBackoff <= 0 {
+		return 5 * time.Second
+	}
+	return l.FetchRetryBackoff
+}
+
+// ListRelays returns the relays lists are fetched from: list.relays if set,
+// otherwise the read relays
+func (c *Config) ListRelays() []string {
+	if len(c.List.Relays) > 0 {
+		return c.List.Relays
+	}
+	return c.ReadRelays()
+}
+
+// UsesFollows reports whether the monitored set comes from the follow list
+func (l ListConfig) UsesFollows() bool {
+	return l.Source == ListSourceFollows
+}
+
+// ZapRule zaps a different amount when a note matches a regex
+type ZapRule struct {
+	Match  string `mapstructure:"match"`  // Regex matched against content and #hashtags
+	Amount int    `mapstructure:"amount"` // Sats to zap on match
+}
+
+// DefaultZapPresets are offered when zap.presets is not configured
+var DefaultZapPresets = []int{21, 100, 1000}
+
+// AmountPresets returns the configured interactive amount presets
+func (z ZapConfig) AmountPresets() []int {
+	if len(z.Presets) == 0 {
+		return DefaultZapPresets
+	}
+	return z.Presets
+}
+
+// SampleProbability returns the chance a qualifying note is zapped, treating
+// an unset rate as zapping every note
+func (z ZapConfig) SampleProbability() float64 {
+	if z.SampleRate == nil {
+		return 1
+	}
+	return *z.SampleRate
+}
+
+// fiatSymbols maps currency symbols accepted in zap.amount_fiat to ISO codes
+var fiatSymbols = map[string]string{"$": "USD", "€": "EUR", "£": "GBP"}
+
+// FiatAmount parses zap.amount_fiat ("$0.10", "0.10 USD", "€1") into a value
+// and an ISO currency code
+func (z ZapConfig) FiatAmount() (float64, string, error) {
+	s := strings.TrimSpace(z.AmountFiat)
+	currency := ""
+	for symbol, code := range fiatSymbols {
+		if rest, ok := strings.CutPrefix(s, symbol); ok {
+			s, currency = strings.TrimSpace(rest), code
+			break
+		}
+	}
+
+	if fields := strings.Fields(s); len(fields) == 2 && currency == "" {
+		s, currency = fields[0], strings.ToUpper(fields[1])
+	}
+	if currency == "" {
+		return 0, "", fmt.Errorf("missing currency in %q, use e.g. $0.10 or 0.10 USD", z.AmountFiat)
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || value <= 0 {
+		return 0, "", fmt.Errorf("%q is not a positive amount", z.AmountFiat)
+	}
+
+	return value, currency, nil
+}
+
+// ContentDedupWindow returns how long zapped content blocks identical notes
+func (z ZapConfig) ContentDedupWindow() time.Duration {
+	if z.DedupWindow <= 0 {
+		return 24 * time.Hour
+	}
+	return z.DedupWindow
+}
+
+// DefaultSignTimeout is used when zap.sign_timeout is not set
+const DefaultSignTimeout = 60 * time.Second
+
+// SigningTimeout returns how long signing a zap request may take per attempt
+func (z ZapConfig) SigningTimeout() time.Duration {
+	if z.SignTimeout <= 0 {
+		return DefaultSignTimeout
+	}
+	return z.SignTimeout
+}
+
+// ZapTimeout returns the overall time budget for one zap: signing (twice
+// with the grace retry) plus a minute for the LNURL and wallet round trips
+func (z ZapConfig) ZapTimeout() time.Duration {
+	signing := z.SigningTimeout()
+	if z.SignGraceRetry {
+		signing *= 2
+	}
+	return signing + time.Minute
+}
+
+// CatchUpConfig controls the missed-notes check when the bot starts
+type CatchUpConfig struct {
+	Enabled bool          `mapstructure:"enabled"` // Count notes posted while offline and offer to zap them
+	Window  time.Duration `mapstructure:"window"`  // Look back at most this far (default 24h)
+}
+
+// DefaultCatchUpWindow is used when catch_up.window is not set
+const DefaultCatchUpWindow = 24 * time.Hour
+
+// LookBack returns how far back the catch-up check may reach
+func (c CatchUpConfig) LookBack() time.Duration {
+	if c.Window <= 0 {
+		return DefaultCatchUpWindow
+	}
+	return c.Window
+}
+
+// AuditConfig records what the bot ran with
+type AuditConfig struct {
+	ConfigSnapshot bool `mapstructure:"config_snapshot"` // Write the effective config, secrets masked, to logs/ on each start
+}
+
+// WebhookConfig posts each zap as JSON to an HTTP endpoint
+type WebhookConfig struct {
+	URL     string        `mapstructure:"url"`     // POST here after each zap (empty disables)
+	Retries int           `mapstructure:"retries"` // Retry a failed delivery this many times, with backoff (0 disables)
+	Timeout time.Duration `mapstructure:"timeout"` // Per attempt (default 10s)
+}
+
+// ClockConfig guards against a skewed system clock producing event
+// timestamps relays reject
+type ClockConfig struct {
+	Offset  time.Duration `mapstructure:"offset"`   // Fixed correction added to event timestamps (at most 1h)
+	Check   bool          `mapstructure:"check"`    // Compare our clock against relays at startup
+	MaxSkew time.Duration `mapstructure:"max_skew"` // Skew tolerated before warning/correcting (default 30s)
+}
+
+// SkewThreshold returns the tolerated skew before warning
+func (c ClockConfig) SkewThreshold() time.Duration {
+	if c.MaxSkew <= 0 {
+		return 30 * time.Second
+	}
+	return c.MaxSkew
+}
+
+// RelayPruneConfig drops relays that stay unreachable during a run
+type RelayPruneConfig struct {
+	After time.Duration `mapstructure:"after"` // Unreachable this long before being dropped for the session (0 disables)
+}
+
+// PublishConfig controls how many relays must accept bot-published events
+type PublishConfig struct {
+	MinSuccess int  `mapstructure:"min_success"` // Minimum relays that must accept (default 1)
+	Quorum     bool `mapstructure:"quorum"`      // Require a majority of relays instead
+
+	BatchWindow time.Duration `mapstructure:"batch_window"` // Coalesce reactions published within this window (0 disables)
+
+	RateLimitRetries int  `mapstructure:"rate_limit_retries"` // Retry relays answering "rate-limited:" with backoff (0 disables)
+	KeepBlocked      bool `mapstructure:"keep_blocked"`       // Keep publishing to relays answering "blocked:" / "restricted:"
+}
+
+// Threshold returns the number of relays that must accept a publish
+func (p PublishConfig) Threshold(relayCount int) int {
+	threshold := p.MinSuccess
+	if p.Quorum && relayCount/2+1 > threshold {
+		threshold = relayCount/2 + 1
+	}
+	if threshold < 1 {
+		threshold = 1
+	}
+	return threshold
+}
+
+// IsShadow reports whether the bot runs in shadow mode
+func (c *Config) IsShadow() bool {
+	return c.Mode == ModeShadow
+}
+
 // Validate checks if config is valid
 func (c *Config) Validate() error {
+	switch c.Mode {
+	case "", ModeLive, ModeShadow:
+	default:
+		return fmt.Errorf("mode must be %q or %q, got %q", ModeLive, ModeShadow, c.Mode)
+	}
+
 	if c.Author.NPub == "" {
 		return fmt.Errorf("author.npub is required")
 	}
@@ -55,37 +498,238 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("author.bunker_url is required")
 	}
 
-	if len(c.Relays) == 0 {
-		return fmt.Errorf("at least one relay is required")
+	if len(c.RelayList) > 0 {
+		c.Relays = c.Relays[:0]
+		for _, entry := range c.RelayList {
+			c.Relays = append(c.Relays, entry.URL)
+		}
 	}
 	if len(c.Relays) == 0 {
 		return fmt.Errorf("at least one relay is required")
 	}
+	if len(c.ReadRelays()) == 0 || len(c.WriteRelays()) == 0 {
+		return fmt.Errorf("relays needs at least one read and one write relay")
+	}
+	for name, relays := range map[string][]string{"relays": c.Relays, "list.relays": c.List.Relays} {
+		for _, relay := range relays {
+			if !strings.HasPrefix(relay, "wss://") && !strings.HasPrefix(relay, "ws://") {
+				return fmt.Errorf("%s contains an invalid relay URL %q (must start with wss:// or ws://)", name, relay)
+			}
+		}
+	}
 
 	if c.NWCUrl == "" {
 		return fmt.Errorf("nwc_url is required")
 	}
 
-	if c.Zap.Amount <= 0 {
+	for i, rule := range c.Zap.Rules {
+		if _, err := regexp.Compile(rule.Match); err != nil {
+			return fmt.Errorf("zap.rules[%d].match is not a valid regex: %w", i, err)
+		}
+		if rule.Amount <= 0 {
+			return fmt.Errorf("zap.rules[%d].amount must be positive", i)
+		}
+	}
+
+	for name, kind := range map[string]string{"zap.signer": c.Zap.Signer, "reaction.signer": c.Reaction.Signer} {
+		switch kind {
+		case "", SignerBunker, SignerAnon:
+		case SignerLocal:
+			if c.Author.NSec == "" {
+				return fmt.Errorf("%s is %q but author.nsec is not set", name, SignerLocal)
+			}
+		default:
+			return fmt.Errorf("%s must be %q, %q or %q, got %q", name, SignerBunker, SignerAnon, SignerLocal, kind)
+		}
+	}
+
+	if r := c.Zap.SampleRate; r != nil && (*r <= 0 || *r > 1) {
+		return fmt.Errorf("zap.sample_rate must be above 0.0 and at most 1.0 (leave it unset to zap every note)")
+	}
+
+	for _, preset := range c.Zap.Presets {
+		if preset <= 0 {
+			return fmt.Errorf("zap.presets must all be positive, got %d", preset)
+		}
+	}
+
+	if c.NWC.ConnectRetries < 0 {
+		return fmt.Errorf("nwc.connect_retries cannot be negative")
+	}
+
+	if c.NWC.ConnectTimeout < 0 {
+		return fmt.Errorf("nwc.connect_timeout cannot be negative")
+	}
+
+	if c.Zap.AmountFiat != "" {
+		if _, _, err := c.Zap.FiatAmount(); err != nil {
+			return fmt.Errorf("zap.amount_fiat: %w", err)
+		}
+		switch c.Zap.FiatSource {
+		case "", FiatSourceCoinbase, FiatSourceMempool:
+		default:
+			return fmt.Errorf("zap.fiat_source must be %q or %q, got %q", FiatSourceCoinbase, FiatSourceMempool, c.Zap.FiatSource)
+		}
+	} else if c.Zap.Amount <= 0 {
 		return fmt.Errorf("zap amount must be positive")
 	}
 
-	if c.Reaction.Enabled {
-		if c.Reaction.Content == "" {
-			return fmt.Errorf("reaction.content is required when reactions are enabled")
+	if c.NWC.BalanceInterval < 0 || c.NWC.BalanceEvery < 0 {
+		return fmt.Errorf("nwc.balance_interval and nwc.balance_every_zaps cannot be negative")
+	}
+
+	if c.Zap.ConfirmWindow < 0 {
+		return fmt.Errorf("zap.confirm_window cannot be negative")
+	}
+
+	if a := c.Zap.Automated; a.RepeatedContent < 0 || a.RegularInterval < 0 || a.Window < 0 {
+		return fmt.Errorf("zap.automated.repeated_content, regular_interval and window cannot be negative")
+	}
+
+	if n := c.Zap.Automated.RegularInterval; n > 0 && n < 3 {
+		return fmt.Errorf("zap.automated.regular_interval must be at least 3 notes (two gaps to compare), got %d", n)
+	}
+
+	for _, npub := range c.Zap.DirectNPubs {
+		if prefix, _, err := nip19.Decode(npub); err != nil || prefix != "npub" {
+			return fmt.Errorf("zap.direct_npubs contains an invalid npub: %q", npub)
 		}
+	}
 
-		// If custom emoji is provided, both name and URL are required
-		if (c.Reaction.EmojiName != "" && c.Reaction.EmojiURL == "") ||
-			(c.Reaction.EmojiName == "" && c.Reaction.EmojiURL != "") {
-			return fmt.Errorf("both reaction.emoji_name and reaction.emoji_url must be provided together")
+	for _, npub := range c.Budget.PriorityNPubs {
+		if prefix, _, err := nip19.Decode(npub); err != nil || prefix != "npub" {
+			return fmt.Errorf("budget.priority_npubs contains an invalid npub: %q", npub)
+		}
+	}
+
+	if c.RelayPrune.After < 0 {
+		return fmt.Errorf("relay_prune.after cannot be negative")
+	}
+
+	if c.Publish.BatchWindow < 0 {
+		return fmt.Errorf("publish.batch_window cannot be negative")
+	}
+
+	if c.Clock.Offset.Abs() > time.Hour {
+		return fmt.Errorf("clock.offset cannot exceed 1h either way")
+	}
+
+	if c.CatchUp.Window < 0 {
+		return fmt.Errorf("catch_up.window cannot be negative")
+	}
+
+	if c.Webhook.URL != "" {
+		if u, err := url.Parse(c.Webhook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook.url must be an http(s) URL")
+		}
+	}
+	if c.Webhook.Retries < 0 || c.Webhook.Timeout < 0 {
+		return fmt.Errorf("webhook.retries and webhook.timeout cannot be negative")
+	}
+
+	if c.Budget.MinBalance < 0 {
+		return fmt.Errorf("budget.min_balance cannot be negative")
+	}
+
+	for i, lang := range c.Zap.Languages {
+		if len(lang) != 2 {
+			return fmt.Errorf("zap.languages must contain ISO 639-1 codes like \"en\", got %q", lang)
+		}
+		c.Zap.Languages[i] = strings.ToLower(lang)
+	}
+
+	for i, hashtag := range c.Zap.RequireHashtags {
+		hashtag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(hashtag), "#"))
+		if hashtag == "" {
+			return fmt.Errorf("zap.require_hashtags cannot contain empty entries")
+		}
+		c.Zap.RequireHashtags[i] = hashtag
+	}
+
+	switch c.Zap.OutOfOrder {
+	case "", OutOfOrderSkip, OutOfOrderProcess:
+	default:
+		return fmt.Errorf("zap.out_of_order must be %q or %q, got %q",
+			OutOfOrderProcess, OutOfOrderSkip, c.Zap.OutOfOrder)
+	}
+
+	switch c.Zap.ContentWarning {
+	case "", ContentWarningZap, ContentWarningSkip, ContentWarningOnly:
+	default:
+		return fmt.Errorf("zap.content_warning must be %q, %q or %q, got %q",
+			ContentWarningZap, ContentWarningSkip, ContentWarningOnly, c.Zap.ContentWarning)
+	}
+
+	switch c.Zap.DedupNormalize {
+	case "", DedupExact, DedupBasic, DedupLoose:
+	default:
+		return fmt.Errorf("zap.dedup_normalize must be %q, %q or %q, got %q",
+			DedupExact, DedupBasic, DedupLoose, c.Zap.DedupNormalize)
+	}
+
+	if c.Zap.DedupWindow < 0 {
+		return fmt.Errorf("zap.dedup_window cannot be negative")
+	}
+
+	if c.Publish.RateLimitRetries < 0 {
+		return fmt.Errorf("publish.rate_limit_retries cannot be negative")
+	}
+
+	if c.Bunker.MaxConcurrent < 0 {
+		return fmt.Errorf("bunker.max_concurrent cannot be negative")
+	}
+
+	if c.Bunker.KeepaliveInterval < 0 || c.Bunker.KeepaliveJitter < 0 {
+		return fmt.Errorf("bunker.keepalive_interval and bunker.keepalive_jitter cannot be negative")
+	}
+
+	if c.Zap.SignTimeout < 0 {
+		return fmt.Errorf("zap.sign_timeout cannot be negative")
+	}
+
+	if c.Zap.MaxNoteAge < 0 {
+		return fmt.Errorf("zap.max_note_age cannot be negative")
+	}
+
+	if c.Budget.MaxAuthorsPerDay < 0 {
+		return fmt.Errorf("budget.max_authors_per_day cannot be negative")
+	}
+
+	if c.Budget.MaxPerZap < 0 {
+		return fmt.Errorf("budget.max_per_zap cannot be negative")
+	}
+
+	if c.Budget.MaxPerZap > 0 {
+		if c.Zap.Amount > c.Budget.MaxPerZap {
+			return fmt.Errorf("zap.amount (%d) exceeds budget.max_per_zap (%d)", c.Zap.Amount, c.Budget.MaxPerZap)
+		}
+		for _, rule := range c.Zap.Rules {
+			if rule.Amount > c.Budget.MaxPerZap {
+				return fmt.Errorf("zap rule %q amount (%d) exceeds budget.max_per_zap (%d)", rule.Match, rule.Amount, c.Budget.MaxPerZap)
+			}
 		}
 	}
 
+	if c.Budget.ConfirmAbove < 0 {
+		return fmt.Errorf("budget.confirm_above cannot be negative")
+	}
+
+	if c.Budget.LifetimeLimit < 0 {
+		return fmt.Errorf("budget.lifetime_limit cannot be negative")
+	}
+
+	if c.Zap.BumpToMin && c.Budget.MaxPerZap == 0 {
+		return fmt.Errorf("zap.bump_to_min requires budget.max_per_zap")
+	}
+
 	if c.Budget.DailyLimit <= 0 {
 		return fmt.Errorf("daily budget limit must be positive")
 	}
 
+	if c.SummaryInterval < 0 {
+		return fmt.Errorf("summary_interval cannot be negative")
+	}
+
 	if c.ResponseDelay < 0 {
 		return fmt.Errorf("response delay must be positive")
 	}
@@ -94,6 +738,43 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("database path is required")
 	}
 
+	switch c.List.Source {
+	case "", ListSourceNIP51, ListSourceFollows:
+	default:
+		return fmt.Errorf("list.source must be %q or %q, got %q", ListSourceNIP51, ListSourceFollows, c.List.Source)
+	}
+
+	switch c.List.DecryptPreference {
+	case "", DecryptAuto, DecryptNIP44, DecryptNIP04:
+	default:
+		return fmt.Errorf("list.decrypt_preference must be %q, %q or %q, got %q",
+			DecryptAuto, DecryptNIP44, DecryptNIP04, c.List.DecryptPreference)
+	}
+
+	if c.List.MaxMembers < 0 {
+		return fmt.Errorf("list.max_members cannot be negative")
+	}
+
+	if c.List.FetchRetries < 0 {
+		return fmt.Errorf("list.fetch_retries cannot be negative")
+	}
+
+	if c.List.RefreshInterval < 0 {
+		return fmt.Errorf("list.refresh_interval cannot be negative")
+	}
+
+	if c.List.SelectionTimeout < 0 {
+		return fmt.Errorf("list.selection_timeout cannot be negative")
+	}
+
+	if c.Publish.MinSuccess < 0 {
+		return fmt.Errorf("publish.min_success cannot be negative")
+	}
+
+	if writeRelays := len(c.WriteRelays()); c.Publish.MinSuccess > writeRelays {
+		return fmt.Errorf("publish.min_success (%d) exceeds write relay count (%d)", c.Publish.MinSuccess, writeRelays)
+	}
+
 	return nil
 }
 
@@ -105,26 +786,157 @@ func (c *Config) Print() {
 	fmt.Printf("Author Npub: %s\n", c.Author.NPub)
 	fmt.Println()
 
-	if c.SelectedList != "" {
+	if c.IsShadow() {
+		fmt.Println("Mode: shadow (no payments, recorded to shadow_zaps)")
+		fmt.Println()
+	}
+
+	if c.Zap.UsesDirectNPubs() {
+		fmt.Printf("List Source: zap.direct_npubs (%d npubs)\n", len(c.Zap.DirectNPubs))
+	} else if c.List.UsesFollows() {
+		fmt.Println("List Source: follows (kind 3)")
+	} else if c.SelectedList != "" {
 		fmt.Printf("Selected List: %s\n", c.SelectedList)
 	}
+	if c.List.RefreshInterval > 0 {
+		fmt.Printf("List Refresh Interval: %s\n", c.List.RefreshInterval)
+	}
+	if c.List.PublicOnly {
+		fmt.Println("List Members: public only (private members are not decrypted)")
+	}
 
 	fmt.Println("Relays:")
 	for i, relay := range c.Relays {
-		fmt.Printf("  %v %s\n", i+1, relay)
+		fmt.Printf("  %v %s", i+1, relay)
+		if i < len(c.RelayList) && c.RelayList[i].Role() != "read+write" {
+			fmt.Printf(" (%s)", c.RelayList[i].Role())
+		}
+		fmt.Println()
 	}
 	fmt.Println()
 
-	fmt.Printf("Zap Amount: %d sats\n", c.Zap.Amount)
+	if len(c.List.Relays) > 0 {
+		fmt.Println("List Relays:")
+		for i, relay := range c.List.Relays {
+			fmt.Printf("  %v %s\n", i+1, relay)
+		}
+		fmt.Println()
+	}
+
+	if c.Zap.AmountFiat != "" {
+		fmt.Printf("Zap Amount: %s in sats at the current BTC price\n", c.Zap.AmountFiat)
+	} else {
+		fmt.Printf("Zap Amount: %d sats\n", c.Zap.Amount)
+	}
+	for _, rule := range c.Zap.Rules {
+		fmt.Printf("  %d sats when matching %q\n", rule.Amount, rule.Match)
+	}
+	if p := c.Zap.SampleProbability(); p < 1 {
+		fmt.Printf("Sample Rate: %.0f%% of notes\n", p*100)
+	}
+	if c.Zap.MaxNoteAge > 0 {
+		fmt.Printf("Max Note Age: %s\n", c.Zap.MaxNoteAge)
+	}
+	if len(c.Zap.Languages) > 0 {
+		fmt.Printf("Languages: %s (unlabeled notes pass)\n", strings.Join(c.Zap.Languages, ", "))
+	}
+	if c.Zap.SkipRepublished {
+		fmt.Println("Skipping bridged and republished-activity notes")
+	}
+	if c.Zap.SkipMentionOnly {
+		fmt.Println("Skipping mention-only notes")
+	}
+	if len(c.Zap.RequireHashtags) > 0 {
+		fmt.Printf("Required Hashtags: #%s\n", strings.Join(c.Zap.RequireHashtags, ", #"))
+	}
+	if c.Zap.OutOfOrder == OutOfOrderSkip {
+		fmt.Println("Skipping notes older than the author's last zapped one")
+	}
+	switch c.Zap.ContentWarning {
+	case ContentWarningSkip:
+		fmt.Println("Skipping notes with a content warning")
+	case ContentWarningOnly:
+		fmt.Println("Only zapping notes with a content warning")
+	}
+	if c.Zap.DedupByContent {
+		normalize := c.Zap.DedupNormalize
+		if normalize == "" {
+			normalize = DedupBasic
+		}
+		fmt.Printf("Content Dedup: %s (%s)\n", c.Zap.ContentDedupWindow(), normalize)
+	}
+	if a := c.Zap.Automated; a.LinkOnly || a.RepeatedContent > 0 || a.RegularInterval > 0 {
+		var checks []string
+		if a.LinkOnly {
+			checks = append(checks, "link-only notes")
+		}
+		if a.RepeatedContent > 0 {
+			checks = append(checks, fmt.Sprintf("same content %dx", a.RepeatedContent))
+		}
+		if a.RegularInterval > 0 {
+			checks = append(checks, fmt.Sprintf("%d evenly spaced notes", a.RegularInterval))
+		}
+		fmt.Printf("Skipping Automated Notes: %s\n", strings.Join(checks, ", "))
+	}
+	if c.Zap.BumpToMin {
+		fmt.Printf("Bump To LNURL Minimum: up to %d sats\n", c.Budget.MaxPerZap)
+	}
+	if c.Zap.Signer != "" && c.Zap.Signer != SignerBunker {
+		fmt.Printf("Zap Signer: %s\n", c.Zap.Signer)
+	}
+	if c.Zap.SignGraceRetry {
+		fmt.Printf("Zap Signing: %s per attempt, one grace retry\n", c.Zap.SigningTimeout())
+	}
+	if c.Reaction.Signer != "" && c.Reaction.Signer != SignerBunker {
+		fmt.Printf("Reaction Signer: %s\n", c.Reaction.Signer)
+	}
+	if c.Reaction.Enabled && c.Reaction.RequireZapSuccess {
+		fmt.Println("Reactions: only after a successful zap")
+	}
+	if c.Reaction.Enabled && len(c.Reaction.Extra) > 0 {
+		fmt.Printf("Reactions per Note: %d, %s apart\n", len(c.Reaction.Emojis()), c.Reaction.ReactionSpacing())
+	}
+	if c.Zap.StoreReceipts {
+		fmt.Println("Zap Receipts: stored")
+	}
+	if c.Zap.ConfirmWindow > 0 {
+		fmt.Printf("Zap Confirmation: pending until verified, within %s\n", c.Zap.ConfirmWindow)
+	}
 	fmt.Println()
 
 	fmt.Printf("Daily Budget Limit: %d sats\n", c.Budget.DailyLimit)
+	if c.Budget.LifetimeLimit > 0 {
+		fmt.Printf("Lifetime Budget Limit: %d sats\n", c.Budget.LifetimeLimit)
+	}
 	fmt.Printf("Per-NPub Limit: %d sats\n", c.Budget.PerNPubLimit)
+	if c.Budget.MaxAuthorsPerDay > 0 {
+		fmt.Printf("Max Authors Per Day: %d\n", c.Budget.MaxAuthorsPerDay)
+	}
+	if len(c.Budget.PriorityNPubs) > 0 {
+		fmt.Printf("Priority NPubs (no per-npub limit): %d\n", len(c.Budget.PriorityNPubs))
+	}
+	if c.Budget.MaxPerZap > 0 {
+		fmt.Printf("Max Per Zap: %d sats\n", c.Budget.MaxPerZap)
+	}
+	if c.Budget.ConfirmAbove > 0 {
+		fmt.Printf("Confirm Zaps Above: %d sats\n", c.Budget.ConfirmAbove)
+	}
+	if c.Budget.MinBalance > 0 {
+		fmt.Printf("Minimum Wallet Balance: %d sats\n", c.Budget.MinBalance)
+	}
 	fmt.Println()
 
 	fmt.Printf("Bot Response Delay: %d\n", c.ResponseDelay)
 	fmt.Println()
 
+	fmt.Printf("Publish Threshold: %d relay(s)\n", c.Publish.Threshold(len(c.WriteRelays())))
+	fmt.Println()
+
+	if c.Webhook.URL != "" {
+		fmt.Printf("Webhook: enabled, %d retries\n", c.Webhook.Retries)
+		fmt.Println()
+	}
+
 	fmt.Printf("Database Path: %s\n", c.Database.Path)
 	fmt.Println()
 	fmt.Println("===================================")
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// secretKeys are settings whose values are masked when displayed
+var secretKeys = map[string]bool{
+	"nwc_url":           true,
+	"author.bunker_url": true,
+	"author.nsec":       true,
+	"webhook.url":       true, // often embeds a token
+}
+
+// Setting is one resolved config value, keyed like the YAML file
+// (e.g. "budget.daily_limit")
+type Setting struct {
+	Key   string
+	Value string // masked for secrets
+}
+
+// Effective flattens the config into its settings in struct order, masking
+// secrets. Zero values are included so unset keys show up too.
+func (c *Config) Effective() []Setting {
+	var settings []Setting
+	flatten(reflect.ValueOf(*c), "", &settings)
+	return settings
+}
+
+// flatten walks struct fields by their mapstructure tags
+func flatten(v reflect.Value, prefix string, out *[]Setting) {
+	t := v.Type()
+	for i := range t.NumField() {
+		tag := t.Field(i).Tag.Get("mapstructure")
+		if tag == "" || tag == "-" || tag == "profiles" {
+			continue
+		}
+
+		key := prefix + tag
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			flatten(field, key+".", out)
+			continue
+		}
+
+		value := fmt.Sprint(field.Interface())
+		if secretKeys[key] {
+			value = MaskSecret(value)
+		}
+		*out = append(*out, Setting{Key: key, Value: value})
+	}
+}
+
+// MaskSecret hides all but the start of a secret so it can be recognized
+// without being leaked
+func MaskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	visible := min(len(value)/4, 12)
+	return value[:visible] + strings.Repeat("*", 8)
+}
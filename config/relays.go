@@ -0,0 +1,91 @@
+package config
+
+import (
+	"reflect"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// RelayEntry is one entry of the relays list. In the config file it is either
+// a plain URL (read and write) or an object with url, read and write keys,
+// mirroring NIP-65 relay roles.
+type RelayEntry struct {
+	URL   string `mapstructure:"url"`
+	Read  *bool  `mapstructure:"read"`  // Used to fetch lists, profiles and notes (default true)
+	Write *bool  `mapstructure:"write"` // Used to publish reactions and as zap receipt relays (default true)
+}
+
+// CanRead reports whether the relay is used for fetching
+func (r RelayEntry) CanRead() bool {
+	return r.Read == nil || *r.Read
+}
+
+// CanWrite reports whether the relay is used for publishing
+func (r RelayEntry) CanWrite() bool {
+	return r.Write == nil || *r.Write
+}
+
+// String renders the entry as its URL plus any non-default role
+func (r RelayEntry) String() string {
+	if role := r.Role(); role != "read+write" {
+		return r.URL + " (" + role + ")"
+	}
+	return r.URL
+}
+
+// Role describes the relay's role for display
+func (r RelayEntry) Role() string {
+	switch {
+	case r.CanRead() && r.CanWrite():
+		return "read+write"
+	case r.CanRead():
+		return "read"
+	case r.CanWrite():
+		return "write"
+	default:
+		return "unused"
+	}
+}
+
+// ReadRelays returns the relays used to fetch lists, profiles and notes
+func (c *Config) ReadRelays() []string {
+	return c.relaysWhere(RelayEntry.CanRead)
+}
+
+// WriteRelays returns the relays reactions and zap receipts are published to
+func (c *Config) WriteRelays() []string {
+	return c.relaysWhere(RelayEntry.CanWrite)
+}
+
+func (c *Config) relaysWhere(keep func(RelayEntry) bool) []string {
+	// Configs built in code may only set Relays, meaning read+write
+	if len(c.RelayList) == 0 {
+		return c.Relays
+	}
+
+	var relays []string
+	for _, entry := range c.RelayList {
+		if keep(entry) {
+			relays = append(relays, entry.URL)
+		}
+	}
+	return relays
+}
+
+// DecodeHook returns the hooks used to unmarshal the config: viper's
+// defaults plus plain strings as read+write relay entries
+func DecodeHook() mapstructure.DecodeHookFunc {
+	return mapstructure.ComposeDecodeHookFunc(
+		relayEntryHook,
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+	)
+}
+
+// relayEntryHook lets a relay be written as just its URL
+func relayEntryHook(from, to reflect.Type, data any) (any, error) {
+	if from.Kind() != reflect.String || to != reflect.TypeOf(RelayEntry{}) {
+		return data, nil
+	}
+	return RelayEntry{URL: data.(string)}, nil
+}
@@ -0,0 +1,131 @@
+package bot
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mistic0xb/pekka/config"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// regularIntervalSlack is how far apart the gaps between an author's notes
+// may be and still count as evenly spaced, on top of 2% of the average gap
+const regularIntervalSlack = 2 * time.Second
+
+// automationHistory remembers recent notes per author for the
+// zap.automated heuristics
+type automationHistory struct {
+	mu    sync.Mutex
+	notes map[string][]seenNote // pubkey -> notes in arrival order
+}
+
+// seenNote is what the heuristics keep of a note
+type seenNote struct {
+	id        string
+	hash      [sha256.Size]byte
+	hashed    bool // false when the content normalized to nothing
+	createdAt time.Time
+}
+
+// check records the note and returns the first heuristic it triggers with a
+// human-readable detail, or "" when the note does not look automated
+func (h *automationHistory) check(event *nostr.Event, cfg *config.AutomatedConfig) (skipReason, string) {
+	if cfg.LinkOnly && isLinkOnly(event.Content) {
+		return skipLinkOnly, "note is only a link (zap.automated.link_only)"
+	}
+
+	if cfg.RepeatedContent == 0 && cfg.RegularInterval == 0 {
+		return "", ""
+	}
+
+	notes, current := h.record(event, cfg.HistoryWindow())
+
+	if cfg.RepeatedContent > 0 && current.hashed {
+		copies := 0
+		for _, note := range notes {
+			if note.hashed && note.hash == current.hash {
+				copies++
+			}
+		}
+		if copies >= cfg.RepeatedContent {
+			return skipRepeatedContent, fmt.Sprintf("author posted the same content %d times (zap.automated.repeated_content)", copies)
+		}
+	}
+
+	if cfg.RegularInterval > 0 && evenlySpaced(notes, current, cfg.RegularInterval) {
+		return skipRegularInterval, fmt.Sprintf("author's last %d notes were posted at regular intervals (zap.automated.regular_interval)", cfg.RegularInterval)
+	}
+
+	return "", ""
+}
+
+// record adds the note to its author's history, dropping notes older than
+// window, and returns a copy of the history along with the note. A note seen
+// before (e.g. from another relay) is not added twice.
+func (h *automationHistory) record(event *nostr.Event, window time.Duration) ([]seenNote, seenNote) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.notes == nil {
+		h.notes = make(map[string][]seenNote)
+	}
+
+	cutoff := time.Now().Add(-window)
+	kept := slices.DeleteFunc(h.notes[event.PubKey], func(note seenNote) bool {
+		return note.createdAt.Before(cutoff)
+	})
+
+	i := slices.IndexFunc(kept, func(note seenNote) bool { return note.id == event.ID })
+	if i < 0 {
+		hash, ok := contentHash(event.Content, config.DedupBasic)
+		kept = append(kept, seenNote{id: event.ID, hash: hash, hashed: ok, createdAt: event.CreatedAt.Time()})
+		i = len(kept) - 1
+	}
+	h.notes[event.PubKey] = kept
+
+	return slices.Clone(kept), kept[i]
+}
+
+// evenlySpaced reports whether current and the count-1 notes the author
+// posted before it are separated by gaps of (nearly) the same length
+func evenlySpaced(notes []seenNote, current seenNote, count int) bool {
+	times := make([]time.Time, 0, len(notes))
+	for _, note := range notes {
+		if !note.createdAt.After(current.createdAt) {
+			times = append(times, note.createdAt)
+		}
+	}
+	if count < 3 || len(times) < count {
+		return false
+	}
+	slices.SortFunc(times, time.Time.Compare)
+	times = times[len(times)-count:]
+
+	var shortest, longest, total time.Duration
+	for i := 1; i < len(times); i++ {
+		gap := times[i].Sub(times[i-1])
+		if gap <= 0 {
+			return false
+		}
+		if i == 1 || gap < shortest {
+			shortest = gap
+		}
+		longest = max(longest, gap)
+		total += gap
+	}
+
+	average := total / time.Duration(len(times)-1)
+	return longest-shortest <= regularIntervalSlack+average/50
+}
+
+// isLinkOnly reports whether content is one or more links and nothing else
+func isLinkOnly(content string) bool {
+	if !link.MatchString(content) {
+		return false
+	}
+	return strings.TrimSpace(link.ReplaceAllString(content, "")) == ""
+}
@@ -0,0 +1,111 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// minBalanceRefresh throttles balance requests to the wallet relay no matter
+// how often the interval or zap-count triggers fire
+const minBalanceRefresh = 30 * time.Second
+
+// balanceLoop periodically refreshes and prints the wallet balance
+func (b *Bot) balanceLoop() {
+	ticker := time.NewTicker(b.config.NWC.BalanceInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+			b.refreshBalance()
+		}
+	}
+}
+
+// refreshBalance fetches the wallet balance, prints it and caches it for the
+// min-balance gate. Calls within minBalanceRefresh of the last one are skipped.
+func (b *Bot) refreshBalance() {
+	b.balanceMu.Lock()
+	if time.Since(b.balanceAt) < minBalanceRefresh {
+		b.balanceMu.Unlock()
+		return
+	}
+	b.balanceAt = time.Now()
+	b.balanceMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(b.ctx, 30*time.Second)
+	defer cancel()
+
+	balance, err := b.zapper.GetBalance(ctx)
+	if err != nil {
+		b.log.Warn().Err(err).Msg("failed to refresh wallet balance")
+		return
+	}
+
+	b.setBalance(balance)
+
+	b.log.Info().Int64("balance_msat", balance).Msg("wallet balance refreshed")
+	fmt.Fprintf(b.out, "💰 Wallet balance: %d sats\n", balance/1000)
+}
+
+// setBalance records a freshly fetched balance (in millisats)
+func (b *Bot) setBalance(msat int64) {
+	b.balanceMu.Lock()
+	defer b.balanceMu.Unlock()
+
+	b.balance = msat
+	b.balanceKnown = true
+	b.zapsSinceBalance = 0
+}
+
+// recordSpend lowers the cached balance after a paid zap and triggers a
+// refresh every nwc.balance_every_zaps zaps
+func (b *Bot) recordSpend(amountSats int) {
+	b.balanceMu.Lock()
+	b.balance -= int64(amountSats) * 1000
+	b.zapsSinceBalance++
+	due := b.config.NWC.BalanceEvery > 0 && b.zapsSinceBalance >= b.config.NWC.BalanceEvery
+	b.balanceMu.Unlock()
+
+	if due {
+		go b.refreshBalance()
+	}
+}
+
+// balanceAllows reports whether zapping amountSats keeps the wallet at or
+// above budget.min_balance. An unknown balance never blocks zaps.
+func (b *Bot) balanceAllows(amountSats int) (bool, int64) {
+	if b.config.Budget.MinBalance <= 0 {
+		return true, 0
+	}
+
+	b.balanceMu.Lock()
+	defer b.balanceMu.Unlock()
+
+	if !b.balanceKnown {
+		return true, 0
+	}
+
+	remaining := b.balance/1000 - int64(amountSats)
+	return remaining >= int64(b.config.Budget.MinBalance), b.balance / 1000
+}
+
+// spendable returns how many sats can be spent before the wallet drops to
+// budget.min_balance; ok is false when that is not enforced or not yet known
+func (b *Bot) spendable() (sats int64, ok bool) {
+	if b.config.Budget.MinBalance <= 0 {
+		return 0, false
+	}
+
+	b.balanceMu.Lock()
+	defer b.balanceMu.Unlock()
+
+	if !b.balanceKnown {
+		return 0, false
+	}
+
+	return b.balance/1000 - int64(b.config.Budget.MinBalance), true
+}
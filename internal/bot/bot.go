@@ -1,400 +1,1484 @@
 package bot
 
 import (
+	"cmp"
 	"context"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/mistic0xb/pekka/config"
 	"github.com/mistic0xb/pekka/internal/bunker"
+	"github.com/mistic0xb/pekka/internal/clock"
 	"github.com/mistic0xb/pekka/internal/db"
 	"github.com/mistic0xb/pekka/internal/logger"
 	"github.com/mistic0xb/pekka/internal/nostrlist"
+	"github.com/mistic0xb/pekka/internal/nwc"
+	"github.com/mistic0xb/pekka/internal/price"
+	"github.com/mistic0xb/pekka/internal/publish"
 	reaction "github.com/mistic0xb/pekka/internal/reactor"
+	"github.com/mistic0xb/pekka/internal/relaycheck"
+	"github.com/mistic0xb/pekka/internal/signer"
 	"github.com/mistic0xb/pekka/internal/ui"
+	"github.com/mistic0xb/pekka/internal/webhook"
 	"github.com/mistic0xb/pekka/internal/zap"
 
 	"github.com/nbd-wtf/go-nostr"
 	"github.com/nbd-wtf/go-nostr/nip19"
+	"github.com/rs/zerolog"
 )
 
 type Bot struct {
-	config       *config.Config
-	db           *db.DB
-	pool         *nostr.SimplePool
-	zapper       *zap.Zapper
-	bunkerClient *bunker.ReconnectingClient
-	npubs        []string
-	ctx          context.Context
-	cancel       context.CancelFunc
+	config           *config.Config
+	db               *db.DB
+	pool             *nostr.SimplePool
+	publisher        *publish.Batcher // publishes reactions, batched per publish.batch_window
+	zapper           *zap.Zapper
+	bunkerClient     *bunker.ReconnectingClient
+	zapSigner        signer.Signer // signs zap requests (zap.signer)
+	reactSigner      signer.Signer // signs reactions (reaction.signer)
+	npubs            []string
+	ownPubkey        string
+	rules            []amountRule
+	fiat             *price.Converter // converts zap.amount_fiat to sats, nil when unset
+	webhook          *webhook.Sender  // posts zaps to webhook.url, nil when unset
+	reactionsEnabled bool             // false if reactions are off or failed to initialize
+	sampler          *rand.Rand       // decides which notes are zapped when sampling
+	priority         map[string]bool  // hex pubkeys exempt from the per-author budget
+	samplerMu        sync.Mutex
+	promptMu         sync.Mutex        // one confirmation prompt at a time
+	dedup            contentDedup      // content already zapped, for zap.dedup_by_content
+	automation       automationHistory // recent notes per author, for zap.automated
+	lifetimeReached  atomic.Bool       // budget.lifetime_limit was hit, nothing is zapped anymore
+	reserved         reservations      // budget claimed by zaps in flight
+	reservedMu       sync.Mutex        // guards reserved, held while checking against it
+	clock            *clock.Clock      // corrected time for the events this bot creates
+	log              *zerolog.Logger
+	out              io.Writer   // console output
+	verbosity        int         // console verbosity, see ui.LevelNormal
+	prompter         ui.Prompter // asks before oversized lists, large zaps and catch-up
+	ctx              context.Context
+	cancel           context.CancelFunc
+
+	mu          sync.Mutex         // guards npubs, listEventID and subCancel during list refresh
+	subCancel   context.CancelFunc // cancels the current event subscription
+	listEventID string             // event ID of the loaded NIP-51 list, for change detection
+
+	relayMu      sync.Mutex           // guards activeRelays and relaySeen
+	activeRelays []string             // relays still in use after pruning
+	relaySeen    map[string]time.Time // when each relay was last known reachable
+
+	balanceMu        sync.Mutex // guards the cached wallet balance below
+	balance          int64      // last known balance in millisats, minus zaps since
+	balanceKnown     bool
+	balanceAt        time.Time // when the balance was last requested
+	zapsSinceBalance int
+
+	counters counters // session totals for the periodic summary
 }
 
-func New(cfg *config.Config, database *db.DB) (*Bot, error) {
-	logger.Log.Info().Msg("initializing bot")
+// Options customize a Bot. The zero value is the CLI: logs go to logger.Log,
+// console output to stdout and questions to the terminal.
+type Options struct {
+	Logger    *zerolog.Logger // nil logs to logger.Log
+	Out       io.Writer       // console output, nil is stdout
+	Prompter  ui.Prompter     // nil asks on the terminal
+	Verbosity int             // console verbosity, one of the ui.Level constants
+}
+
+func New(cfg *config.Config, database *db.DB, opts Options) (*Bot, error) {
+	log := cmp.Or(opts.Logger, &logger.Log)
+	out := opts.Out
+	if out == nil {
+		out = os.Stdout
+	}
+	prompter := opts.Prompter
+	if prompter == nil {
+		prompter = ui.Terminal{}
+	}
+
+	log.Info().Msg("initializing bot")
 
-	if cfg.SelectedList == "" {
-		logger.Log.Error().Msg("no selected list in config")
+	if cfg.SelectedList == "" && !cfg.List.UsesFollows() && !cfg.Zap.UsesDirectNPubs() {
+		log.Error().Msg("no selected list in config")
 		return nil, fmt.Errorf("no list selected.")
 	}
 
+	// Everything the bot starts logs and prints through its context
 	ctx, cancel := context.WithCancel(context.Background())
+	botClock := &clock.Clock{}
+	ctx = ui.WithOutput(logger.WithContext(clock.WithContext(ctx, botClock), log), out)
 	pool := nostr.NewSimplePool(ctx)
 
-	bunkerClient, err := bunker.NewReconnectingClient(ctx, cfg.Author.BunkerURL, pool)
+	bunkerClient, err := bunker.NewReconnectingClient(ctx, cfg.Author.BunkerURL, pool, bunker.Options{
+		OnAuth:            bunker.NewAuthHandler(ctx, cfg.Bunker.AuthURLFile),
+		MaxConcurrent:     cfg.Bunker.MaxConcurrent,
+		KeepaliveInterval: cfg.Bunker.KeepaliveInterval,
+		KeepaliveJitter:   cfg.Bunker.KeepaliveJitter,
+	})
 	if err != nil {
-		logger.Log.Error().Err(err).Msg("failed to create bunker client")
+		log.Error().Err(err).Msg("failed to create bunker client")
 		cancel()
 		return nil, fmt.Errorf("failed to create bunker client: %w", err)
 	}
 
-	zapper, err := zap.New(cfg.NWCUrl, cfg.Relays, pool)
+	zapSigner, err := signer.New(cfg.Zap.Signer, bunkerClient, cfg.Author.NSec)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to create zap signer: %w", err)
+	}
+
+	reactSigner, err := signer.New(cfg.Reaction.Signer, bunkerClient, cfg.Author.NSec)
 	if err != nil {
-		logger.Log.Error().Err(err).Msg("failed to create zapper")
+		cancel()
+		return nil, fmt.Errorf("failed to create reaction signer: %w", err)
+	}
+
+	zapper, err := zap.New(ctx, cfg.NWCUrl, nwc.RetryConfig{
+		Attempts: cfg.NWC.ConnectRetries,
+		Timeout:  cfg.NWC.ConnectTimeout,
+	}, zap.SignPolicy{
+		Timeout:    cfg.Zap.SigningTimeout(),
+		GraceRetry: cfg.Zap.SignGraceRetry,
+	}, zap.Relays{
+		Read:      cfg.ReadRelays(),
+		Write:     cfg.WriteRelays(),
+		Recipient: cfg.Zap.RecipientRelays,
+	}, pool)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to create zapper")
 		cancel()
 		return nil, fmt.Errorf("failed to create zapper: %w", err)
 	}
 
-	logger.Log.Info().Msg("bot initialized successfully")
+	if cfg.IsShadow() {
+		log.Warn().Msg("shadow mode enabled: invoices will not be paid")
+		database = database.Shadow()
+	}
+
+	var fiat *price.Converter
+	if cfg.Zap.AmountFiat != "" {
+		_, currency, _ := cfg.Zap.FiatAmount()
+		fiat = price.NewConverter(cfg.Zap.FiatSource, currency)
+	}
+
+	var hook *webhook.Sender
+	if cfg.Webhook.URL != "" {
+		hook = webhook.New(ctx, cfg.Webhook.URL, cfg.Webhook.Retries, cfg.Webhook.Timeout)
+	}
+
+	log.Info().Msg("bot initialized successfully")
 
 	return &Bot{
-		config:       cfg,
-		db:           database,
-		pool:         pool,
+		config: cfg,
+		db:     database,
+		pool:   pool,
+		publisher: publish.NewBatcher(ctx, pool, publish.Options{
+			Window:           cfg.Publish.BatchWindow,
+			RateLimitRetries: cfg.Publish.RateLimitRetries,
+			KeepBlocked:      cfg.Publish.KeepBlocked,
+		}),
 		zapper:       zapper,
 		bunkerClient: bunkerClient,
+		zapSigner:    zapSigner,
+		reactSigner:  reactSigner,
+		rules:        compileRules(cfg.Zap.Rules),
+		fiat:         fiat,
+		webhook:      hook,
+		sampler:      newSampler(),
+		priority:     priorityPubkeys(cfg.Budget.PriorityNPubs),
+		clock:        botClock,
+		log:          log,
+		out:          out,
+		verbosity:    opts.Verbosity,
+		prompter:     prompter,
 		ctx:          ctx,
 		cancel:       cancel,
 	}, nil
 }
 
+// spinner shows a spinner while the bot's output is the terminal, and
+// returns nil, whose Stop does nothing, otherwise
+func (b *Bot) spinner(msg, color string) *ui.Spinner {
+	if !ui.OnTerminal(b.ctx) {
+		return nil
+	}
+	return ui.NewSpinner(msg, 11, color)
+}
+
 func (b *Bot) Start() error {
-	logger.Log.Info().Str("list_id", b.config.SelectedList).Msg("starting bot")
+	b.log.Info().Str("list_id", b.config.SelectedList).Msg("starting bot")
 
 	// Start ascii
-	ui.PrintAscii()
+	if ui.OnTerminal(b.ctx) {
+		ui.PrintAscii()
+	}
 
-	fmt.Printf("Selected list: %s\n", b.config.SelectedList)
-	fmt.Println()
+	b.clock.SetOffset(b.config.Clock.Offset)
+	if b.config.Clock.Check {
+		b.checkClock()
+	}
+
+	// Without a single read relay the bot would run and never see a note
+	s := b.spinner("Connecting to relays", "blue")
+	relays, err := relaycheck.Require(b.ctx, b.pool, b.config.ReadRelays())
+	s.Stop()
+	if err != nil {
+		b.log.Error().Err(err).Msg("no read relay reachable")
+		return fmt.Errorf("%w. Check your connection or the relays in your config", err)
+	}
+	if len(relays.Failed) > 0 {
+		fmt.Fprintf(b.out, "⚠️  %d of %d relays unreachable, continuing with %d\n",
+			len(relays.Failed), len(b.config.ReadRelays()), len(relays.Connected))
+	}
+
+	if b.config.Zap.UsesDirectNPubs() {
+		fmt.Fprintln(b.out, "Monitoring zap.direct_npubs")
+	} else if b.config.List.UsesFollows() {
+		fmt.Fprintln(b.out, "Monitoring your follow list")
+	} else {
+		fmt.Fprintf(b.out, "Selected list: %s\n", b.config.SelectedList)
+	}
+	fmt.Fprintln(b.out)
 
 	if err := b.loadNPubs(); err != nil {
-		logger.Log.Error().Err(err).Msg("failed to load npubs")
+		b.log.Error().Err(err).Msg("failed to load npubs")
 		return fmt.Errorf("failed to load list: %w", err)
 	}
 
-	logger.Log.Info().Int("npub_count", len(b.npubs)).Msg("loaded npubs")
-	fmt.Println()
-	fmt.Printf("Monitoring %d npubs\n", len(b.npubs))
-	fmt.Println()
+	b.log.Info().Int("npub_count", len(b.npubs)).Msg("loaded npubs")
+
+	ownPubkey, err := b.bunkerClient.GetPublicKey(b.ctx)
+	if err != nil {
+		b.log.Warn().Err(err).Msg("could not resolve own pubkey, self-zap guard disabled")
+	}
+	b.ownPubkey = ownPubkey
+
+	b.initReactions()
+	fmt.Fprintln(b.out)
+	fmt.Fprintf(b.out, "Monitoring %d npubs\n", len(b.npubs))
+	fmt.Fprintln(b.out)
 
-	s := ui.NewSpinner("Connecting to wallet", 11, "yellow")
+	s = b.spinner("Connecting to wallet", "yellow")
 	if err := b.zapper.Connect(b.ctx); err != nil {
-		logger.Log.Error().Err(err).Msg("failed to connect to wallet")
+		b.log.Error().Err(err).Msg("failed to connect to wallet")
 		return fmt.Errorf("failed to connect to wallet: %w", err)
 	}
 	defer b.zapper.Close()
 	s.Stop()
 
+	// Without stored receipts, lookup_invoice is the only way to verify a
+	// pending zap, and every one would end up failed
+	if b.confirmsZaps() && !b.config.Zap.StoreReceipts {
+		supported, err := b.zapper.WalletSupports(b.ctx, "lookup_invoice")
+		if err != nil {
+			b.log.Error().Err(err).Msg("failed to fetch wallet info")
+			return fmt.Errorf("failed to check the wallet supports lookup_invoice: %w", err)
+		}
+		if !supported {
+			b.log.Error().Msg("wallet cannot verify pending zaps")
+			return fmt.Errorf("zap.confirm_window needs zap.store_receipts or a wallet that supports lookup_invoice")
+		}
+	}
+
 	balance, err := b.zapper.GetBalance(b.ctx)
 	if err != nil {
-		logger.Log.Error().Err(err).Msg("failed to fetch wallet balance")
-		fmt.Printf("Warning: could not fetch balance: %v\n", err)
+		b.log.Error().Err(err).Msg("failed to fetch wallet balance")
+		fmt.Fprintf(b.out, "Warning: could not fetch balance: %v\n", err)
 	} else {
-		logger.Log.Info().Int64("balance_msat", balance).Msg("wallet balance fetched")
-		fmt.Println()
-		fmt.Printf("Wallet balance: %d sats\n", balance/1000)
+		b.log.Info().Int64("balance_msat", balance).Msg("wallet balance fetched")
+		fmt.Fprintln(b.out)
+		fmt.Fprintf(b.out, "Wallet balance: %d sats\n", balance/1000)
+		b.setBalance(balance)
 	}
-	fmt.Println()
+	fmt.Fprintln(b.out)
+
+	b.initRelayHealth()
 
-	s = ui.NewSpinner("Subscribing to events", 11, "blue")
+	if b.config.CatchUp.Enabled {
+		b.catchUp()
+	}
+
+	s = b.spinner("Subscribing to events", "blue")
 	if err := b.subscribeToEvents(); err != nil {
-		logger.Log.Error().Err(err).Msg("failed to subscribe to events")
+		b.log.Error().Err(err).Msg("failed to subscribe to events")
 		return fmt.Errorf("failed to subscribe: %w", err)
 	}
 	s.Stop()
 
-	logger.Log.Info().Msg("bot is running")
-	fmt.Println("Pekka 🤖 is running. Press Ctrl+C to stop.")
+	// zap.direct_npubs come from the config and cannot change while running
+	if b.config.List.RefreshInterval > 0 && !b.config.Zap.UsesDirectNPubs() {
+		go b.refreshLoop()
+	}
+
+	if b.config.NWC.BalanceInterval > 0 {
+		go b.balanceLoop()
+	}
+
+	if b.config.RelayPrune.After > 0 {
+		go b.pruneLoop()
+	}
+
+	if b.config.SummaryInterval > 0 {
+		go b.summaryLoop()
+	}
+
+	// Shadow zaps are never paid, so there is nothing to confirm
+	if b.confirmsZaps() {
+		go b.confirmLoop()
+	}
+
+	b.log.Info().Msg("bot is running")
+	fmt.Fprintln(b.out, "Pekka 🤖 is running. Press Ctrl+C to stop.")
 	<-b.ctx.Done()
 
-	logger.Log.Info().Msg("bot context cancelled")
+	b.log.Info().Msg("bot context cancelled")
 	return nil
 }
 
+// initReactions checks the reaction setup and turns reactions off for this
+// session if anything is wrong, so zapping keeps working regardless
+func (b *Bot) initReactions() {
+	b.reactionsEnabled = false
+	if !b.config.Reaction.Enabled {
+		return
+	}
+
+	if err := b.checkReactions(); err != nil {
+		b.log.Error().Err(err).Msg("reactions disabled for this session")
+		fmt.Fprintf(b.out, "⚠️  Reactions disabled: %v\n", err)
+		return
+	}
+
+	b.reactionsEnabled = true
+}
+
+// checkReactions validates the reaction config and that a custom emoji image
+// can actually be fetched
+func (b *Bot) checkReactions() error {
+	if err := b.config.Reaction.Validate(); err != nil {
+		return err
+	}
+
+	for _, emoji := range b.config.Reaction.Emojis() {
+		if emoji.EmojiURL == "" {
+			continue
+		}
+		if err := b.checkEmojiURL(emoji.EmojiURL); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkEmojiURL makes sure a custom emoji image can be fetched
+func (b *Bot) checkEmojiURL(url string) error {
+	ctx, cancel := context.WithTimeout(b.ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return fmt.Errorf("invalid reaction emoji_url %s: %w", url, err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not fetch custom emoji: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("custom emoji %s returned status %d", url, resp.StatusCode)
+	}
+
+	return nil
+}
+
+// checkClock warns when the system clock disagrees with the relays and,
+// unless a fixed offset is configured, corrects event timestamps
+func (b *Bot) checkClock() {
+	ctx, cancel := context.WithTimeout(b.ctx, 15*time.Second)
+	defer cancel()
+
+	skew, err := clock.MeasureSkew(ctx, b.config.Relays)
+	if err != nil {
+		b.log.Warn().Err(err).Msg("could not check system clock against relays")
+		return
+	}
+
+	b.log.Info().Dur("skew", skew).Msg("measured clock skew against relays")
+
+	if skew.Abs() <= b.config.Clock.SkewThreshold() {
+		return
+	}
+
+	b.log.Warn().
+		Dur("skew", skew).
+		Dur("max_skew", b.config.Clock.SkewThreshold()).
+		Msg("system clock differs significantly from relays")
+	fmt.Fprintf(b.out, "⚠️  System clock is off by %s compared to relays\n", skew.Round(time.Second))
+
+	if b.config.Clock.Offset == 0 {
+		offset := b.clock.SetOffset(skew)
+		b.log.Info().Dur("offset", offset).Msg("correcting event timestamps for clock skew")
+		if offset != skew {
+			fmt.Fprintf(b.out, "   Event timestamps will be corrected by at most %s for this session.\n", clock.MaxOffset)
+		} else {
+			fmt.Fprintln(b.out, "   Event timestamps will be corrected for this session.")
+		}
+	}
+	fmt.Fprintln(b.out)
+}
+
+// Run starts the bot and stops it when ctx is cancelled
+func (b *Bot) Run(ctx context.Context) error {
+	go func() {
+		select {
+		case <-ctx.Done():
+			b.Stop()
+		case <-b.ctx.Done():
+		}
+	}()
+
+	return b.Start()
+}
+
 func (b *Bot) Stop() {
-	logger.Log.Info().Msg("stopping bot")
-	fmt.Println("\nStopping bot...")
+	b.log.Info().Msg("stopping bot")
+	fmt.Fprintln(b.out, "\nStopping bot...")
+
+	stats := b.bunkerClient.Stats()
+	b.log.Info().
+		Int64("bunker_reconnects", stats.Count).
+		Time("last_reconnect", stats.Last).
+		Msg("bunker session summary")
+	if stats.Count > 0 {
+		fmt.Fprintf(b.out, "Bunker reconnects this session: %d (last at %s)\n",
+			stats.Count, stats.Last.Format("2006-01-02 15:04:05"))
+	}
+
 	b.cancel()
 }
 
+// verbose reports whether console output at level should be printed
+func (b *Bot) verbose(level int) bool {
+	return b.verbosity >= level
+}
+
 func (b *Bot) loadNPubs() error {
-	logger.Log.Info().Str("list_id", b.config.SelectedList).Msg("loading npubs from list")
-
-	npubs, err := nostrlist.GetNPubsFromList(
-		b.config.Relays,
-		b.config.Author.NPub,
-		b.bunkerClient,
-		b.pool,
-		b.config.SelectedList,
-	)
+	list, err := b.fetchNPubs()
 	if err != nil {
-		logger.Log.Error().Err(err).Msg("failed to fetch npubs from list")
 		return err
 	}
 
-	if len(npubs) == 0 {
-		logger.Log.Error().Msg("selected list is empty")
-		return fmt.Errorf("selected list is empty")
+	if err := b.checkDecryption(list); err != nil {
+		return err
 	}
 
-	b.npubs = npubs
+	if err := b.checkListSize(len(list.NPubs)); err != nil {
+		return err
+	}
 
-	fmt.Println("Monitoring these npubs:")
+	b.npubs = list.NPubs
+	b.listEventID = list.EventID
+
+	fmt.Fprintln(b.out, "Monitoring these npubs:")
 	for i, npub := range b.npubs {
-		fmt.Printf("  %d. %s\n", i+1, npub)
+		fmt.Fprintf(b.out, "  %d. %s\n", i+1, npub)
 	}
 
 	return nil
 }
 
+// checkListSize asks for confirmation when the list exceeds list.max_members,
+// and refuses outright when there is no terminal to ask on
+func (b *Bot) checkListSize(count int) error {
+	maxMembers := b.config.List.MaxMembers
+	if maxMembers == 0 || count <= maxMembers {
+		return nil
+	}
+
+	b.log.Warn().
+		Int("npub_count", count).
+		Int("max_members", maxMembers).
+		Msg("list exceeds max_members")
+	fmt.Fprintf(b.out, "\n⚠️  This list has %d members, above list.max_members (%d)\n", count, maxMembers)
+
+	if !b.prompter.Interactive() {
+		return fmt.Errorf("list has %d members, above list.max_members (%d); refusing in non-interactive mode", count, maxMembers)
+	}
+
+	if !b.prompter.Confirm(fmt.Sprintf("Monitor all %d npubs?", count)) {
+		return fmt.Errorf("aborted: list has %d members", count)
+	}
+
+	b.log.Info().Int("npub_count", count).Msg("oversized list confirmed by user")
+	return nil
+}
+
+// fetchNPubs resolves the monitored npubs from the configured list source.
+// Follow lists come back wrapped in a PrivateList without an event ID.
+func (b *Bot) fetchNPubs() (*nostrlist.PrivateList, error) {
+	var list *nostrlist.PrivateList
+	var err error
+
+	if b.config.Zap.UsesDirectNPubs() {
+		// No list to fetch or decrypt
+		b.log.Info().Int("npub_count", len(b.config.Zap.DirectNPubs)).Msg("using zap.direct_npubs")
+		list = nostrlist.DirectList(b.config.Zap.DirectNPubs)
+	} else if b.config.List.UsesFollows() {
+		b.log.Info().Msg("loading npubs from follow list")
+
+		var npubs []string
+		npubs, err = nostrlist.FetchFollows(
+			b.ctx,
+			b.config.ListRelays(),
+			b.config.Author.NPub,
+			b.pool,
+		)
+		list = &nostrlist.PrivateList{ID: config.ListSourceFollows, Title: "follows", NPubs: npubs}
+	} else {
+		b.log.Info().Str("list_id", b.config.SelectedList).Msg("loading npubs from list")
+
+		list, err = nostrlist.GetList(
+			b.ctx,
+			b.config.ListRelays(),
+			b.config.Author.NPub,
+			b.bunkerClient,
+			b.pool,
+			&b.config.List,
+			b.config.SelectedList,
+		)
+		if err == nil {
+			if invalid := list.InvalidMembers(); invalid > 0 {
+				fmt.Fprintf(b.out, "⚠️  List has %d invalid member tag(s), they were ignored\n", invalid)
+			}
+		}
+	}
+	if err != nil {
+		b.log.Error().Err(err).Msg("failed to fetch npubs from list")
+		return nil, err
+	}
+
+	if len(list.NPubs) == 0 {
+		if list.PrivateUnreadable() {
+			b.log.Error().Msg("selected list is empty because its private members could not be decrypted")
+			return nil, fmt.Errorf("selected list has no readable members: its private members could not be decrypted, check your bunker and list.decrypt_preference")
+		}
+		b.log.Error().Msg("selected list is empty")
+		return nil, fmt.Errorf("selected list is empty")
+	}
+
+	return list, nil
+}
+
+// checkDecryption warns when the list has private content that yielded no
+// members, and offers to abort instead of silently running public-only
+func (b *Bot) checkDecryption(list *nostrlist.PrivateList) error {
+	if !list.PrivateUnreadable() {
+		return nil
+	}
+
+	reason := "no private members were found in its encrypted content"
+	if list.DecryptFailed {
+		reason = "its encrypted content could not be decrypted"
+	}
+
+	b.log.Warn().
+		Str("list_id", list.ID).
+		Bool("decrypt_failed", list.DecryptFailed).
+		Int("public_members", len(list.NPubs)).
+		Msg("list has private content but no private members, decryption likely failed")
+
+	fmt.Fprintln(b.out)
+	fmt.Fprintln(b.out, "⚠️  ⚠️  ⚠️  PRIVATE MEMBERS MISSING ⚠️  ⚠️  ⚠️")
+	fmt.Fprintf(b.out, "This list has private members but %s.\n", reason)
+	fmt.Fprintln(b.out, "Check that your bunker holds the list author's key and try list.decrypt_preference.")
+	fmt.Fprintf(b.out, "Only the %d public member(s) would be monitored.\n", len(list.NPubs))
+
+	if !b.prompter.Interactive() {
+		return nil
+	}
+
+	if !b.prompter.Confirm("Continue with public members only?") {
+		return fmt.Errorf("aborted: private list members could not be read")
+	}
+
+	b.log.Info().Msg("user chose to continue without private members")
+	return nil
+}
+
 func (b *Bot) subscribeToEvents() error {
 	pubkeys, err := b.npubsToHex()
 	if err != nil {
-		logger.Log.Error().Err(err).Msg("failed to convert npubs to hex")
+		b.log.Error().Err(err).Msg("failed to convert npubs to hex")
 		return err
 	}
 
-	since := nostr.Now()
+	since := b.clock.Now()
 	filters := []nostr.Filter{{
 		Kinds:   []int{1},
 		Authors: pubkeys,
 		Since:   &since,
 	}}
 
-	logger.Log.Info().Int("author_count", len(pubkeys)).Msg("subscribing to events")
-	go b.handleEvents(filters)
+	subCtx, subCancel := context.WithCancel(b.ctx)
+	b.subCancel = subCancel
+
+	relays := b.monitorRelays()
+	if len(relays) == 0 {
+		subCancel()
+		return fmt.Errorf("no relays to subscribe to")
+	}
+
+	b.log.Info().
+		Int("author_count", len(pubkeys)).
+		Int("relay_count", len(relays)).
+		Msg("subscribing to events")
+	go b.handleEvents(subCtx, relays, filters)
 	return nil
 }
 
-func (b *Bot) handleEvents(filters []nostr.Filter) {
-	for event := range b.pool.SubscribeMany(b.ctx, b.config.Relays, filters[0]) {
-		go b.processEvent(event)
+// Subscription reconnect backoff bounds
+const (
+	minReconnectBackoff = 2 * time.Second
+	maxReconnectBackoff = 5 * time.Minute
+	healthyPeriod       = 10 * time.Minute // a subscription alive this long resets the backoff
+)
+
+// handleEvents consumes the event subscription and resubscribes with capped
+// exponential backoff (with jitter) whenever it ends unexpectedly
+func (b *Bot) handleEvents(ctx context.Context, relays []string, filters []nostr.Filter) {
+	backoff := minReconnectBackoff
+
+	for {
+		started := time.Now()
+		for event := range b.pool.SubscribeMany(ctx, relays, filters[0]) {
+			b.markRelaySeen(event.Relay.URL)
+			go b.processEvent(event)
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		// Resume from when the subscription dropped so notes posted during
+		// the backoff are not missed
+		since := b.clock.Now()
+		filters[0].Since = &since
+
+		if time.Since(started) >= healthyPeriod {
+			backoff = minReconnectBackoff
+		}
+
+		delay := withJitter(backoff)
+		b.log.Warn().
+			Dur("backoff", delay).
+			Dur("uptime", time.Since(started)).
+			Msg("event subscription ended, resubscribing")
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+
+		backoff = min(backoff*2, maxReconnectBackoff)
+	}
+}
+
+// sampled rolls against zap.sample_rate to decide whether to zap a note
+func (b *Bot) sampled() bool {
+	p := b.config.Zap.SampleProbability()
+	if p >= 1 {
+		return true
+	}
+
+	b.samplerMu.Lock()
+	defer b.samplerMu.Unlock()
+	return b.sampler.Float64() < p
+}
+
+// olderThanLastZap returns the created_at of the author's newest zapped note
+// when this note predates it and zap.out_of_order is skip, or 0 to go on.
+// Relays deliver notes out of order, so by default (process) a late note is
+// zapped like any other as long as it was not zapped yet; skip treats it as
+// backfill to avoid a flurry of old zaps after a reconnect.
+func (b *Bot) olderThanLastZap(event *nostr.Event) (int64, error) {
+	if b.config.Zap.OutOfOrder != config.OutOfOrderSkip {
+		return 0, nil
 	}
+
+	lastZapped, err := b.db.GetLastZappedEventTime(event.PubKey)
+	if err != nil {
+		return 0, err
+	}
+	if int64(event.CreatedAt) < lastZapped {
+		return lastZapped, nil
+	}
+	return 0, nil
+}
+
+// newSampler returns an RNG seeded from the current time
+func newSampler() *rand.Rand {
+	seed := uint64(time.Now().UnixNano())
+	return rand.New(rand.NewPCG(seed, seed>>32))
+}
+
+// withJitter returns a random delay between d/2 and d
+func withJitter(d time.Duration) time.Duration {
+	half := d / 2
+	return half + rand.N(half+1)
 }
 
 func (b *Bot) processEvent(event nostr.RelayEvent) {
 	if event.Kind != 1 {
 		return
 	}
+	b.counters.processed.Add(1)
+
+	if err := b.db.SetLastSeen(int64(event.CreatedAt)); err != nil {
+		b.log.Warn().Err(err).Str("event_id", event.ID).Msg("failed to record last seen note")
+	}
+
+	content := truncate(ui.Sanitize(event.Content), 80)
+
+	// The note header is printed once, before the first console line about it
+	headerShown := false
+	header := func() {
+		if headerShown {
+			return
+		}
+		headerShown = true
+		eventAuthorNpub, _ := nip19.EncodePublicKey(event.PubKey)
+		fmt.Fprintf(b.out, "\n[%s] New note from %s\n",
+			time.Now().Format("15:04:05"),
+			eventAuthorNpub,
+		)
+		fmt.Fprintf(b.out, "Content: %s\n", content)
+	}
 
-	logger.Log.Info().
+	b.log.Info().
 		Str("event_id", event.ID).
 		Str("author", event.PubKey).
+		Str("content", content).
 		Msg("new note received")
 
+	if event.PubKey == b.ownPubkey && !b.config.Zap.AllowSelf {
+		b.skip(event.Event, skipOwnNote).Msg("skipping own note")
+		if b.verbose(ui.LevelDecision) {
+			fmt.Fprintln(b.out, "\nSkipping own note.")
+		}
+		return
+	}
+
+	if maxAge := b.config.Zap.MaxNoteAge; maxAge > 0 {
+		if age := b.clock.Now().Time().Sub(event.CreatedAt.Time()); age > maxAge {
+			b.skip(event.Event, skipStale).
+				Dur("age", age).
+				Dur("max_note_age", maxAge).
+				Msg("skipping stale note")
+			if b.verbose(ui.LevelDecision) {
+				fmt.Fprintf(b.out, "\nSkipping stale note (%s old).\n", age.Round(time.Second))
+			}
+			return
+		}
+	}
+
+	if reason, detail := b.filterNote(event.Event); reason != "" {
+		b.skip(event.Event, reason).
+			Str("reason", detail).
+			Msg("note filtered out")
+		if b.verbose(ui.LevelDecision) {
+			fmt.Fprintf(b.out, "\nSkipping note: %s.\n", detail)
+		}
+		return
+	}
+
 	select {
 	case <-time.After(time.Duration(b.config.ResponseDelay) * time.Second):
 	case <-b.ctx.Done():
 		return
 	}
 
-	eventAuthorNpub, _ := nip19.EncodePublicKey(event.PubKey)
-	fmt.Printf("\n[%s] New note from %s\n",
-		time.Now().Format("15:04:05"),
-		eventAuthorNpub,
-	)
-	fmt.Printf("Content: %s\n", truncate(event.Content, 80))
+	if b.verbose(ui.LevelDetail) {
+		header()
+	}
 
 	// Check if already zapped
 	isZapped, err := b.db.IsZapped(event.ID)
 	if err != nil {
-		logger.Log.Error().Err(err).Str("event_id", event.ID).Msg("failed to check zap status")
-		fmt.Printf("Error checking zap status: %v\n", err)
+		b.log.Error().Err(err).Str("event_id", event.ID).Msg("failed to check zap status")
+		header()
+		fmt.Fprintf(b.out, "Error checking zap status: %v\n", err)
+		b.counters.failed.Add(1)
 		return
 	}
 
 	if isZapped {
-		logger.Log.Info().Str("event_id", event.ID).Msg("event already zapped")
-		fmt.Println("Already zapped. Skipping.")
+		b.skip(event.Event, skipAlreadyZapped).Msg("event already zapped")
+		if b.verbose(ui.LevelDecision) {
+			header()
+			fmt.Fprintln(b.out, "Already zapped. Skipping.")
+		}
+		return
+	}
+
+	lastZapped, err := b.olderThanLastZap(event.Event)
+	if err != nil {
+		b.log.Error().Err(err).Str("event_id", event.ID).Msg("failed to check author's last zapped note")
+		header()
+		fmt.Fprintf(b.out, "Error checking zap history: %v\n", err)
+		b.counters.failed.Add(1)
+		return
+	}
+
+	if lastZapped > 0 {
+		b.skip(event.Event, skipOlderThanLastZap).
+			Int64("created_at", int64(event.CreatedAt)).
+			Int64("last_zapped_created_at", lastZapped).
+			Msg("note older than author's last zapped note")
+		if b.verbose(ui.LevelDecision) {
+			header()
+			fmt.Fprintln(b.out, "Older than a note already zapped for this author. Skipping.")
+		}
+		return
+	}
+
+	if !b.sampled() {
+		b.skip(event.Event, skipNotSampled).
+			Float64("sample_rate", b.config.Zap.SampleProbability()).
+			Msg("note not sampled, skipping")
+		if b.verbose(ui.LevelDecision) {
+			header()
+			fmt.Fprintln(b.out, "Not sampled this time. Skipping.")
+		}
+		return
+	}
+
+	amount, err := b.zapAmount(event.Event)
+	if err != nil {
+		b.skip(event.Event, skipNoPrice).Err(err).Msg("no BTC price for fiat amount, skipping")
+		header()
+		fmt.Fprintf(b.out, "⚠️  Could not convert %s to sats: %v. Skipping.\n", b.config.Zap.AmountFiat, err)
+		return
+	}
+
+	// Fiat amounts follow the BTC price, so max_per_zap is checked per note
+	if b.fiat != nil && b.config.Budget.MaxPerZap > 0 && amount > b.config.Budget.MaxPerZap {
+		b.skip(event.Event, skipMaxPerZap).
+			Int("amount", amount).
+			Int("max_per_zap", b.config.Budget.MaxPerZap).
+			Msg("converted amount above max_per_zap")
+		if b.verbose(ui.LevelDecision) {
+			header()
+			fmt.Fprintf(b.out, "⚠️  %s is %d sats, above max_per_zap (%d sats). Skipping.\n",
+				b.config.Zap.AmountFiat, amount, b.config.Budget.MaxPerZap)
+		}
 		return
 	}
 
 	// Check daily budget
 	todayTotal, err := b.db.GetTodayTotal()
 	if err != nil {
-		logger.Log.Error().Err(err).Msg("failed to fetch daily total")
-		fmt.Printf("Error checking budget: %v\n", err)
+		b.log.Error().Err(err).Msg("failed to fetch daily total")
+		header()
+		fmt.Fprintf(b.out, "Error checking budget: %v\n", err)
+		b.counters.failed.Add(1)
 		return
 	}
 
-	if todayTotal+b.config.Zap.Amount > b.config.Budget.DailyLimit {
-		logger.Log.Info().
+	if todayTotal+amount > b.config.Budget.DailyLimit {
+		b.skip(event.Event, skipDailyBudget).
 			Int("today_total", todayTotal).
 			Int("limit", b.config.Budget.DailyLimit).
 			Msg("daily budget exceeded")
-		fmt.Printf("⚠️  Daily budget exceeded (%d/%d sats)\n", todayTotal, b.config.Budget.DailyLimit)
+		if b.verbose(ui.LevelDecision) {
+			header()
+			fmt.Fprintf(b.out, "⚠️  Daily budget exceeded (%d/%d sats)\n", todayTotal, b.config.Budget.DailyLimit)
+		}
 		return
 	}
 
 	// Check per-author budget
 	authorTotal, err := b.db.GetTodayTotalForAuthor(event.PubKey)
 	if err != nil {
-		logger.Log.Error().Err(err).Str("author", event.PubKey).Msg("failed to fetch author budget")
-		fmt.Printf("Error checking author budget: %v\n", err)
+		b.log.Error().Err(err).Str("author", event.PubKey).Msg("failed to fetch author budget")
+		header()
+		fmt.Fprintf(b.out, "Error checking author budget: %v\n", err)
+		b.counters.failed.Add(1)
 		return
 	}
 
-	if authorTotal+b.config.Zap.Amount > b.config.Budget.PerNPubLimit {
-		logger.Log.Info().
+	if authorTotal+amount > b.config.Budget.PerNPubLimit {
+		if !b.priority[event.PubKey] {
+			b.skip(event.Event, skipAuthorBudget).
+				Int("author_total", authorTotal).
+				Msg("per-author budget exceeded")
+			if b.verbose(ui.LevelDecision) {
+				header()
+				fmt.Fprintf(b.out, "⚠️  Per-author budget exceeded for %s (%d/%d sats)\n",
+					event.PubKey[:16]+"...", authorTotal, b.config.Budget.PerNPubLimit)
+			}
+			return
+		}
+
+		b.log.Info().
 			Str("author", event.PubKey).
 			Int("author_total", authorTotal).
-			Msg("per-author budget exceeded")
-		fmt.Printf("⚠️  Per-author budget exceeded for %s (%d/%d sats)\n",
-			event.PubKey[:16]+"...", authorTotal, b.config.Budget.PerNPubLimit)
+			Int("per_npub_limit", b.config.Budget.PerNPubLimit).
+			Msg("priority author, bypassing per-author budget")
+		if b.verbose(ui.LevelDecision) {
+			header()
+			fmt.Fprintln(b.out, "⭐ Priority author, per-author limit bypassed")
+		}
+	}
+
+	// Claimed before the slower checks below so concurrent notes cannot
+	// overshoot the lifetime limit or author cap, released once the zap is
+	// recorded
+	res, ok := b.reserve(event, amount, b.bumpLimit(event.PubKey, todayTotal, authorTotal), authorTotal == 0, header)
+	if !ok {
+		return
+	}
+	defer res.release()
+
+	if ok, balance := b.balanceAllows(amount); !ok {
+		b.skip(event.Event, skipLowBalance).
+			Int64("balance_sats", balance).
+			Int("amount", amount).
+			Int("min_balance", b.config.Budget.MinBalance).
+			Msg("wallet balance below minimum")
+		if b.verbose(ui.LevelDecision) {
+			header()
+			fmt.Fprintf(b.out, "⚠️  Wallet balance too low (%d sats, keeping %d)\n", balance, b.config.Budget.MinBalance)
+		}
+		return
+	}
+
+	if !b.confirmLarge(event, amount, header) {
+		b.skip(event.Event, skipNotConfirmed).
+			Int("amount", amount).
+			Int("confirm_above", b.config.Budget.ConfirmAbove).
+			Msg("large zap not confirmed")
 		return
 	}
 
-	fmt.Printf("🌩️  Zapping %d sats", b.config.Zap.Amount)
-	if b.config.Reaction.Enabled {
-		fmt.Printf(" and reacting with %s", b.config.Reaction.Content)
+	// Claimed last so only a zap that is actually attempted blocks the copies
+	var contentKey [sha256.Size]byte
+	var claimed bool
+	if b.config.Zap.DedupByContent {
+		var ok bool
+		if contentKey, ok = contentHash(event.Content, b.config.Zap.DedupNormalize); ok {
+			if !b.dedup.claim(contentKey, b.config.Zap.ContentDedupWindow()) {
+				b.skip(event.Event, skipDuplicateContent).
+					Dur("dedup_window", b.config.Zap.ContentDedupWindow()).
+					Msg("same content already zapped recently")
+				if b.verbose(ui.LevelDecision) {
+					header()
+					fmt.Fprintln(b.out, "Same content already zapped recently. Skipping.")
+				}
+				return
+			}
+			claimed = true
+		}
 	}
-	fmt.Println()
+
+	maxBump := res.maxBump
+	if threshold := b.config.Budget.ConfirmAbove; threshold > 0 {
+		// A bump happens mid-zap and cannot be confirmed, so never past what was
+		maxBump = min(maxBump, max(amount, threshold))
+	}
+
+	header()
+	if b.config.IsShadow() {
+		fmt.Fprintf(b.out, "👻 Shadow-zapping %d sats", amount)
+	} else {
+		fmt.Fprintf(b.out, "🌩️  Zapping %d sats", amount)
+	}
+	if b.reactionsEnabled {
+		contents := make([]string, 0, len(b.config.Reaction.Emojis()))
+		for _, emoji := range b.config.Reaction.Emojis() {
+			contents = append(contents, emoji.Content)
+		}
+		fmt.Fprintf(b.out, " and reacting with %s", strings.Join(contents, " "))
+	}
+	fmt.Fprintln(b.out)
 
 	var wg sync.WaitGroup
-	var zapSuccess, reactSuccess bool
+	var zapResult *zap.Zap
+	var reactions []reactOutcome
+	var reactSuccess, reactSkipped bool
+	zapped := make(chan bool, 1) // the zap's outcome, for reaction.require_zap_success
 
 	// Launch zap in goroutine
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		zapSuccess = b.tryZap(event)
+		zapResult = b.tryZap(event, amount, maxBump)
+		zapped <- zapResult != nil
 	}()
 
 	// Launch reaction in goroutine (if enabled)
-	if b.config.Reaction.Enabled {
+	if b.reactionsEnabled {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			reactSuccess = b.tryReact(event)
+			// A reaction bug must never take the zap down with it
+			defer func() {
+				if r := recover(); r != nil {
+					b.log.Error().
+						Interface("panic", r).
+						Str("event_id", event.ID).
+						Msg("reaction panicked")
+				}
+			}()
+			if b.config.Reaction.RequireZapSuccess && !<-zapped {
+				b.log.Info().Str("event_id", event.ID).Msg("zap failed, not reacting")
+				reactSkipped = true
+				return
+			}
+			reactions = b.tryReact(event)
+			reactSuccess = allReacted(reactions)
 		}()
 	}
 
 	// Wait for both to complete
 	wg.Wait()
 
-	if zapSuccess {
-		fmt.Printf("✅ Zapped successfully!\n")
+	if zapResult != nil {
+		if b.config.IsShadow() {
+			fmt.Fprintf(b.out, "👻 Shadow zap recorded (not paid)\n")
+		} else {
+			fmt.Fprintf(b.out, "✅ Zapped successfully!\n")
+		}
+
+		b.counters.zapped.Add(1)
+		b.counters.zappedSats.Add(int64(zapResult.Amount))
+
+		if zapResult.Amount != amount {
+			fmt.Fprintf(b.out, "⬆️  Bumped to the author's minimum: %d sats\n", zapResult.Amount)
+		}
 
-		// Mark as zapped in database
-		err = b.db.MarkZapped(event.ID, event.PubKey, b.config.Zap.Amount, int64(event.CreatedAt))
+		if zapResult.Payee != "" && zapResult.Payee != event.PubKey {
+			payee, _ := nip19.EncodePublicKey(zapResult.Payee)
+			fmt.Fprintf(b.out, "💸 Paid to the note's zap recipient: %s\n", payee)
+		}
+
+		// Mark as zapped in database, pending until verified if zap.confirm_window
+		// is set and the wallet returned no preimage proving the payment
+		if b.confirmsZaps() && zapResult.Preimage == "" {
+			err = b.db.MarkPending(event.ID, event.PubKey, zapResult.Amount, int64(event.CreatedAt), zapResult.Invoice)
+			if err == nil {
+				fmt.Fprintf(b.out, "⏳ Recorded as pending until the payment is confirmed\n")
+			}
+		} else {
+			err = b.db.MarkZapped(event.ID, event.PubKey, zapResult.Amount, int64(event.CreatedAt), zapResult.Invoice)
+		}
 		if err != nil {
-			logger.Log.Error().Err(err).Str("event_id", event.ID).Msg("failed to mark zap in database")
-			fmt.Printf("⚠️  Warning: failed to mark as zapped: %v\n", err)
+			b.log.Error().Err(err).Str("event_id", event.ID).Msg("failed to mark zap in database")
+			fmt.Fprintf(b.out, "⚠️  Warning: failed to mark as zapped: %v\n", err)
+		}
+
+		if !b.config.IsShadow() {
+			if nevent := b.nevent(event.ID, event.PubKey, event.Relay); nevent != "" {
+				fmt.Fprintf(b.out, "🔗 nostr:%s\n", nevent)
+			}
+		}
+
+		if b.config.Zap.StoreReceipts && !b.config.IsShadow() {
+			go b.storeReceipt(event.ID, zapResult.RequestID)
+		}
+
+		if b.webhook != nil {
+			go b.notifyZap(event.Event, zapResult)
+		}
+
+		if !b.config.IsShadow() {
+			b.recordSpend(zapResult.Amount)
 		}
 	} else {
-		fmt.Printf("❌ Zap failed after retry. Skipping.\n")
+		b.counters.failed.Add(1)
 		// Don't mark as zapped - retry
+		if claimed {
+			b.dedup.release(contentKey)
+		}
 	}
 
-	if b.config.Reaction.Enabled {
-		if reactSuccess {
-			fmt.Printf("💬 Reacted successfully!\n")
-		} else {
-			fmt.Printf("⚠️  Reaction failed after retry.\n")
-			// Continue - zap might have succeeded
+	if b.reactionsEnabled {
+		b.log.Info().
+			Str("event_id", event.ID).
+			Bool("zap_ok", zapResult != nil).
+			Bool("reaction_ok", reactSuccess).
+			Bool("reaction_skipped", reactSkipped).
+			Msg("note handled")
+
+		if reactSkipped {
+			fmt.Fprintf(b.out, "💬 Not reacting: the zap failed (reaction.require_zap_success)\n")
+		}
+		for _, outcome := range reactions {
+			b.printReaction(outcome, len(reactions) > 1)
 		}
+		reactLabel := outcomeLabel(reactSuccess)
+		if reactSkipped {
+			reactLabel = "skipped"
+		} else if !reactSuccess && anyReacted(reactions) {
+			reactLabel = "partial"
+		}
+		fmt.Fprintf(b.out, "   Summary: zap %s, reaction %s\n", outcomeLabel(zapResult != nil), reactLabel)
 	}
 }
 
-// tryZap attempts to zap (with 1 retry)
-func (b *Bot) tryZap(event nostr.RelayEvent) bool {
+// outcomeLabel renders a success flag for the per-note summary line
+func outcomeLabel(ok bool) string {
+	if ok {
+		return "ok"
+	}
+	return "failed"
+}
+
+// bumpLimit returns the most a zap may be raised to when the author's LNURL
+// minimum is above the configured amount, staying within every budget (0 disables)
+func (b *Bot) bumpLimit(pubkey string, todayTotal, authorTotal int) int {
+	if !b.config.Zap.BumpToMin {
+		return 0
+	}
+
+	limit := min(b.config.Budget.MaxPerZap, b.config.Budget.DailyLimit-todayTotal)
+	if !b.priority[pubkey] {
+		limit = min(limit, b.config.Budget.PerNPubLimit-authorTotal)
+	}
+	if spendable, ok := b.spendable(); ok {
+		limit = int(min(int64(limit), spendable))
+	}
+
+	return max(limit, 0)
+}
+
+// confirmLarge asks before zapping more than budget.confirm_above. Without a
+// terminal there is nobody to ask, so the zap is skipped.
+func (b *Bot) confirmLarge(event nostr.RelayEvent, amount int, header func()) bool {
+	threshold := b.config.Budget.ConfirmAbove
+	if threshold == 0 || amount <= threshold {
+		return true
+	}
+
+	interactive := b.prompter.Interactive()
+	b.log.Warn().
+		Str("event_id", event.ID).
+		Int("amount", amount).
+		Int("confirm_above", threshold).
+		Bool("interactive", interactive).
+		Msg("zap above confirmation threshold")
+
+	// Concurrent notes must not prompt on stdin at the same time
+	b.promptMu.Lock()
+	defer b.promptMu.Unlock()
+
+	header()
+	if !interactive {
+		fmt.Fprintf(b.out, "⚠️  %d sats is above budget.confirm_above (%d sats) and there is no terminal to confirm. Skipping.\n", amount, threshold)
+		return false
+	}
+
+	if !b.prompter.Confirm(fmt.Sprintf("⚠️  Zap %d sats (above %d)?", amount, threshold)) {
+		b.log.Info().Str("event_id", event.ID).Int("amount", amount).Msg("large zap declined")
+		fmt.Fprintln(b.out, "Not zapping.")
+		return false
+	}
+
+	b.log.Info().Str("event_id", event.ID).Int("amount", amount).Msg("large zap confirmed")
+	return true
+}
+
+// tryZap attempts to zap (with 1 retry), bumping up to maxBump sats if needed
+func (b *Bot) tryZap(event nostr.RelayEvent, amount, maxBump int) *zap.Zap {
 	for attempt := 1; attempt <= 2; attempt++ {
-		logger.Log.Info().
+		b.log.Info().
 			Str("event_id", event.ID).
 			Int("attempt", attempt).
 			Msg("attempting zap")
 
-		zapCtx, cancel := context.WithTimeout(b.ctx, 120*time.Second)
-		err := b.zapper.ZapNote(
-			zapCtx,
-			event.ID,
-			event.PubKey,
-			b.config.Zap.Amount,
-			b.config.Zap.Comment,
-			b.bunkerClient,
-		)
+		zapCtx, cancel := context.WithTimeout(b.ctx, b.config.Zap.ZapTimeout())
+		var result *zap.Zap
+		var err error
+		if b.config.IsShadow() {
+			result, err = b.zapper.PrepareZap(
+				zapCtx,
+				event.Event,
+				amount,
+				maxBump,
+				b.config.Zap.Comment,
+				b.zapSigner,
+			)
+		} else {
+			result, err = b.zapper.ZapNote(
+				zapCtx,
+				event.Event,
+				amount,
+				maxBump,
+				b.config.Zap.Comment,
+				b.zapSigner,
+			)
+		}
 		cancel()
 
 		if err == nil {
-			logger.Log.Info().
+			b.log.Info().
 				Str("event_id", event.ID).
 				Int("attempt", attempt).
 				Msg("zap successful")
-			return true
+			return result
 		}
 
-		logger.Log.Error().
+		b.log.Error().
 			Err(err).
 			Str("event_id", event.ID).
 			Int("attempt", attempt).
 			Msg("zap failed")
 
+		if zap.IsPermanent(err) {
+			b.log.Info().
+				Str("event_id", event.ID).
+				Msg("zap failure is permanent, not retrying")
+			fmt.Fprintf(b.out, "❌ Zap failed: %v. Not retrying, skipping.\n", err)
+			return nil
+		}
+
 		if attempt == 1 {
-			fmt.Printf("⚠️  Zap failed, retrying...\n")
+			fmt.Fprintf(b.out, "⚠️  Zap failed, retrying...\n")
 			time.Sleep(2 * time.Second) // Brief pause before retry
 		}
 	}
 
-	logger.Log.Error().
+	b.log.Error().
 		Str("event_id", event.ID).
 		Msg("zap failed after 2 attempts")
+	fmt.Fprintf(b.out, "❌ Zap failed after 2 attempts. Skipping.\n")
+	return nil
+}
+
+// storeReceipt waits for the zap receipt matching our zap request and saves it
+func (b *Bot) storeReceipt(eventID, zapRequestID string) {
+	ctx, cancel := context.WithTimeout(b.ctx, 2*time.Minute)
+	defer cancel()
+
+	receipt, err := b.zapper.WaitForReceipt(ctx, eventID, zapRequestID)
+	if err != nil {
+		b.log.Warn().
+			Err(err).
+			Str("event_id", eventID).
+			Msg("zap receipt not observed")
+		return
+	}
+
+	if err := b.db.SaveReceipt(eventID, zapRequestID, receipt.ID, receipt.Bolt11); err != nil {
+		b.log.Error().
+			Err(err).
+			Str("event_id", eventID).
+			Msg("failed to save zap receipt")
+	}
+
+	if nevent := b.nevent(receipt.ID, "", nil); nevent != "" {
+		fmt.Fprintf(b.out, "🧾 Zap receipt for %s: nostr:%s\n", truncate(eventID, 16), nevent)
+	}
+}
+
+// maxNeventRelays caps the relay hints embedded in printed nevents
+const maxNeventRelays = 3
+
+// nevent encodes an event reference with relay hints for clicking through in
+// a client: the relay the event arrived from first, then monitored relays
+func (b *Bot) nevent(eventID, author string, from *nostr.Relay) string {
+	var relays []string
+	if from != nil {
+		relays = append(relays, from.URL)
+	}
+	for _, relay := range b.monitorRelays() {
+		if len(relays) >= maxNeventRelays {
+			break
+		}
+		if !slices.Contains(relays, nostr.NormalizeURL(relay)) {
+			relays = append(relays, nostr.NormalizeURL(relay))
+		}
+	}
+
+	nevent, err := nip19.EncodeEvent(eventID, relays, author)
+	if err != nil {
+		b.log.Warn().Err(err).Str("event_id", eventID).Msg("failed to encode nevent")
+		return ""
+	}
+	return nevent
+}
+
+// reactOutcome is the result of publishing one of the configured reactions
+type reactOutcome struct {
+	content string
+	result  *reaction.ReactResult
+	ok      bool
+}
+
+// allReacted reports whether every reaction was published
+func allReacted(outcomes []reactOutcome) bool {
+	for _, outcome := range outcomes {
+		if !outcome.ok {
+			return false
+		}
+	}
+	return len(outcomes) > 0
+}
+
+// anyReacted reports whether at least one reaction was published
+func anyReacted(outcomes []reactOutcome) bool {
+	for _, outcome := range outcomes {
+		if outcome.ok {
+			return true
+		}
+	}
 	return false
 }
 
-// tryReact attempts to react (with 1 retry)
-func (b *Bot) tryReact(event nostr.RelayEvent) bool {
+// printReaction prints the console lines for one reaction, naming its
+// content when several reactions are configured
+func (b *Bot) printReaction(outcome reactOutcome, named bool) {
+	label := ""
+	if named {
+		label = " " + outcome.content
+	}
+	result := outcome.result
+
+	if outcome.ok && result != nil {
+		fmt.Fprintf(b.out, "💬 Reacted%s successfully! (%d/%d relays)\n", label, result.Succeeded, result.Attempted)
+		if failed := result.FailedRelays(); len(failed) > 0 {
+			fmt.Fprintf(b.out, "   Not accepted by: %s\n", strings.Join(failed, ", "))
+		}
+	} else if outcome.ok {
+		fmt.Fprintf(b.out, "💬 Reacted%s successfully!\n", label)
+	} else if result != nil {
+		fmt.Fprintf(b.out, "⚠️  Reaction%s failed after retry (%d/%d relays accepted).\n", label, result.Succeeded, result.Attempted)
+		if failed := result.FailedRelays(); len(failed) > 0 {
+			fmt.Fprintf(b.out, "   Not accepted by: %s\n", strings.Join(failed, ", "))
+		}
+	} else {
+		fmt.Fprintf(b.out, "⚠️  Reaction%s failed after retry.\n", label)
+		// Continue - zap might have succeeded
+	}
+}
+
+// tryReact publishes every configured reaction in order, pausing
+// reaction.spacing between them so relays do not rate-limit the burst
+func (b *Bot) tryReact(event nostr.RelayEvent) []reactOutcome {
+	emojis := b.config.Reaction.Emojis()
+	outcomes := make([]reactOutcome, 0, len(emojis))
+
+	for i, emoji := range emojis {
+		if i > 0 {
+			select {
+			case <-time.After(b.config.Reaction.ReactionSpacing()):
+			case <-b.ctx.Done():
+				return outcomes
+			}
+		}
+
+		result, ok := b.reactOnce(event, emoji)
+		outcomes = append(outcomes, reactOutcome{content: emoji.Content, result: result, ok: ok})
+	}
+
+	return outcomes
+}
+
+// reactOnce attempts to publish a single reaction (with 1 retry)
+func (b *Bot) reactOnce(event nostr.RelayEvent, emoji config.ReactionEmoji) (*reaction.ReactResult, bool) {
+	log := b.log.With().
+		Str("component", "reaction").
+		Str("event_id", event.ID).
+		Str("reaction", emoji.Content).
+		Logger()
+
+	var result *reaction.ReactResult
 	for attempt := 1; attempt <= 2; attempt++ {
-		logger.Log.Info().
-			Str("event_id", event.ID).
-			Str("reaction", b.config.Reaction.Content).
+		log.Info().
 			Int("attempt", attempt).
 			Msg("attempting reaction")
 
-		reactCtx, cancel := context.WithTimeout(b.ctx, 60*time.Second)
-		err := reaction.React(
+		relays := b.publishRelays()
+		// Its own deadline: a slow reaction never holds up or cancels the zap
+		reactCtx, cancel := context.WithTimeout(b.ctx, b.config.Reaction.AttemptTimeout())
+		var err error
+		result, err = reaction.React(
 			reactCtx,
 			event.ID,
 			event.PubKey,
-			&b.config.Reaction,
-			b.bunkerClient,
-			b.config.Relays,
+			emoji,
+			b.reactSigner,
+			b.publisher,
+			relays,
+			// Pruning may leave fewer relays than publish.min_success
+			min(b.config.Publish.Threshold(len(relays)), len(relays)),
 		)
 		cancel()
 
+		if result != nil {
+			log.Info().
+				Str("reaction_id", result.EventID).
+				Int("attempt", attempt).
+				Int("attempted", result.Attempted).
+				Int("succeeded", result.Succeeded).
+				Int("failed", result.Failed).
+				Int("retried", result.Retried).
+				Interface("failures", result.Failures).
+				Msg("reaction relay results")
+		}
+
 		if err == nil {
-			logger.Log.Info().
-				Str("event_id", event.ID).
+			log.Info().
 				Int("attempt", attempt).
 				Msg("reaction successful")
-			return true
+			return result, true
 		}
 
-		logger.Log.Error().
+		log.Error().
 			Err(err).
-			Str("event_id", event.ID).
 			Int("attempt", attempt).
+			Dur("timeout", b.config.Reaction.AttemptTimeout()).
 			Msg("reaction failed")
 
 		if attempt == 1 {
-			time.Sleep(1 * time.Second) // Brief pause before retry
+			// Brief pause before retry
+			select {
+			case <-time.After(1 * time.Second):
+			case <-b.ctx.Done():
+				return result, false
+			}
 		}
 	}
 
-	logger.Log.Error().
-		Str("event_id", event.ID).
-		Msg("reaction failed after 2 attempts")
-	return false
+	log.Error().Msg("reaction failed after 2 attempts")
+	return result, false
+}
+
+// priorityPubkeys converts budget.priority_npubs to a set of hex pubkeys.
+// The npubs are checked by config validation, so bad entries are skipped.
+func priorityPubkeys(npubs []string) map[string]bool {
+	priority := make(map[string]bool, len(npubs))
+	for _, npub := range npubs {
+		if _, data, err := nip19.Decode(npub); err == nil {
+			if hex, ok := data.(string); ok {
+				priority[hex] = true
+			}
+		}
+	}
+	return priority
 }
 
 func (b *Bot) npubsToHex() ([]string, error) {
@@ -403,12 +1487,12 @@ func (b *Bot) npubsToHex() ([]string, error) {
 	for _, npub := range b.npubs {
 		hr, data, err := nip19.Decode(npub)
 		if err != nil {
-			logger.Log.Error().Err(err).Str("npub", npub).Msg("failed to decode npub")
+			b.log.Error().Err(err).Str("npub", npub).Msg("failed to decode npub")
 			return nil, fmt.Errorf("failed to decode %s: %w", npub, err)
 		}
 
 		if hr != "npub" {
-			logger.Log.Error().Str("hr", hr).Msg("unexpected nip19 prefix")
+			b.log.Error().Str("hr", hr).Msg("unexpected nip19 prefix")
 			return nil, fmt.Errorf("expected npub, got %s", hr)
 		}
 
@@ -419,7 +1503,7 @@ func (b *Bot) npubsToHex() ([]string, error) {
 		case []byte:
 			hexPubkey = hex.EncodeToString(v)
 		default:
-			logger.Log.Error().Msg("unexpected nip19 decode type")
+			b.log.Error().Msg("unexpected nip19 decode type")
 			return nil, fmt.Errorf("unexpected type from decode: %T", data)
 		}
 
@@ -430,8 +1514,9 @@ func (b *Bot) npubsToHex() ([]string, error) {
 }
 
 func truncate(s string, maxLen int) string {
-	if len(s) <= maxLen {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
 		return s
 	}
-	return s[:maxLen] + "..."
+	return string(runes[:maxLen]) + "..."
 }
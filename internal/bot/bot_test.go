@@ -0,0 +1,65 @@
+package bot
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistic0xb/pekka/config"
+	"github.com/mistic0xb/pekka/internal/clock"
+	"github.com/mistic0xb/pekka/internal/db"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/rs/zerolog"
+)
+
+// testBot returns a Bot with just enough set up to call its filters
+func testBot(database *db.DB) *Bot {
+	nop := zerolog.Nop()
+	return &Bot{config: &config.Config{}, db: database, clock: &clock.Clock{}, log: &nop, out: io.Discard}
+}
+
+func TestOlderThanLastZap(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "pekka.db"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer database.Close()
+
+	const author = "author"
+	if err := database.MarkZapped("newer", author, 21, 200, ""); err != nil {
+		t.Fatalf("MarkZapped: %v", err)
+	}
+	late := &nostr.Event{ID: "older", PubKey: author, CreatedAt: 100}
+
+	tests := []struct {
+		policy string
+		want   int64
+	}{
+		{"", 0}, // process by default
+		{config.OutOfOrderProcess, 0},
+		{config.OutOfOrderSkip, 200},
+	}
+
+	for _, tt := range tests {
+		t.Run("policy "+tt.policy, func(t *testing.T) {
+			b := testBot(database)
+			b.config.Zap.OutOfOrder = tt.policy
+
+			got, err := b.olderThanLastZap(late)
+			if err != nil {
+				t.Fatalf("olderThanLastZap: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	zapped, err := database.IsZapped(late.ID)
+	if err != nil {
+		t.Fatalf("IsZapped: %v", err)
+	}
+	if zapped {
+		t.Error("late note should not be marked zapped")
+	}
+}
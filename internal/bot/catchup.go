@@ -0,0 +1,119 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// catchUpFetchTimeout bounds the missed-notes query at startup
+const catchUpFetchTimeout = 15 * time.Second
+
+// catchUp counts the notes posted since the last processed one (within
+// catch_up.window) and offers to zap those not zapped yet. Backfilled notes go
+// through processEvent one at a time, so every filter and budget still applies.
+func (b *Bot) catchUp() {
+	lastSeen, err := b.db.GetLastSeen()
+	if err != nil {
+		b.log.Warn().Err(err).Msg("catch-up skipped")
+		return
+	}
+	if lastSeen == 0 {
+		b.log.Info().Msg("no last seen note recorded, nothing to catch up on")
+		return
+	}
+
+	now := b.clock.Now()
+	since := max(nostr.Timestamp(lastSeen+1), now-nostr.Timestamp(b.config.CatchUp.LookBack()/time.Second))
+	if since >= now {
+		return
+	}
+
+	pubkeys, err := b.npubsToHex()
+	if err != nil {
+		b.log.Warn().Err(err).Msg("catch-up skipped")
+		return
+	}
+
+	s := b.spinner("Checking for missed notes", "blue")
+	missed := b.fetchMissed(pubkeys, since, now)
+	s.Stop()
+
+	var unzapped []nostr.RelayEvent
+	for _, event := range missed {
+		zapped, err := b.db.IsZapped(event.ID)
+		if err != nil {
+			b.log.Warn().Err(err).Str("event_id", event.ID).Msg("failed to check missed note")
+			continue
+		}
+		if !zapped {
+			unzapped = append(unzapped, event)
+		}
+	}
+
+	b.log.Info().
+		Int64("since", int64(since)).
+		Int("missed", len(missed)).
+		Int("unzapped", len(unzapped)).
+		Msg("catch-up check")
+
+	fmt.Fprintf(b.out, "📬 %d note(s) posted since %s, %d not zapped yet\n",
+		len(missed), since.Time().Format("2006-01-02 15:04"), len(unzapped))
+	if len(unzapped) == 0 {
+		fmt.Fprintln(b.out)
+		return
+	}
+
+	if !b.prompter.Interactive() {
+		b.log.Info().Int("unzapped", len(unzapped)).Msg("non-interactive, not backfilling missed notes")
+		fmt.Fprintln(b.out, "Not zapping them: no terminal to confirm on.")
+		fmt.Fprintln(b.out)
+		return
+	}
+
+	if !b.prompter.Confirm("Zap them now? Budget limits and filters still apply") {
+		b.log.Info().Msg("user declined catch-up zaps")
+		fmt.Fprintln(b.out)
+		return
+	}
+
+	for _, event := range unzapped {
+		if b.ctx.Err() != nil {
+			return
+		}
+		b.processEvent(event)
+	}
+	fmt.Fprintln(b.out)
+}
+
+// fetchMissed returns the monitored authors' notes in [since, until], oldest
+// first, deduplicated across relays
+func (b *Bot) fetchMissed(pubkeys []string, since, until nostr.Timestamp) []nostr.RelayEvent {
+	ctx, cancel := context.WithTimeout(b.ctx, catchUpFetchTimeout)
+	defer cancel()
+
+	filter := nostr.Filter{
+		Kinds:   []int{1},
+		Authors: pubkeys,
+		Since:   &since,
+		Until:   &until,
+	}
+
+	seen := make(map[string]bool)
+	var events []nostr.RelayEvent
+	for event := range b.pool.FetchMany(ctx, b.monitorRelays(), filter) {
+		if seen[event.ID] {
+			continue
+		}
+		seen[event.ID] = true
+		events = append(events, event)
+	}
+
+	slices.SortFunc(events, func(a, b nostr.RelayEvent) int {
+		return int(a.CreatedAt) - int(b.CreatedAt)
+	})
+	return events
+}
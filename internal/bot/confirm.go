@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mistic0xb/pekka/internal/db"
+)
+
+// confirmInterval is how often pending zaps are checked with the wallet
+const confirmInterval = 15 * time.Second
+
+// confirmsZaps reports whether paid zaps are recorded as pending and
+// verified later (zap.confirm_window)
+func (b *Bot) confirmsZaps() bool {
+	return b.config.Zap.ConfirmWindow > 0 && !b.config.IsShadow()
+}
+
+// confirmLoop verifies pending zaps until the bot stops. Zaps left pending
+// by an earlier run are picked up on the first pass.
+func (b *Bot) confirmLoop() {
+	ticker := time.NewTicker(confirmInterval)
+	defer ticker.Stop()
+
+	for {
+		b.reconcilePending()
+
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reconcilePending promotes pending zaps whose payment the wallet reports as
+// settled, or that have a stored zap receipt, and marks the ones still
+// unverified after zap.confirm_window as failed
+func (b *Bot) reconcilePending() {
+	pending, err := b.db.GetPendingZaps()
+	if err != nil {
+		b.log.Warn().Err(err).Msg("failed to load pending zaps")
+		return
+	}
+
+	for _, z := range pending {
+		if b.ctx.Err() != nil {
+			return
+		}
+
+		log := b.log.With().
+			Str("event_id", z.EventID).
+			Str("author", z.AuthorPubkey).
+			Int("amount", z.Amount).
+			Logger()
+
+		confirmed, via := b.verifyZap(z)
+		if confirmed {
+			if err := b.db.ConfirmZap(z.EventID); err != nil {
+				log.Error().Err(err).Msg("failed to confirm zap")
+				continue
+			}
+			log.Info().Str("via", via).Msg("zap confirmed")
+			continue
+		}
+
+		age := time.Since(time.Unix(z.ZappedAt, 0))
+		if age < b.config.Zap.ConfirmWindow {
+			continue
+		}
+
+		if err := b.db.FailZap(z.EventID); err != nil {
+			log.Error().Err(err).Msg("failed to mark unconfirmed zap as failed")
+			continue
+		}
+		log.Warn().
+			Dur("confirm_window", b.config.Zap.ConfirmWindow).
+			Msg("zap not confirmed in time, marked as failed")
+		fmt.Fprintf(b.out, "\n⚠️  Zap of %d sats for note %s was not confirmed within %s and is marked as failed. Check it with `pekka reconcile`.\n",
+			z.Amount, z.EventID, b.config.Zap.ConfirmWindow)
+	}
+}
+
+// verifyZap checks a pending zap with lookup_invoice, falling back to a
+// stored zap receipt for wallets without it. It returns how it was verified.
+func (b *Bot) verifyZap(z db.ZappedEvent) (bool, string) {
+	if z.Invoice != "" {
+		ctx, cancel := context.WithTimeout(b.ctx, 30*time.Second)
+		settled, err := b.zapper.InvoiceSettled(ctx, z.Invoice)
+		cancel()
+		if err == nil && settled {
+			return true, "lookup_invoice"
+		}
+		if err != nil {
+			b.log.Debug().Err(err).Str("event_id", z.EventID).Msg("lookup_invoice failed")
+		}
+	}
+
+	hasReceipt, err := b.db.HasReceipt(z.EventID)
+	if err != nil {
+		b.log.Warn().Err(err).Str("event_id", z.EventID).Msg("failed to check zap receipt")
+		return false, ""
+	}
+	if hasReceipt {
+		return true, "receipt"
+	}
+
+	return false, ""
+}
@@ -0,0 +1,78 @@
+package bot
+
+import (
+	"crypto/sha256"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/mistic0xb/pekka/config"
+)
+
+var link = regexp.MustCompile(`(?i)https?://\S+`)
+
+// contentDedup remembers hashes of zapped note content for zap.dedup_window,
+// so copies reposted under other event ids or authors are not zapped again
+type contentDedup struct {
+	mu   sync.Mutex
+	seen map[[sha256.Size]byte]time.Time
+}
+
+// claim reserves a content hash and reports whether it was free. A hash
+// claimed within the window, including by a zap still in flight, is not.
+func (d *contentDedup) claim(hash [sha256.Size]byte, window time.Duration) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	if d.seen == nil {
+		d.seen = make(map[[sha256.Size]byte]time.Time)
+	}
+	for h, at := range d.seen {
+		if now.Sub(at) > window {
+			delete(d.seen, h)
+		}
+	}
+
+	if _, ok := d.seen[hash]; ok {
+		return false
+	}
+	d.seen[hash] = now
+	return true
+}
+
+// release forgets a claimed hash whose zap did not go through
+func (d *contentDedup) release(hash [sha256.Size]byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.seen, hash)
+}
+
+// contentHash hashes note content normalized per zap.dedup_normalize. It
+// returns false when nothing is left to compare, e.g. a note that is only a link.
+func contentHash(content, mode string) ([sha256.Size]byte, bool) {
+	switch mode {
+	case config.DedupExact:
+		content = strings.TrimSpace(content)
+	default:
+		content = strings.ToLower(content)
+		if mode == config.DedupLoose {
+			content = link.ReplaceAllString(content, " ")
+			content = nostrURI.ReplaceAllString(content, " ")
+			content = strings.Map(func(r rune) rune {
+				if unicode.IsLetter(r) || unicode.IsDigit(r) {
+					return r
+				}
+				return ' '
+			}, content)
+		}
+		content = strings.Join(strings.Fields(content), " ")
+	}
+
+	if content == "" {
+		return [sha256.Size]byte{}, false
+	}
+	return sha256.Sum256([]byte(content)), true
+}
@@ -0,0 +1,154 @@
+package bot
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/mistic0xb/pekka/config"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// mentionOnlyMaxText is how many letters or digits a note may have besides
+// its nostr: mentions and still count as mention-only
+const mentionOnlyMaxText = 10
+
+// nostrURI matches NIP-21 references such as nostr:npub1... (with or without
+// the "nostr:" prefix, which some clients drop)
+var nostrURI = regexp.MustCompile(`(?i)(nostr:)?@?(npub|nprofile|note|nevent|naddr)1[02-9ac-hj-np-z]+`)
+
+// zapActivity matches notes that only announce a zap, as some clients
+// cross-post them: "I just zapped ...", "⚡ Zapped ..."
+var zapActivity = regexp.MustCompile(`(?i)^\W*(i\s+)?(just\s+)?zapped\b`)
+
+// languageNamespace is the NIP-32 label namespace for ISO 639-1 language codes
+const languageNamespace = "ISO-639-1"
+
+// filterNote applies the opt-in content filters and returns why the note
+// should be skipped with a human-readable detail, or "" to keep it
+func (b *Bot) filterNote(event *nostr.Event) (skipReason, string) {
+	if len(b.config.Zap.Languages) > 0 {
+		if lang := noteLanguage(event); lang != "" && !slices.Contains(b.config.Zap.Languages, lang) {
+			return skipLanguage, "language " + lang + " not in zap.languages"
+		}
+	}
+
+	if b.config.Zap.SkipRepublished {
+		if detail := republished(event); detail != "" {
+			return skipRepublished, detail
+		}
+	}
+
+	if b.config.Zap.SkipMentionOnly && isMentionOnly(event.Content) {
+		return skipMentionOnly, "note is only mentions"
+	}
+
+	if len(b.config.Zap.RequireHashtags) > 0 && !hasHashtag(event, b.config.Zap.RequireHashtags) {
+		return skipHashtag, "no hashtag from zap.require_hashtags"
+	}
+
+	if detail := b.contentWarningPolicy(event); detail != "" {
+		return skipContentWarning, detail
+	}
+
+	if reason, detail := b.automation.check(event, &b.config.Zap.Automated); reason != "" {
+		return reason, detail
+	}
+
+	return "", ""
+}
+
+// contentWarningPolicy applies zap.content_warning to the note's NIP-36 tag
+func (b *Bot) contentWarningPolicy(event *nostr.Event) string {
+	policy := b.config.Zap.ContentWarning
+	warned, reason := contentWarning(event)
+
+	switch {
+	case warned && policy == config.ContentWarningSkip:
+		return "note has a content warning"
+	case !warned && policy == config.ContentWarningOnly:
+		return "note has no content warning"
+	}
+
+	if warned {
+		b.log.Info().
+			Str("event_id", event.ID).
+			Str("content_warning", reason).
+			Str("policy", cmp.Or(policy, config.ContentWarningZap)).
+			Msg("zapping note with content warning")
+	}
+	return ""
+}
+
+// contentWarning reports whether the note carries a NIP-36 tag and its
+// reason. The reason is optional, so a bare ["content-warning"] counts too
+func contentWarning(event *nostr.Event) (bool, string) {
+	for _, tag := range event.Tags {
+		if len(tag) >= 1 && tag[0] == "content-warning" {
+			if len(tag) > 1 {
+				return true, tag[1]
+			}
+			return true, ""
+		}
+	}
+	return false, ""
+}
+
+// republished describes why the note is re-published content rather than
+// something the author wrote here, or returns ""
+func republished(event *nostr.Event) string {
+	// NIP-48: ["proxy", <source id>, <protocol>]
+	if tag := event.Tags.Find("proxy"); tag != nil {
+		protocol := "another network"
+		if len(tag) > 2 && tag[2] != "" {
+			protocol = tag[2]
+		}
+		return "note is bridged from " + protocol + " (proxy tag)"
+	}
+
+	if zapActivity.MatchString(event.Content) {
+		return "note is a republished zap announcement"
+	}
+
+	return ""
+}
+
+// hasHashtag reports whether one of the note's "t" tags is in hashtags
+// (lower case, without "#")
+func hasHashtag(event *nostr.Event, hashtags []string) bool {
+	for tag := range event.Tags.FindAll("t") {
+		if slices.Contains(hashtags, strings.ToLower(strings.TrimPrefix(tag[1], "#"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// noteLanguage returns the note's ISO 639-1 language label (NIP-32) in lower
+// case, or "" when the author's client did not label it
+func noteLanguage(event *nostr.Event) string {
+	for _, tag := range event.Tags {
+		if len(tag) >= 3 && tag[0] == "l" && tag[2] == languageNamespace {
+			return strings.ToLower(tag[1])
+		}
+	}
+	return ""
+}
+
+// isMentionOnly reports whether content has nostr: mentions and next to no
+// other text
+func isMentionOnly(content string) bool {
+	if !nostrURI.MatchString(content) {
+		return false
+	}
+
+	text := 0
+	for _, r := range nostrURI.ReplaceAllString(content, "") {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			text++
+		}
+	}
+	return text <= mentionOnlyMaxText
+}
@@ -0,0 +1,62 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/mistic0xb/pekka/config"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+func TestContentWarningPolicy(t *testing.T) {
+	bare := &nostr.Event{Tags: nostr.Tags{{"content-warning"}}}
+	withReason := &nostr.Event{Tags: nostr.Tags{{"t", "art"}, {"content-warning", "spoilers"}}}
+	plain := &nostr.Event{Tags: nostr.Tags{{"t", "art"}}}
+
+	tests := []struct {
+		name   string
+		event  *nostr.Event
+		policy string
+		skip   bool
+	}{
+		{"bare tag, skip", bare, config.ContentWarningSkip, true},
+		{"tag with reason, skip", withReason, config.ContentWarningSkip, true},
+		{"no tag, skip", plain, config.ContentWarningSkip, false},
+		{"bare tag, only", bare, config.ContentWarningOnly, false},
+		{"tag with reason, only", withReason, config.ContentWarningOnly, false},
+		{"no tag, only", plain, config.ContentWarningOnly, true},
+		{"bare tag, zap", bare, config.ContentWarningZap, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := testBot(nil)
+			b.config.Zap.ContentWarning = tt.policy
+
+			if got := b.contentWarningPolicy(tt.event) != ""; got != tt.skip {
+				t.Errorf("skip = %v, want %v", got, tt.skip)
+			}
+		})
+	}
+}
+
+func TestContentWarningReason(t *testing.T) {
+	tests := []struct {
+		name   string
+		tags   nostr.Tags
+		warned bool
+		reason string
+	}{
+		{"bare tag", nostr.Tags{{"content-warning"}}, true, ""},
+		{"tag with reason", nostr.Tags{{"content-warning", "nsfw"}}, true, "nsfw"},
+		{"no tag", nostr.Tags{{"p", "abc"}}, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warned, reason := contentWarning(&nostr.Event{Tags: tt.tags})
+			if warned != tt.warned || reason != tt.reason {
+				t.Errorf("got (%v, %q), want (%v, %q)", warned, reason, tt.warned, tt.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// initRelayHealth starts tracking every configured relay as healthy
+func (b *Bot) initRelayHealth() {
+	b.relayMu.Lock()
+	defer b.relayMu.Unlock()
+
+	b.activeRelays = slices.Clone(b.config.Relays)
+	b.relaySeen = make(map[string]time.Time, len(b.activeRelays))
+	now := time.Now()
+	for _, relay := range b.activeRelays {
+		b.relaySeen[nostr.NormalizeURL(relay)] = now
+	}
+}
+
+// monitorRelays returns the read relays still in use this session
+func (b *Bot) monitorRelays() []string {
+	return b.activeOf(b.config.ReadRelays())
+}
+
+// publishRelays returns the write relays still in use this session
+func (b *Bot) publishRelays() []string {
+	return b.activeOf(b.config.WriteRelays())
+}
+
+// activeOf keeps the relays that have not been pruned
+func (b *Bot) activeOf(relays []string) []string {
+	b.relayMu.Lock()
+	defer b.relayMu.Unlock()
+
+	if b.activeRelays == nil {
+		return relays
+	}
+	return slices.DeleteFunc(slices.Clone(relays), func(relay string) bool {
+		return !slices.Contains(b.activeRelays, relay)
+	})
+}
+
+// markRelaySeen records that a relay delivered an event
+func (b *Bot) markRelaySeen(url string) {
+	b.relayMu.Lock()
+	defer b.relayMu.Unlock()
+
+	if b.relaySeen != nil {
+		b.relaySeen[nostr.NormalizeURL(url)] = time.Now()
+	}
+}
+
+// pruneLoop periodically drops relays that have stayed disconnected for
+// longer than relay_prune.after
+func (b *Bot) pruneLoop() {
+	after := b.config.RelayPrune.After
+	interval := min(max(after/4, 10*time.Second), time.Minute)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+			for _, relay := range b.staleRelays(after) {
+				b.pruneRelay(relay)
+			}
+		}
+	}
+}
+
+// staleRelays refreshes the health of every active relay and returns those
+// unreachable for longer than after. A connected relay counts as healthy
+// even if quiet, since a small list may post rarely.
+func (b *Bot) staleRelays(after time.Duration) []string {
+	b.relayMu.Lock()
+	defer b.relayMu.Unlock()
+
+	now := time.Now()
+	var stale []string
+	for _, relay := range b.activeRelays {
+		url := nostr.NormalizeURL(relay)
+		if r, ok := b.pool.Relays.Load(url); ok && r.IsConnected() {
+			b.relaySeen[url] = now
+			continue
+		}
+		if now.Sub(b.relaySeen[url]) > after {
+			stale = append(stale, relay)
+		}
+	}
+	return stale
+}
+
+// pruneRelay stops using a relay for the rest of the session and
+// resubscribes without it. The last remaining relay is never pruned.
+func (b *Bot) pruneRelay(relay string) {
+	b.relayMu.Lock()
+	if len(b.activeRelays) <= 1 {
+		b.relayMu.Unlock()
+		b.log.Warn().Str("relay", relay).Msg("only one relay left, not pruning")
+		return
+	}
+	previous := b.activeRelays
+	b.activeRelays = slices.DeleteFunc(slices.Clone(previous), func(r string) bool { return r == relay })
+	silentFor := time.Since(b.relaySeen[nostr.NormalizeURL(relay)])
+	b.relayMu.Unlock()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	previousCancel := b.subCancel
+	if err := b.subscribeToEvents(); err != nil {
+		b.log.Error().Err(err).Str("relay", relay).Msg("failed to resubscribe after pruning relay")
+		b.relayMu.Lock()
+		b.activeRelays = previous
+		b.relayMu.Unlock()
+		return
+	}
+	previousCancel()
+
+	b.log.Warn().
+		Str("relay", relay).
+		Dur("unreachable_for", silentFor).
+		Int("remaining_relays", len(previous)-1).
+		Msg("pruned unreachable relay for this session")
+	fmt.Fprintf(b.out, "\n✂️  Dropped %s for this session (unreachable for %s)\n", relay, silentFor.Round(time.Second))
+	fmt.Fprintf(b.out, "   To remove it permanently: pekka relays remove %s\n", relay)
+}
@@ -0,0 +1,138 @@
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/mistic0xb/pekka/internal/nostrlist"
+)
+
+// refreshLoop periodically re-fetches the monitored list and resubscribes
+// when its members change
+func (b *Bot) refreshLoop() {
+	ticker := time.NewTicker(b.config.List.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+			b.refreshNPubs()
+		}
+	}
+}
+
+// refreshNPubs reloads the list and swaps the subscription if members changed
+func (b *Bot) refreshNPubs() {
+	b.log.Info().Msg("refreshing monitored list")
+
+	if !b.listChanged() {
+		return
+	}
+
+	list, err := b.fetchNPubs()
+	if err != nil {
+		b.log.Warn().Err(err).Msg("list refresh failed, keeping current members")
+		return
+	}
+
+	// A transient decryption failure would otherwise drop every private member
+	b.mu.Lock()
+	current := len(b.npubs)
+	b.mu.Unlock()
+	if list.PrivateUnreadable() && len(list.NPubs) < current {
+		b.log.Warn().Msg("refreshed list has unreadable private members, keeping current members")
+		return
+	}
+
+	npubs, listEventID := list.NPubs, list.EventID
+
+	if maxMembers := b.config.List.MaxMembers; maxMembers > 0 && len(npubs) > maxMembers {
+		b.log.Warn().
+			Int("npub_count", len(npubs)).
+			Int("max_members", maxMembers).
+			Msg("refreshed list exceeds max_members, keeping current members")
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.listEventID = listEventID
+
+	if sameMembers(b.npubs, npubs) {
+		b.log.Info().Int("npub_count", len(npubs)).Msg("list unchanged")
+		return
+	}
+
+	previous := b.npubs
+	previousCancel := b.subCancel
+	b.npubs = npubs
+
+	if err := b.subscribeToEvents(); err != nil {
+		b.log.Error().Err(err).Msg("failed to resubscribe after list refresh")
+		b.npubs = previous
+		b.subCancel = previousCancel
+		return
+	}
+	previousCancel()
+
+	b.log.Info().
+		Int("old_count", len(previous)).
+		Int("new_count", len(npubs)).
+		Msg("list changed, resubscribed")
+	fmt.Fprintf(b.out, "\n🔄 List updated: now monitoring %d npubs (was %d)\n", len(npubs), len(previous))
+}
+
+// listChanged cheaply checks whether a newer version of the NIP-51 list
+// exists before paying for a full fetch and decryption. Follow lists are
+// not encrypted, so they are always re-fetched.
+func (b *Bot) listChanged() bool {
+	b.mu.Lock()
+	current := b.listEventID
+	b.mu.Unlock()
+
+	if b.config.List.UsesFollows() || current == "" {
+		return true
+	}
+
+	latest, err := nostrlist.LatestListEvent(
+		b.ctx,
+		b.config.ListRelays(),
+		b.config.Author.NPub,
+		b.pool,
+		b.config.SelectedList,
+	)
+	if err != nil {
+		b.log.Warn().Err(err).Msg("could not check list version, doing full refresh")
+		return true
+	}
+
+	if latest.ID == current {
+		b.log.Info().
+			Str("event_id", latest.ID).
+			Msg("list event unchanged, skipping re-decryption")
+		return false
+	}
+
+	b.log.Info().
+		Str("old_event_id", current).
+		Str("new_event_id", latest.ID).
+		Msg("newer list event found")
+	return true
+}
+
+// sameMembers reports whether two npub sets contain the same members
+func sameMembers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	sortedA := slices.Clone(a)
+	sortedB := slices.Clone(b)
+	slices.Sort(sortedA)
+	slices.Sort(sortedB)
+	return slices.Equal(sortedA, sortedB)
+}
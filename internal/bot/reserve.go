@@ -0,0 +1,146 @@
+package bot
+
+import (
+	"fmt"
+
+	"github.com/mistic0xb/pekka/internal/ui"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// reservations holds the sats and author slots of zaps that passed the budget
+// checks but are not recorded yet. Notes are processed concurrently, so
+// checking only the database would let several zaps through a limit only one
+// of them fits.
+type reservations struct {
+	sats       int            // sats claimed by zaps in flight
+	newAuthors map[string]int // zaps in flight per author not yet zapped today
+}
+
+// reservation is one zap's claim, held until the zap is recorded or fails
+type reservation struct {
+	b         *Bot
+	sats      int
+	newAuthor string // author whose max_authors_per_day slot is claimed, if any
+	maxBump   int    // bump limit, lowered to what the lifetime limit leaves
+}
+
+// reserve checks budget.lifetime_limit and budget.max_authors_per_day
+// against the database plus the zaps in flight and, if the zap fits, claims
+// its sats and author slot until release. A bump past amount is limited to
+// what is left, and claimed too. newAuthor is whether the author has no zap
+// recorded today.
+func (b *Bot) reserve(event nostr.RelayEvent, amount, maxBump int, newAuthor bool, header func()) (*reservation, bool) {
+	b.reservedMu.Lock()
+	defer b.reservedMu.Unlock()
+
+	res := &reservation{b: b, maxBump: maxBump}
+
+	if limit := b.config.Budget.LifetimeLimit; limit > 0 {
+		total, err := b.db.GetTotalSpent()
+		if err != nil {
+			b.log.Error().Err(err).Msg("failed to fetch lifetime total")
+			header()
+			fmt.Fprintf(b.out, "Error checking budget: %v\n", err)
+			b.counters.failed.Add(1)
+			return nil, false
+		}
+
+		if b.lifetimeReached.Load() || total+amount > limit {
+			b.lifetimeLimitReached(event, total, limit)
+			return nil, false
+		}
+
+		// Zaps in flight may still fail, so they only hold this note back
+		committed := total + b.reserved.sats
+		if committed+amount > limit {
+			b.skip(event.Event, skipLifetimeBudget).
+				Int("lifetime_total", total).
+				Int("in_flight", b.reserved.sats).
+				Int("limit", limit).
+				Msg("lifetime budget held by zaps in flight")
+			if b.verbose(ui.LevelDecision) {
+				header()
+				fmt.Fprintf(b.out, "⚠️  Lifetime budget is held by zaps in flight (%d+%d/%d sats)\n", total, b.reserved.sats, limit)
+			}
+			return nil, false
+		}
+		res.maxBump = min(res.maxBump, limit-committed)
+	}
+
+	// Spread zaps across people: past the cap only today's authors qualify,
+	// including those with a zap in flight
+	if maxAuthors := b.config.Budget.MaxAuthorsPerDay; maxAuthors > 0 && newAuthor && b.reserved.newAuthors[event.PubKey] == 0 {
+		authors, err := b.db.CountDistinctAuthorsToday()
+		if err != nil {
+			b.log.Error().Err(err).Msg("failed to count today's authors")
+			header()
+			fmt.Fprintf(b.out, "Error checking author cap: %v\n", err)
+			b.counters.failed.Add(1)
+			return nil, false
+		}
+
+		authors += len(b.reserved.newAuthors)
+		if authors >= maxAuthors {
+			b.skip(event.Event, skipAuthorsPerDay).
+				Int("authors_today", authors).
+				Int("limit", maxAuthors).
+				Msg("daily author cap reached")
+			if b.verbose(ui.LevelDecision) {
+				header()
+				fmt.Fprintf(b.out, "⚠️  Already zapped %d different authors today (max_authors_per_day), skipping new author\n", authors)
+			}
+			return nil, false
+		}
+	}
+
+	res.sats = max(amount, res.maxBump)
+	b.reserved.sats += res.sats
+	if newAuthor {
+		if b.reserved.newAuthors == nil {
+			b.reserved.newAuthors = make(map[string]int)
+		}
+		res.newAuthor = event.PubKey
+		b.reserved.newAuthors[event.PubKey]++
+	}
+	return res, true
+}
+
+// release returns the claim. Call it after the zap is recorded, so there is
+// no moment its sats are in neither the database nor the reservations.
+func (r *reservation) release() {
+	r.b.reservedMu.Lock()
+	defer r.b.reservedMu.Unlock()
+
+	r.b.reserved.sats -= r.sats
+	if r.newAuthor != "" {
+		if r.b.reserved.newAuthors[r.newAuthor]--; r.b.reserved.newAuthors[r.newAuthor] == 0 {
+			delete(r.b.reserved.newAuthors, r.newAuthor)
+		}
+	}
+}
+
+// lifetimeLimitReached skips a note that does not fit budget.lifetime_limit.
+// The first time it stops the bot zapping, announces it and fires the
+// webhook.
+func (b *Bot) lifetimeLimitReached(event nostr.RelayEvent, total, limit int) {
+	if b.lifetimeReached.CompareAndSwap(false, true) {
+		b.log.Warn().
+			Int("lifetime_total", total).
+			Int("lifetime_limit", limit).
+			Msg("lifetime budget reached, no more zaps will be sent")
+		fmt.Fprintln(b.out)
+		fmt.Fprintln(b.out, "🛑 🛑 🛑  LIFETIME BUDGET REACHED  🛑 🛑 🛑")
+		fmt.Fprintf(b.out, "%d of %d sats spent in total. No more zaps will be sent;\n", total, limit)
+		fmt.Fprintln(b.out, "raise budget.lifetime_limit to continue.")
+		fmt.Fprintln(b.out)
+
+		if b.webhook != nil {
+			go b.notifyLifetimeLimit(total, limit)
+		}
+	}
+
+	b.skip(event.Event, skipLifetimeBudget).
+		Int("lifetime_total", total).
+		Int("limit", limit).
+		Msg("lifetime budget reached")
+}
@@ -0,0 +1,116 @@
+package bot
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/mistic0xb/pekka/internal/db"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+func TestReserveLifetimeLimitUnderConcurrency(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "pekka.db"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer database.Close()
+
+	if err := database.MarkZapped("earlier", "author", 80, 0, ""); err != nil {
+		t.Fatalf("MarkZapped: %v", err)
+	}
+
+	b := testBot(database)
+	b.config.Budget.LifetimeLimit = 100
+
+	// 20 sats are left: of ten concurrent 15 sat zaps exactly one fits
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var held []*reservation
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			event := nostr.RelayEvent{Event: &nostr.Event{ID: "note", PubKey: "author"}}
+			if res, ok := b.reserve(event, 15, 0, false, func() {}); ok {
+				mu.Lock()
+				held = append(held, res)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(held) != 1 {
+		t.Fatalf("%d reservations fit, want 1", len(held))
+	}
+	if b.lifetimeReached.Load() {
+		t.Error("a zap in flight must not stop the bot for good, it may still fail")
+	}
+
+	held[0].release()
+	if b.reserved.sats != 0 {
+		t.Errorf("%d sats still reserved after release", b.reserved.sats)
+	}
+
+	// Past the recorded total the limit is reached for good
+	event := nostr.RelayEvent{Event: &nostr.Event{ID: "big", PubKey: "author"}}
+	if _, ok := b.reserve(event, 30, 0, false, func() {}); ok {
+		t.Error("a 30 sat zap fit with 20 sats left")
+	}
+	if !b.lifetimeReached.Load() {
+		t.Error("lifetime limit not marked reached")
+	}
+}
+
+func TestReserveAuthorSlotsUnderConcurrency(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "pekka.db"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer database.Close()
+
+	if err := database.MarkZapped("earlier", "zapped-today", 21, 0, ""); err != nil {
+		t.Fatalf("MarkZapped: %v", err)
+	}
+
+	b := testBot(database)
+	b.config.Budget.MaxAuthorsPerDay = 3
+
+	// One slot is taken, so of ten new authors at once only two get one
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var held []*reservation
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			event := nostr.RelayEvent{Event: &nostr.Event{ID: "note", PubKey: string(rune('a' + i))}}
+			if res, ok := b.reserve(event, 21, 0, true, func() {}); ok {
+				mu.Lock()
+				held = append(held, res)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(held) != 2 {
+		t.Fatalf("%d new authors got a slot, want 2", len(held))
+	}
+
+	// An author with a zap in flight is not new again
+	event := nostr.RelayEvent{Event: &nostr.Event{ID: "second", PubKey: held[0].newAuthor}}
+	res, ok := b.reserve(event, 21, 0, true, func() {})
+	if !ok {
+		t.Fatal("second zap for an author in flight was refused")
+	}
+	res.release()
+
+	for _, res := range held {
+		res.release()
+	}
+	if len(b.reserved.newAuthors) != 0 {
+		t.Errorf("author slots still held after release: %v", b.reserved.newAuthors)
+	}
+}
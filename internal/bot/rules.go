@@ -0,0 +1,73 @@
+package bot
+
+import (
+	"regexp"
+
+	"github.com/mistic0xb/pekka/config"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// amountRule is a compiled config.ZapRule
+type amountRule struct {
+	pattern *regexp.Regexp
+	amount  int
+}
+
+// compileRules compiles zap rules; patterns are validated with the config
+func compileRules(rules []config.ZapRule) []amountRule {
+	compiled := make([]amountRule, 0, len(rules))
+	for _, rule := range rules {
+		compiled = append(compiled, amountRule{
+			pattern: regexp.MustCompile(rule.Match),
+			amount:  rule.Amount,
+		})
+	}
+	return compiled
+}
+
+// zapAmount returns the amount of the first rule matching the note's content
+// or hashtags, falling back to zap.amount_fiat at the current rate or zap.amount
+func (b *Bot) zapAmount(event *nostr.Event) (int, error) {
+	for _, rule := range b.rules {
+		if ruleMatches(rule.pattern, event) {
+			b.log.Info().
+				Str("event_id", event.ID).
+				Str("rule", rule.pattern.String()).
+				Int("amount", rule.amount).
+				Msg("zap rule matched")
+			return rule.amount, nil
+		}
+	}
+
+	if b.fiat != nil {
+		value, currency, _ := b.config.Zap.FiatAmount() // validated with the config
+		sats, err := b.fiat.ToSats(b.ctx, value)
+		if err != nil {
+			return 0, err
+		}
+		b.log.Info().
+			Str("event_id", event.ID).
+			Float64("fiat", value).
+			Str("currency", currency).
+			Int("amount", sats).
+			Msg("converted fiat amount")
+		return sats, nil
+	}
+
+	return b.config.Zap.Amount, nil
+}
+
+// ruleMatches checks the content and each "t" tag (as #tag)
+func ruleMatches(pattern *regexp.Regexp, event *nostr.Event) bool {
+	if pattern.MatchString(event.Content) {
+		return true
+	}
+
+	for tag := range event.Tags.FindAll("t") {
+		if pattern.MatchString("#" + tag[1]) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,45 @@
+package bot
+
+import (
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/rs/zerolog"
+)
+
+// skipReason is the skip_reason logged on every note that is not zapped, so
+// JSON logs can be aggregated by why zaps did not happen
+type skipReason string
+
+const (
+	skipOwnNote          skipReason = "own_note"
+	skipStale            skipReason = "stale"
+	skipLanguage         skipReason = "language"
+	skipMentionOnly      skipReason = "mention_only"
+	skipRepublished      skipReason = "republished"
+	skipHashtag          skipReason = "hashtag"
+	skipDuplicateContent skipReason = "duplicate_content"
+	skipContentWarning   skipReason = "content_warning"
+	skipLinkOnly         skipReason = "link_only"
+	skipRepeatedContent  skipReason = "repeated_content"
+	skipRegularInterval  skipReason = "regular_interval"
+	skipAlreadyZapped    skipReason = "already_zapped"
+	skipOlderThanLastZap skipReason = "older_than_last_zap"
+	skipNotSampled       skipReason = "not_sampled"
+	skipNoPrice          skipReason = "no_price"
+	skipMaxPerZap        skipReason = "max_per_zap"
+	skipDailyBudget      skipReason = "daily_budget"
+	skipLifetimeBudget   skipReason = "lifetime_budget"
+	skipAuthorBudget     skipReason = "author_budget"
+	skipAuthorsPerDay    skipReason = "max_authors"
+	skipLowBalance       skipReason = "low_balance"
+	skipNotConfirmed     skipReason = "not_confirmed"
+)
+
+// skip counts a skipped note and starts its log line with the event, the
+// author and the skip_reason
+func (b *Bot) skip(event *nostr.Event, reason skipReason) *zerolog.Event {
+	b.counters.skipped.Add(1)
+	return b.log.Info().
+		Str("event_id", event.ID).
+		Str("author", event.PubKey).
+		Str("skip_reason", string(reason))
+}
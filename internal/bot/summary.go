@@ -0,0 +1,69 @@
+package bot
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// counters are session totals updated from concurrent event handlers
+type counters struct {
+	processed  atomic.Int64 // notes handled by processEvent
+	zapped     atomic.Int64
+	zappedSats atomic.Int64
+	skipped    atomic.Int64 // notes not zapped on purpose (budget, sampling, ...)
+	failed     atomic.Int64 // notes not zapped because something went wrong
+}
+
+// summaryLoop logs a one-line summary of the session every summary_interval
+func (b *Bot) summaryLoop() {
+	ticker := time.NewTicker(b.config.SummaryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+			b.logSummary()
+		}
+	}
+}
+
+// DumpStats logs and prints the session counters on demand. It only reads
+// in-memory state, so it never contends with the bot for the database.
+func (b *Bot) DumpStats() {
+	b.log.Info().Msg("stats requested")
+	b.logSummary()
+}
+
+// logSummary snapshots the counters and the cached balance
+func (b *Bot) logSummary() {
+	processed := b.counters.processed.Load()
+	zapped := b.counters.zapped.Load()
+	zappedSats := b.counters.zappedSats.Load()
+	skipped := b.counters.skipped.Load()
+	failed := b.counters.failed.Load()
+
+	b.balanceMu.Lock()
+	balance, known := b.balance/1000, b.balanceKnown
+	b.balanceMu.Unlock()
+
+	event := b.log.Info().
+		Int64("processed", processed).
+		Int64("zapped", zapped).
+		Int64("zapped_sats", zappedSats).
+		Int64("skipped", skipped).
+		Int64("failed", failed)
+	if known {
+		event = event.Int64("balance_sats", balance)
+	}
+	event.Msg("session summary")
+
+	balanceText := "unknown"
+	if known {
+		balanceText = fmt.Sprintf("%d sats", balance)
+	}
+	fmt.Fprintf(b.out, "📊 Processed %d, zapped %d (%d sats), skipped %d, failed %d, balance %s\n",
+		processed, zapped, zappedSats, skipped, failed, balanceText)
+}
@@ -0,0 +1,58 @@
+package bot
+
+import (
+	"cmp"
+	"fmt"
+	"time"
+
+	"github.com/mistic0xb/pekka/internal/webhook"
+	"github.com/mistic0xb/pekka/internal/zap"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// notifyZap posts the zap to webhook.url. The zap request ID is the
+// idempotency key: each zap has its own, and every retry reuses it.
+func (b *Bot) notifyZap(event *nostr.Event, result *zap.Zap) {
+	delivery := webhook.Delivery{
+		IdempotencyKey: cmp.Or(result.RequestID, event.ID),
+		Type:           webhook.TypeZap,
+		EventID:        event.ID,
+		Author:         event.PubKey,
+		AmountSats:     result.Amount,
+		ZapRequestID:   result.RequestID,
+		Shadow:         b.config.IsShadow(),
+		CreatedAt:      time.Now().Unix(),
+	}
+	if result.Payee != event.PubKey {
+		delivery.Payee = result.Payee
+	}
+
+	if err := b.webhook.Send(b.ctx, delivery); err != nil {
+		b.log.Error().
+			Err(err).
+			Str("event_id", event.ID).
+			Str("idempotency_key", delivery.IdempotencyKey).
+			Msg("webhook delivery gave up")
+	}
+}
+
+// notifyLifetimeLimit posts that budget.lifetime_limit stopped the bot
+// zapping. The key only depends on the limit, so a receiver sees it once per
+// limit however many runs hit it.
+func (b *Bot) notifyLifetimeLimit(total, limit int) {
+	delivery := webhook.Delivery{
+		IdempotencyKey: fmt.Sprintf("lifetime_limit:%d", limit),
+		Type:           webhook.TypeLifetimeLimit,
+		SpentSats:      total,
+		LimitSats:      limit,
+		Shadow:         b.config.IsShadow(),
+		CreatedAt:      time.Now().Unix(),
+	}
+
+	if err := b.webhook.Send(b.ctx, delivery); err != nil {
+		b.log.Error().
+			Err(err).
+			Str("idempotency_key", delivery.IdempotencyKey).
+			Msg("webhook delivery gave up")
+	}
+}
@@ -12,93 +12,135 @@ import (
 
 	"github.com/nbd-wtf/go-nostr"
 	"github.com/nbd-wtf/go-nostr/nip46"
+	"github.com/rs/zerolog"
 )
 
 type Client struct {
 	bunker *nip46.BunkerClient
+	log    *zerolog.Logger
 }
 
+// AuthHandler receives the URL a remote signer asks the user to open to
+// approve the connection
+type AuthHandler func(url string)
+
+// NewAuthHandler returns an AuthHandler that prints the auth URL to ctx's
+// output and, when file is set, also writes it there so a service without a
+// watched terminal can still surface it
+func NewAuthHandler(ctx context.Context, file string) AuthHandler {
+	out := ui.Output(ctx)
+	return func(url string) {
+		fmt.Fprintf(out, "Auth URL: %s\n", url)
+		if file == "" {
+			return
+		}
+
+		if err := os.WriteFile(file, []byte(url+"\n"), 0600); err != nil {
+			logger.Ctx(ctx).Error().Err(err).Str("file", file).Msg("failed to write bunker auth URL")
+			return
+		}
+		logger.Ctx(ctx).Info().Str("file", file).Msg("bunker auth URL written to file")
+		fmt.Fprintf(out, "Auth URL also written to %s\n", file)
+	}
+}
+
+// ClientKeyFile holds the persisted client key, beside config.yml in the
+// working directory
+const ClientKeyFile = ".bunker_client_key"
+
 // loadOrCreateClientKey loads a persisted ephemeral key, or creates and saves a new one.
 // Reusing the same client key across runs means Amber/remote signers remember the
 // granted permissions and don't require re-approval every time.
-func loadOrCreateClientKey() (string, error) {
-	keyPath := ".bunker_client_key" // saved beside config.yml in the root directory
+func loadOrCreateClientKey(ctx context.Context) (string, error) {
+	keyPath := ClientKeyFile
 
 	data, err := os.ReadFile(keyPath)
 	if err == nil {
 		key := strings.TrimSpace(string(data))
 		if len(key) == 64 {
-			logger.Log.Info().Str("key_path", keyPath).Msg("loaded persisted client key")
+			logger.Ctx(ctx).Info().Str("key_path", keyPath).Msg("loaded persisted client key")
 			return key, nil
 		}
-		logger.Log.Warn().Str("key_path", keyPath).Msg("persisted key invalid, regenerating")
+		logger.Ctx(ctx).Warn().Str("key_path", keyPath).Msg("persisted key invalid, regenerating")
 	}
 
 	key := nostr.GeneratePrivateKey()
 	if err := os.WriteFile(keyPath, []byte(key), 0600); err != nil {
-		logger.Log.Warn().Err(err).Msg("could not persist client key; permissions will reset on next run")
+		logger.Ctx(ctx).Warn().Err(err).Msg("could not persist client key; permissions will reset on next run")
 	} else {
-		logger.Log.Info().Str("key_path", keyPath).Msg("generated and persisted new client key (beside config.yml)")
+		logger.Ctx(ctx).Info().Str("key_path", keyPath).Msg("generated and persisted new client key (beside config.yml)")
 	}
 	return key, nil
 }
 
-// NewClient creates a bunker client from bunkerURL
-func NewClient(ctx context.Context, bunkerURL string, pool *nostr.SimplePool) (*Client, error) {
-	logger.Log.Info().Msg("validating bunker URL")
+// NewClient creates a bunker client from bunkerURL. onAuth is called if the
+// signer requires approval through an auth URL (nil prints it). Logs and
+// console output go to ctx's logger and output.
+func NewClient(ctx context.Context, bunkerURL string, pool *nostr.SimplePool, onAuth AuthHandler) (*Client, error) {
+	log := logger.Ctx(ctx)
+	out := ui.Output(ctx)
+
+	log.Info().Msg("validating bunker URL")
 
 	if !nip46.IsValidBunkerURL(bunkerURL) {
-		logger.Log.Error().Msg("invalid bunker URL format")
+		log.Error().Msg("invalid bunker URL format")
 		return nil, fmt.Errorf("invalid bunker URL format")
 	}
 
-	clientSecretKey, err := loadOrCreateClientKey()
+	clientSecretKey, err := loadOrCreateClientKey(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("could not obtain client key: %w", err)
 	}
 
-	sp := ui.NewSpinner("Authenticating from bunker", 11, "blue")
+	var sp *ui.Spinner
+	if ui.OnTerminal(ctx) {
+		sp = ui.NewSpinner("Authenticating from bunker", 11, "blue")
+	}
 
 	// Background context — ConnectBunker keeps a relay subscription open for
 	// the entire process lifetime. Cancelling this would break all future
 	// SignEvent / Decrypt calls.
 	bunkerCtx := context.Background()
 
-	logger.Log.Info().Msg("calling ConnectBunker — waiting for remote signer approval")
+	log.Info().Msg("calling ConnectBunker — waiting for remote signer approval")
+
+	if onAuth == nil {
+		onAuth = NewAuthHandler(ctx, "")
+	}
 
 	bunker, err := nip46.ConnectBunker(bunkerCtx, clientSecretKey, bunkerURL, pool, func(url string) {
-		logger.Log.Info().Str("auth_url", url).Msg("bunker auth URL received — open this to approve")
-		fmt.Printf("Auth URL: %s\n", url)
+		log.Info().Str("auth_url", url).Msg("bunker auth URL received — open this to approve")
+		onAuth(url)
 	})
 	sp.Stop()
 
 	if err != nil {
 		if strings.Contains(err.Error(), "already connected") && bunker != nil {
-			logger.Log.Warn().Msg("bunker reported already connected — reusing existing connection")
-			fmt.Println("Connection already exists, continuing...")
-			fmt.Println()
-			return &Client{bunker: bunker}, nil
+			log.Warn().Msg("bunker reported already connected — reusing existing connection")
+			fmt.Fprintln(out, "Connection already exists, continuing...")
+			fmt.Fprintln(out)
+			return &Client{bunker: bunker, log: log}, nil
 		}
-		logger.Log.Error().Err(err).Msg("ConnectBunker failed")
+		log.Error().Err(err).Msg("ConnectBunker failed")
 		return nil, fmt.Errorf("failed to connect to bunker: %w", err)
 	}
 
-	logger.Log.Info().Msg("bunker connected successfully")
-	fmt.Println("Connected to bunker successfully!")
-	fmt.Println()
-	return &Client{bunker: bunker}, nil
+	log.Info().Msg("bunker connected successfully")
+	fmt.Fprintln(out, "Connected to bunker successfully!")
+	fmt.Fprintln(out)
+	return &Client{bunker: bunker, log: log}, nil
 }
 
 // DecryptNIP44 decrypts content using NIP-44
 func (c *Client) DecryptNIP44(ctx context.Context, senderPubkey, ciphertext string) (string, error) {
-	logger.Log.Debug().Str("sender", senderPubkey).Msg("sending NIP-44 decrypt request to bunker")
+	c.log.Debug().Str("sender", senderPubkey).Msg("sending NIP-44 decrypt request to bunker")
 
 	decryptCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	result, err := c.bunker.NIP44Decrypt(decryptCtx, senderPubkey, ciphertext)
 	if err != nil {
-		logger.Log.Error().
+		c.log.Error().
 			Err(err).
 			Str("sender", senderPubkey).
 			Bool("context_deadline_exceeded", ctx.Err() == context.DeadlineExceeded).
@@ -106,20 +148,20 @@ func (c *Client) DecryptNIP44(ctx context.Context, senderPubkey, ciphertext stri
 		return "", fmt.Errorf("NIP44 decrypt: %w", err)
 	}
 
-	logger.Log.Debug().Str("sender", senderPubkey).Msg("NIP-44 decrypt succeeded")
+	c.log.Debug().Str("sender", senderPubkey).Msg("NIP-44 decrypt succeeded")
 	return result, nil
 }
 
 // DecryptNIP04 decrypts content using NIP-04
 func (c *Client) DecryptNIP04(ctx context.Context, senderPubkey, ciphertext string) (string, error) {
-	logger.Log.Debug().Str("sender", senderPubkey).Msg("sending NIP-04 decrypt request to bunker")
+	c.log.Debug().Str("sender", senderPubkey).Msg("sending NIP-04 decrypt request to bunker")
 
 	decryptCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	result, err := c.bunker.NIP04Decrypt(decryptCtx, senderPubkey, ciphertext)
 	if err != nil {
-		logger.Log.Error().
+		c.log.Error().
 			Err(err).
 			Str("sender", senderPubkey).
 			Bool("context_deadline_exceeded", ctx.Err() == context.DeadlineExceeded).
@@ -127,38 +169,42 @@ func (c *Client) DecryptNIP04(ctx context.Context, senderPubkey, ciphertext stri
 		return "", fmt.Errorf("NIP04 decrypt: %w", err)
 	}
 
-	logger.Log.Debug().Str("sender", senderPubkey).Msg("NIP-04 decrypt succeeded")
+	c.log.Debug().Str("sender", senderPubkey).Msg("NIP-04 decrypt succeeded")
 	return result, nil
 }
 
 // GetPublicKey gets the bunker's public key
 func (c *Client) GetPublicKey(ctx context.Context) (string, error) {
-	logger.Log.Debug().Msg("requesting public key from bunker")
+	c.log.Debug().Msg("requesting public key from bunker")
 
 	getPkCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	pubkey, err := c.bunker.GetPublicKey(getPkCtx)
 	if err != nil {
-		logger.Log.Error().Err(err).Msg("failed to get public key from bunker")
+		c.log.Error().Err(err).Msg("failed to get public key from bunker")
 		return "", err
 	}
 
-	logger.Log.Info().Str("pubkey", pubkey).Msg("got public key from bunker")
+	c.log.Info().Str("pubkey", pubkey).Msg("got public key from bunker")
 	return pubkey, nil
 }
 
-// SignEvent signs an event using the remote signer
+// SignEvent signs an event using the remote signer. Callers that set their
+// own deadline on ctx get it as is, otherwise signing times out after 60s.
 func (c *Client) SignEvent(ctx context.Context, event *nostr.Event) error {
-	logger.Log.Debug().
+	c.log.Debug().
 		Str("event_id", event.ID).
 		Int("kind", event.Kind).
 		Msg("sending sign request to bunker")
 
-	signCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	signCtx, cancel := ctx, context.CancelFunc(func() {})
+	if _, ok := ctx.Deadline(); !ok {
+		signCtx, cancel = context.WithTimeout(ctx, 60*time.Second)
+	}
 	defer cancel()
 	if err := c.bunker.SignEvent(signCtx, event); err != nil {
-		logger.Log.Error().
+		c.log.Error().
 			Err(err).
 			Str("event_id", event.ID).
 			Int("kind", event.Kind).
@@ -166,6 +212,6 @@ func (c *Client) SignEvent(ctx context.Context, event *nostr.Event) error {
 		return err
 	}
 
-	logger.Log.Debug().Str("event_id", event.ID).Msg("event signed successfully")
+	c.log.Debug().Str("event_id", event.ID).Msg("event signed successfully")
 	return nil
 }
@@ -0,0 +1,8 @@
+package bunker
+
+import "errors"
+
+// ErrInvalidPubkey means the signer answered get_public_key with something
+// that is not a 64-character hex pubkey (some signers send "" right after
+// connecting)
+var ErrInvalidPubkey = errors.New("signer returned an invalid pubkey")
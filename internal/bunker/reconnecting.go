@@ -1,14 +1,19 @@
 package bunker
 
 import (
+	"cmp"
 	"context"
 	"errors"
+	"fmt"
+	"math/rand/v2"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/mistic0xb/pekka/internal/logger"
 	"github.com/nbd-wtf/go-nostr"
+	"github.com/rs/zerolog"
 )
 
 type ReconnectingClient struct {
@@ -18,10 +23,41 @@ type ReconnectingClient struct {
 	bunkerURL   string
 	pool        *nostr.SimplePool
 	botCtx      context.Context
+	onAuth      AuthHandler
+	slots       chan struct{} // bounds concurrent signer requests, nil if unbounded
+	log         *zerolog.Logger
+
+	keepaliveInterval time.Duration
+	keepaliveJitter   time.Duration
+
+	reconnects    atomic.Int64
+	lastReconnect atomic.Int64 // unix seconds, 0 if never reconnected
+}
+
+// ReconnectStats summarizes bunker session drops
+type ReconnectStats struct {
+	Count int64
+	Last  time.Time // zero if never reconnected
 }
 
-func NewReconnectingClient(botCtx context.Context, bunkerURL string, pool *nostr.SimplePool) (*ReconnectingClient, error) {
-	client, err := NewClient(botCtx, bunkerURL, pool)
+// Options tune a ReconnectingClient
+type Options struct {
+	OnAuth        AuthHandler // called with the auth URL when approval is needed (nil prints it)
+	MaxConcurrent int         // requests in flight to the signer at once, others queue (0 = unlimited)
+
+	KeepaliveInterval time.Duration // how often the session is checked (0 = DefaultKeepaliveInterval)
+	KeepaliveJitter   time.Duration // random extra wait added to each check (0 = a tenth of the interval)
+}
+
+// DefaultKeepaliveInterval is used when Options.KeepaliveInterval is not set
+const DefaultKeepaliveInterval = 4 * time.Hour
+
+// keepaliveProbeTimeout bounds the get_public_key request that checks
+// whether the session still answers
+const keepaliveProbeTimeout = 30 * time.Second
+
+func NewReconnectingClient(botCtx context.Context, bunkerURL string, pool *nostr.SimplePool, opts Options) (*ReconnectingClient, error) {
+	client, err := NewClient(botCtx, bunkerURL, pool, opts.OnAuth)
 	if err != nil {
 		return nil, err
 	}
@@ -31,6 +67,17 @@ func NewReconnectingClient(botCtx context.Context, bunkerURL string, pool *nostr
 		bunkerURL: bunkerURL,
 		pool:      pool,
 		botCtx:    botCtx,
+		onAuth:    opts.OnAuth,
+		log:       logger.Ctx(botCtx),
+
+		keepaliveInterval: cmp.Or(opts.KeepaliveInterval, DefaultKeepaliveInterval),
+		keepaliveJitter:   opts.KeepaliveJitter,
+	}
+	if rc.keepaliveJitter == 0 {
+		rc.keepaliveJitter = rc.keepaliveInterval / 10
+	}
+	if opts.MaxConcurrent > 0 {
+		rc.slots = make(chan struct{}, opts.MaxConcurrent)
 	}
 
 	rc.startKeepalive()
@@ -41,35 +88,107 @@ func (rc *ReconnectingClient) reconnect() error {
 	rc.reconnectMu.Lock()
 	defer rc.reconnectMu.Unlock()
 
-	logger.Log.Info().Msg("reconnecting bunker client")
-	client, err := NewClient(rc.botCtx, rc.bunkerURL, rc.pool)
+	rc.log.Info().Msg("reconnecting bunker client")
+	client, err := NewClient(rc.botCtx, rc.bunkerURL, rc.pool, rc.onAuth)
 	if err != nil {
-		logger.Log.Error().Err(err).Msg("bunker reconnect failed")
+		rc.log.Error().Err(err).Msg("bunker reconnect failed")
 		return err
 	}
 	rc.mu.Lock()
 	rc.client = client
 	rc.mu.Unlock()
-	logger.Log.Info().Msg("bunker reconnected successfully")
+
+	count := rc.reconnects.Add(1)
+	rc.lastReconnect.Store(time.Now().Unix())
+	rc.log.Info().Int64("reconnect_count", count).Msg("bunker reconnected successfully")
 	return nil
 }
 
+// Stats returns how often the bunker session has been re-established
+func (rc *ReconnectingClient) Stats() ReconnectStats {
+	stats := ReconnectStats{Count: rc.reconnects.Load()}
+	if last := rc.lastReconnect.Load(); last > 0 {
+		stats.Last = time.Unix(last, 0)
+	}
+	return stats
+}
+
+// startKeepalive checks the session every keepalive interval plus a random
+// jitter, so bots sharing a signer relay drift apart instead of reconnecting
+// in sync, and only reconnects when the signer stopped answering
 func (rc *ReconnectingClient) startKeepalive() {
 	go func() {
-		ticker := time.NewTicker(4 * time.Hour)
-		defer ticker.Stop()
 		for {
+			wait := rc.keepaliveInterval
+			if rc.keepaliveJitter > 0 {
+				wait += rand.N(rc.keepaliveJitter)
+			}
+
+			timer := time.NewTimer(wait)
 			select {
 			case <-rc.botCtx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			err := rc.probe()
+			if err == nil {
+				rc.log.Debug().Msg("bunker keepalive: session answering")
+				continue
+			}
+			if rc.botCtx.Err() != nil {
 				return
-			case <-ticker.C:
-				logger.Log.Info().Msg("proactive bunker keepalive reconnect")
-				rc.reconnect()
 			}
+
+			rc.log.Info().Err(err).Msg("bunker keepalive: session not answering, reconnecting")
+			rc.reconnect()
 		}
 	}()
 }
 
+// probe asks the current session for our pubkey to see whether it still
+// answers, without the reconnect GetPublicKey would do on failure
+func (rc *ReconnectingClient) probe() error {
+	ctx, cancel := context.WithTimeout(rc.botCtx, keepaliveProbeTimeout)
+	defer cancel()
+
+	if err := rc.acquire(ctx); err != nil {
+		return err
+	}
+	defer rc.release()
+
+	_, err := rc.getClient().GetPublicKey(ctx)
+	return err
+}
+
+// acquire waits for a free request slot; release must follow a nil error
+func (rc *ReconnectingClient) acquire(ctx context.Context) error {
+	if rc.slots == nil {
+		return nil
+	}
+
+	select {
+	case rc.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	rc.log.Debug().Int("max_concurrent", cap(rc.slots)).Msg("bunker busy, queueing request")
+	select {
+	case rc.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (rc *ReconnectingClient) release() {
+	if rc.slots != nil {
+		<-rc.slots
+	}
+}
+
 func (rc *ReconnectingClient) getClient() *Client {
 	rc.mu.RLock()
 	defer rc.mu.RUnlock()
@@ -91,10 +210,17 @@ func isSessionError(err error) bool {
 		strings.Contains(errStr, "connection refused")
 }
 
-// SignEvent - reconnects once on session error
+// SignEvent - reconnects once on session error. Running out the caller's own
+// deadline is not one: the request may still be awaiting approval on the
+// signer, and a new session would orphan it.
 func (rc *ReconnectingClient) SignEvent(ctx context.Context, event *nostr.Event) error {
+	if err := rc.acquire(ctx); err != nil {
+		return err
+	}
+	defer rc.release()
+
 	err := rc.getClient().SignEvent(ctx, event)
-	if err != nil && isSessionError(err) {
+	if err != nil && isSessionError(err) && ctx.Err() == nil {
 		if reconnErr := rc.reconnect(); reconnErr != nil {
 			return err
 		}
@@ -104,17 +230,48 @@ func (rc *ReconnectingClient) SignEvent(ctx context.Context, event *nostr.Event)
 }
 
 func (rc *ReconnectingClient) GetPublicKey(ctx context.Context) (string, error) {
+	if err := rc.acquire(ctx); err != nil {
+		return "", err
+	}
+	defer rc.release()
+
 	pubkey, err := rc.getClient().GetPublicKey(ctx)
+	if err == nil && !nostr.IsValidPublicKey(pubkey) {
+		// A fresh session usually answers properly
+		rc.log.Warn().Str("pubkey", pubkey).Msg("bunker returned an invalid pubkey, reconnecting")
+		err = fmt.Errorf("%w: %q", ErrInvalidPubkey, pubkey)
+		if reconnErr := rc.reconnect(); reconnErr != nil {
+			return "", err
+		}
+		return rc.checkedPublicKey(ctx)
+	}
 	if err != nil && isSessionError(err) {
 		if reconnErr := rc.reconnect(); reconnErr != nil {
 			return "", err
 		}
-		return rc.getClient().GetPublicKey(ctx)
+		return rc.checkedPublicKey(ctx)
 	}
 	return pubkey, err
 }
 
+// checkedPublicKey asks the current session for the pubkey without retrying
+func (rc *ReconnectingClient) checkedPublicKey(ctx context.Context) (string, error) {
+	pubkey, err := rc.getClient().GetPublicKey(ctx)
+	if err != nil {
+		return "", err
+	}
+	if !nostr.IsValidPublicKey(pubkey) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidPubkey, pubkey)
+	}
+	return pubkey, nil
+}
+
 func (rc *ReconnectingClient) DecryptNIP44(ctx context.Context, senderPubkey, ciphertext string) (string, error) {
+	if err := rc.acquire(ctx); err != nil {
+		return "", err
+	}
+	defer rc.release()
+
 	result, err := rc.getClient().DecryptNIP44(ctx, senderPubkey, ciphertext)
 	if err != nil && isSessionError(err) {
 		if reconnErr := rc.reconnect(); reconnErr != nil {
@@ -126,6 +283,11 @@ func (rc *ReconnectingClient) DecryptNIP44(ctx context.Context, senderPubkey, ci
 }
 
 func (rc *ReconnectingClient) DecryptNIP04(ctx context.Context, senderPubkey, ciphertext string) (string, error) {
+	if err := rc.acquire(ctx); err != nil {
+		return "", err
+	}
+	defer rc.release()
+
 	result, err := rc.getClient().DecryptNIP04(ctx, senderPubkey, ciphertext)
 	if err != nil && isSessionError(err) {
 		if reconnErr := rc.reconnect(); reconnErr != nil {
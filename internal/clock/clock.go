@@ -0,0 +1,111 @@
+package clock
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/mistic0xb/pekka/internal/logger"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// MaxOffset bounds the correction applied to event timestamps. A larger
+// skew more likely comes from a relay misreporting its Date header, and
+// events that far off would be rejected anyway.
+const MaxOffset = time.Hour
+
+// Clock is the time used for the event timestamps one bot creates: the
+// system clock plus a correction. The zero value is the system clock.
+type Clock struct {
+	offset atomic.Int64
+}
+
+// SetOffset sets the correction, clamped to within MaxOffset either way, and
+// returns the correction in effect
+func (c *Clock) SetOffset(d time.Duration) time.Duration {
+	d = min(max(d, -MaxOffset), MaxOffset)
+	c.offset.Store(int64(d))
+	return d
+}
+
+// Now returns the corrected current time as a nostr timestamp
+func (c *Clock) Now() nostr.Timestamp {
+	return nostr.Timestamp(time.Now().Add(time.Duration(c.offset.Load())).Unix())
+}
+
+// system is used when a context carries no Clock
+var system Clock
+
+type ctxKey struct{}
+
+// WithContext returns a copy of ctx carrying c, so each bot embedded in one
+// process corrects its own timestamps
+func WithContext(ctx context.Context, c *Clock) context.Context {
+	return context.WithValue(ctx, ctxKey{}, c)
+}
+
+// Ctx returns the Clock carried by ctx, or the uncorrected system clock
+func Ctx(ctx context.Context) *Clock {
+	if c, ok := ctx.Value(ctxKey{}).(*Clock); ok {
+		return c
+	}
+	return &system
+}
+
+// MeasureSkew estimates how far the relays' clocks are ahead of ours, using
+// the HTTP Date header each relay returns. A positive result means our
+// clock is behind.
+func MeasureSkew(ctx context.Context, relays []string) (time.Duration, error) {
+	samples := make([]time.Duration, 0, len(relays))
+
+	for _, relayURL := range relays {
+		skew, err := relaySkew(ctx, relayURL)
+		if err != nil {
+			logger.Ctx(ctx).Debug().Err(err).Str("relay", relayURL).Msg("could not measure relay clock")
+			continue
+		}
+		samples = append(samples, skew)
+	}
+
+	if len(samples) == 0 {
+		return 0, fmt.Errorf("no relay returned a usable Date header")
+	}
+
+	// Median is robust to a single relay with a bad clock
+	slices.Sort(samples)
+	return samples[len(samples)/2], nil
+}
+
+// relaySkew compares one relay's HTTP Date header against the local clock
+func relaySkew(ctx context.Context, relayURL string) (time.Duration, error) {
+	httpURL := strings.Replace(strings.Replace(relayURL, "wss://", "https://", 1), "ws://", "http://", 1)
+
+	reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodHead, httpURL, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	received := time.Now()
+
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid Date header: %w", err)
+	}
+
+	// Compare against the midpoint of the round trip
+	local := sent.Add(received.Sub(sent) / 2)
+	return date.Sub(local), nil
+}
@@ -0,0 +1,33 @@
+package clock
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSetOffsetClamps(t *testing.T) {
+	var c Clock
+	if got := c.SetOffset(48 * time.Hour); got != MaxOffset {
+		t.Errorf("SetOffset(48h) = %s, want %s", got, MaxOffset)
+	}
+	if got := c.SetOffset(-48 * time.Hour); got != -MaxOffset {
+		t.Errorf("SetOffset(-48h) = %s, want %s", got, -MaxOffset)
+	}
+	if got := c.SetOffset(-5 * time.Minute); got != -5*time.Minute {
+		t.Errorf("SetOffset(-5m) = %s, want -5m", got)
+	}
+}
+
+func TestClocksAreIndependent(t *testing.T) {
+	a, b := &Clock{}, &Clock{}
+	a.SetOffset(MaxOffset)
+
+	ctx := WithContext(context.Background(), b)
+	if drift := Ctx(ctx).Now().Time().Sub(time.Now()).Abs(); drift > time.Minute {
+		t.Errorf("offset on one clock moved another by %s", drift)
+	}
+	if Ctx(context.Background()) != &system {
+		t.Error("Ctx without a clock should return the system clock")
+	}
+}
@@ -1,24 +1,42 @@
 package db
 
 import (
+	"cmp"
 	"database/sql"
+	"errors"
 	"fmt"
+	"net/url"
 	"time"
 
 	_ "modernc.org/sqlite"
 )
 
+// Zap statuses. Zaps are confirmed when recorded unless zap.confirm_window
+// asks for the payment to be verified first. A pending zap that could not be
+// verified in time is failed, not deleted: the payment may still have gone
+// through, so it keeps counting toward budgets.
+const (
+	StatusConfirmed = "confirmed"
+	StatusPending   = "pending"
+	StatusFailed    = "failed"
+)
+
 type DB struct {
-	conn *sql.DB
+	conn  *sql.DB
+	table string
+	path  string
+	lock  *runLock // held while a bot runs, see LockForRun
 }
 
 // ZappedEvent represents a record of a zapped event
 type ZappedEvent struct {
-	EventID       string
-	AuthorPubkey  string
-	ZappedAt      int64
-	Amount        int
+	EventID        string
+	AuthorPubkey   string
+	ZappedAt       int64
+	Amount         int
 	EventCreatedAt int64
+	Invoice        string // bolt11 paid for this zap, empty for older rows
+	Status         string // StatusConfirmed, StatusPending or StatusFailed
 }
 
 // Open opens/creates the SQLite database
@@ -33,7 +51,7 @@ func Open(path string) (*DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	db := &DB{conn: conn}
+	db := &DB{conn: conn, table: "zapped_events", path: path}
 
 	// Initialize schema
 	if err := db.initSchema(); err != nil {
@@ -45,7 +63,24 @@ func Open(path string) (*DB, error) {
 
 // Close closes the database connection
 func (db *DB) Close() error {
-	return db.conn.Close()
+	err := db.conn.Close()
+	if db.lock != nil {
+		err = errors.Join(err, db.lock.release())
+		db.lock = nil
+	}
+	return err
+}
+
+// Shadow returns a view of the database that reads and writes the
+// shadow_zaps table instead of zapped_events. It shares the underlying
+// connection, so only the original DB should be closed.
+func (db *DB) Shadow() *DB {
+	return &DB{conn: db.conn, table: "shadow_zaps", path: db.path}
+}
+
+// IsShadow reports whether this view records shadow zaps
+func (db *DB) IsShadow() bool {
+	return db.table == "shadow_zaps"
 }
 
 // initSchema creates tables if they don't exist
@@ -62,6 +97,30 @@ func (db *DB) initSchema() error {
 	CREATE INDEX IF NOT EXISTS idx_author ON zapped_events(author_pubkey);
 	CREATE INDEX IF NOT EXISTS idx_zapped_at ON zapped_events(zapped_at);
 	CREATE INDEX IF NOT EXISTS idx_event_created_at ON zapped_events(event_created_at);
+
+	CREATE TABLE IF NOT EXISTS shadow_zaps (
+		event_id TEXT PRIMARY KEY,
+		author_pubkey TEXT NOT NULL,
+		zapped_at INTEGER NOT NULL,
+		amount INTEGER NOT NULL,
+		event_created_at INTEGER NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_shadow_author ON shadow_zaps(author_pubkey);
+	CREATE INDEX IF NOT EXISTS idx_shadow_zapped_at ON shadow_zaps(zapped_at);
+
+	CREATE TABLE IF NOT EXISTS zap_receipts (
+		event_id TEXT PRIMARY KEY,
+		zap_request_id TEXT NOT NULL,
+		receipt_id TEXT NOT NULL,
+		bolt11 TEXT NOT NULL,
+		received_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS bot_state (
+		key TEXT PRIMARY KEY,
+		value INTEGER NOT NULL
+	);
 	`
 
 	_, err := db.conn.Exec(schema)
@@ -69,14 +128,76 @@ func (db *DB) initSchema() error {
 		return fmt.Errorf("failed to create schema: %w", err)
 	}
 
+	return db.migrate()
+}
+
+// migrate adds columns introduced after the initial schema
+func (db *DB) migrate() error {
+	for _, table := range []string{"zapped_events", "shadow_zaps"} {
+		if err := db.addColumnIfMissing(table, "invoice", "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+		if err := db.addColumnIfMissing(table, "status", "TEXT NOT NULL DEFAULT 'confirmed'"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addColumnIfMissing adds a column to an existing table unless present
+func (db *DB) addColumnIfMissing(table, column, definition string) error {
+	columns, err := tableColumns(db.conn, table)
+	if err != nil {
+		return err
+	}
+	if columns[column] {
+		return nil
+	}
+
+	_, err = db.conn.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, definition))
+	if err != nil {
+		return fmt.Errorf("failed to add %s.%s: %w", table, column, err)
+	}
+
 	return nil
 }
 
+// tableColumns returns the names of a table's columns, empty if the table
+// does not exist
+func tableColumns(conn *sql.DB, table string) (map[string]bool, error) {
+	rows, err := conn.Query(fmt.Sprintf(`PRAGMA table_info(%s)`, table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return nil, fmt.Errorf("failed to scan %s columns: %w", table, err)
+		}
+		columns[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating %s columns: %w", table, err)
+	}
+
+	return columns, nil
+}
+
 // IsZapped checks if an event has already been zapped
 func (db *DB) IsZapped(eventID string) (bool, error) {
 	var exists bool
-	query := `SELECT EXISTS(SELECT 1 FROM zapped_events WHERE event_id = ?)`
-	
+	query := fmt.Sprintf(`SELECT EXISTS(SELECT 1 FROM %s WHERE event_id = ?)`, db.table)
+
 	err := db.conn.QueryRow(query, eventID).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("failed to check if zapped: %w", err)
@@ -86,15 +207,196 @@ func (db *DB) IsZapped(eventID string) (bool, error) {
 }
 
 // MarkZapped records that an event has been zapped
-func (db *DB) MarkZapped(eventID, authorPubkey string, amount int, eventCreatedAt int64) error {
+func (db *DB) MarkZapped(eventID, authorPubkey string, amount int, eventCreatedAt int64, invoice string) error {
+	return db.insertZap(eventID, authorPubkey, amount, eventCreatedAt, invoice, StatusConfirmed)
+}
+
+// MarkPending records a zap whose payment still has to be verified. It
+// counts as zapped, and against budgets, whether ConfirmZap or FailZap
+// settles it later.
+func (db *DB) MarkPending(eventID, authorPubkey string, amount int, eventCreatedAt int64, invoice string) error {
+	return db.insertZap(eventID, authorPubkey, amount, eventCreatedAt, invoice, StatusPending)
+}
+
+func (db *DB) insertZap(eventID, authorPubkey string, amount int, eventCreatedAt int64, invoice, status string) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (event_id, author_pubkey, zapped_at, amount, event_created_at, invoice, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, db.table)
+
+	_, err := db.conn.Exec(query, eventID, authorPubkey, time.Now().Unix(), amount, eventCreatedAt, invoice, status)
+	if err != nil {
+		return fmt.Errorf("failed to mark as zapped: %w", err)
+	}
+
+	return nil
+}
+
+// ConfirmZap promotes a pending zap to confirmed
+func (db *DB) ConfirmZap(eventID string) error {
+	query := fmt.Sprintf(`UPDATE %s SET status = ? WHERE event_id = ? AND status = ?`, db.table)
+
+	if _, err := db.conn.Exec(query, StatusConfirmed, eventID, StatusPending); err != nil {
+		return fmt.Errorf("failed to confirm zap: %w", err)
+	}
+
+	return nil
+}
+
+// FailZap marks a pending zap that could not be verified as failed. The row
+// is kept for reconciliation since the payment may still have settled.
+func (db *DB) FailZap(eventID string) error {
+	query := fmt.Sprintf(`UPDATE %s SET status = ? WHERE event_id = ? AND status = ?`, db.table)
+
+	if _, err := db.conn.Exec(query, StatusFailed, eventID, StatusPending); err != nil {
+		return fmt.Errorf("failed to mark zap as failed: %w", err)
+	}
+
+	return nil
+}
+
+// GetPendingZaps returns the zaps still waiting for verification, oldest first
+func (db *DB) GetPendingZaps() ([]ZappedEvent, error) {
+	query := fmt.Sprintf(`
+		SELECT event_id, author_pubkey, zapped_at, amount, event_created_at, invoice, status
+		FROM %s
+		WHERE status = ?
+		ORDER BY zapped_at ASC
+	`, db.table)
+
+	rows, err := db.conn.Query(query, StatusPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending zaps: %w", err)
+	}
+	defer rows.Close()
+
+	return scanZaps(rows)
+}
+
+// HasReceipt reports whether a zap receipt was stored for the event
+func (db *DB) HasReceipt(eventID string) (bool, error) {
+	var exists bool
+	err := db.conn.QueryRow(`SELECT EXISTS(SELECT 1 FROM zap_receipts WHERE event_id = ?)`, eventID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check zap receipt: %w", err)
+	}
+
+	return exists, nil
+}
+
+// ImportZap inserts a zap recorded elsewhere, keeping its original
+// zapped_at and status (confirmed when unset). It reports false when the
+// event is already recorded.
+func (db *DB) ImportZap(z ZappedEvent) (bool, error) {
+	query := fmt.Sprintf(`
+		INSERT OR IGNORE INTO %s (event_id, author_pubkey, zapped_at, amount, event_created_at, invoice, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, db.table)
+
+	status := cmp.Or(z.Status, StatusConfirmed)
+	result, err := db.conn.Exec(query, z.EventID, z.AuthorPubkey, z.ZappedAt, z.Amount, z.EventCreatedAt, z.Invoice, status)
+	if err != nil {
+		return false, fmt.Errorf("failed to import zap: %w", err)
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to import zap: %w", err)
+	}
+
+	return inserted > 0, nil
+}
+
+// ReadZaps reads every zap from the pekka database at path without touching
+// it: the file is opened read-only and no migrations run. Columns an older
+// database lacks read as their migration defaults.
+func ReadZaps(path string) ([]ZappedEvent, error) {
+	uri := (&url.URL{Scheme: "file", Path: path, RawQuery: "mode=ro"}).String()
+	conn, err := sql.Open("sqlite", uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer conn.Close()
+
+	columns, err := tableColumns(conn, "zapped_events")
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%s has no zapped_events table", path)
+	}
+
+	invoice, status := "''", fmt.Sprintf("'%s'", StatusConfirmed)
+	if columns["invoice"] {
+		invoice = "invoice"
+	}
+	if columns["status"] {
+		status = "status"
+	}
+
+	rows, err := conn.Query(fmt.Sprintf(`
+		SELECT event_id, author_pubkey, zapped_at, amount, event_created_at, %s, %s
+		FROM zapped_events
+		ORDER BY zapped_at ASC
+	`, invoice, status))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query zaps: %w", err)
+	}
+	defer rows.Close()
+
+	return scanZaps(rows)
+}
+
+// SaveReceipt stores the zap receipt (kind 9735) matching a zapped event
+func (db *DB) SaveReceipt(eventID, zapRequestID, receiptID, bolt11 string) error {
 	query := `
-		INSERT INTO zapped_events (event_id, author_pubkey, zapped_at, amount, event_created_at)
+		INSERT OR REPLACE INTO zap_receipts (event_id, zap_request_id, receipt_id, bolt11, received_at)
 		VALUES (?, ?, ?, ?, ?)
 	`
 
-	_, err := db.conn.Exec(query, eventID, authorPubkey, time.Now().Unix(), amount, eventCreatedAt)
+	_, err := db.conn.Exec(query, eventID, zapRequestID, receiptID, bolt11, time.Now().Unix())
 	if err != nil {
-		return fmt.Errorf("failed to mark as zapped: %w", err)
+		return fmt.Errorf("failed to save zap receipt: %w", err)
+	}
+
+	return nil
+}
+
+// lastSeenKey is the bot_state key of the catch-up watermark. Shadow runs
+// keep their own, so simulating notes never moves the live bot's start point.
+func (db *DB) lastSeenKey() string {
+	if db.IsShadow() {
+		return "shadow_last_seen"
+	}
+	return "last_seen"
+}
+
+// GetLastSeen returns the created_at of the newest note processed, 0 if none
+// has been recorded yet
+func (db *DB) GetLastSeen() (int64, error) {
+	var lastSeen int64
+	err := db.conn.QueryRow(`SELECT value FROM bot_state WHERE key = ?`, db.lastSeenKey()).Scan(&lastSeen)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last seen timestamp: %w", err)
+	}
+
+	return lastSeen, nil
+}
+
+// SetLastSeen records the created_at of the latest note processed. The
+// watermark only moves forward: relays deliver notes out of order, and an
+// older note arriving late must not make the next catch-up start earlier.
+func (db *DB) SetLastSeen(createdAt int64) error {
+	query := `
+		INSERT INTO bot_state (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = MAX(value, excluded.value)
+	`
+
+	if _, err := db.conn.Exec(query, db.lastSeenKey(), createdAt); err != nil {
+		return fmt.Errorf("failed to set last seen timestamp: %w", err)
 	}
 
 	return nil
@@ -106,7 +408,7 @@ func (db *DB) GetTodayTotal() (int, error) {
 	today := time.Now().UTC().Truncate(24 * time.Hour).Unix()
 
 	var total sql.NullInt64
-	query := `SELECT SUM(amount) FROM zapped_events WHERE zapped_at >= ?`
+	query := fmt.Sprintf(`SELECT SUM(amount) FROM %s WHERE zapped_at >= ?`, db.table)
 
 	err := db.conn.QueryRow(query, today).Scan(&total)
 	if err != nil {
@@ -125,7 +427,7 @@ func (db *DB) GetTodayTotalForAuthor(pubkey string) (int, error) {
 	today := time.Now().UTC().Truncate(24 * time.Hour).Unix()
 
 	var total sql.NullInt64
-	query := `SELECT SUM(amount) FROM zapped_events WHERE author_pubkey = ? AND zapped_at >= ?`
+	query := fmt.Sprintf(`SELECT SUM(amount) FROM %s WHERE author_pubkey = ? AND zapped_at >= ?`, db.table)
 
 	err := db.conn.QueryRow(query, pubkey, today).Scan(&total)
 	if err != nil {
@@ -139,24 +441,60 @@ func (db *DB) GetTodayTotalForAuthor(pubkey string) (int, error) {
 	return int(total.Int64), nil
 }
 
+// CountDistinctAuthorsToday returns how many different authors were zapped today
+func (db *DB) CountDistinctAuthorsToday() (int, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour).Unix()
+
+	var count int
+	query := fmt.Sprintf(`SELECT COUNT(DISTINCT author_pubkey) FROM %s WHERE zapped_at >= ?`, db.table)
+
+	err := db.conn.QueryRow(query, today).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count today's authors: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetLastZappedEventTime returns the created_at of the newest note zapped for
+// an author, or 0 if none was
+func (db *DB) GetLastZappedEventTime(pubkey string) (int64, error) {
+	var latest sql.NullInt64
+	query := fmt.Sprintf(`SELECT MAX(event_created_at) FROM %s WHERE author_pubkey = ?`, db.table)
+
+	err := db.conn.QueryRow(query, pubkey).Scan(&latest)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get author's last zapped note: %w", err)
+	}
+
+	return latest.Int64, nil
+}
+
+// GetTotalSpent returns the sats zapped over all time
+func (db *DB) GetTotalSpent() (int, error) {
+	var total sql.NullInt64
+	err := db.conn.QueryRow(fmt.Sprintf(`SELECT SUM(amount) FROM %s`, db.table)).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get total sats: %w", err)
+	}
+
+	return int(total.Int64), nil
+}
+
 // GetStats returns overall statistics
 func (db *DB) GetStats() (*Stats, error) {
 	stats := &Stats{}
 
 	// Total events zapped
-	err := db.conn.QueryRow(`SELECT COUNT(*) FROM zapped_events`).Scan(&stats.TotalZapped)
+	err := db.conn.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM %s`, db.table)).Scan(&stats.TotalZapped)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get total count: %w", err)
 	}
 
 	// Total sats spent (all time)
-	var totalSats sql.NullInt64
-	err = db.conn.QueryRow(`SELECT SUM(amount) FROM zapped_events`).Scan(&totalSats)
+	stats.TotalSats, err = db.GetTotalSpent()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get total sats: %w", err)
-	}
-	if totalSats.Valid {
-		stats.TotalSats = int(totalSats.Int64)
+		return nil, err
 	}
 
 	// Today's total
@@ -166,7 +504,7 @@ func (db *DB) GetStats() (*Stats, error) {
 	}
 
 	// Count of unique authors zapped
-	err = db.conn.QueryRow(`SELECT COUNT(DISTINCT author_pubkey) FROM zapped_events`).Scan(&stats.UniqueAuthors)
+	err = db.conn.QueryRow(fmt.Sprintf(`SELECT COUNT(DISTINCT author_pubkey) FROM %s`, db.table)).Scan(&stats.UniqueAuthors)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get unique authors: %w", err)
 	}
@@ -176,12 +514,12 @@ func (db *DB) GetStats() (*Stats, error) {
 
 // GetRecentZaps returns the N most recent zaps
 func (db *DB) GetRecentZaps(limit int) ([]ZappedEvent, error) {
-	query := `
-		SELECT event_id, author_pubkey, zapped_at, amount, event_created_at
-		FROM zapped_events
+	query := fmt.Sprintf(`
+		SELECT event_id, author_pubkey, zapped_at, amount, event_created_at, invoice, status
+		FROM %s
 		ORDER BY zapped_at DESC
 		LIMIT ?
-	`
+	`, db.table)
 
 	rows, err := db.conn.Query(query, limit)
 	if err != nil {
@@ -189,10 +527,33 @@ func (db *DB) GetRecentZaps(limit int) ([]ZappedEvent, error) {
 	}
 	defer rows.Close()
 
+	return scanZaps(rows)
+}
+
+// GetZapsSince returns all zaps made at or after the given unix time
+func (db *DB) GetZapsSince(since int64) ([]ZappedEvent, error) {
+	query := fmt.Sprintf(`
+		SELECT event_id, author_pubkey, zapped_at, amount, event_created_at, invoice, status
+		FROM %s
+		WHERE zapped_at >= ?
+		ORDER BY zapped_at ASC
+	`, db.table)
+
+	rows, err := db.conn.Query(query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query zaps: %w", err)
+	}
+	defer rows.Close()
+
+	return scanZaps(rows)
+}
+
+// scanZaps reads ZappedEvent rows
+func scanZaps(rows *sql.Rows) ([]ZappedEvent, error) {
 	var zaps []ZappedEvent
 	for rows.Next() {
 		var z ZappedEvent
-		err := rows.Scan(&z.EventID, &z.AuthorPubkey, &z.ZappedAt, &z.Amount, &z.EventCreatedAt)
+		err := rows.Scan(&z.EventID, &z.AuthorPubkey, &z.ZappedAt, &z.Amount, &z.EventCreatedAt, &z.Invoice, &z.Status)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
@@ -212,4 +573,4 @@ type Stats struct {
 	TotalSats     int
 	TodayTotal    int
 	UniqueAuthors int
-}
\ No newline at end of file
+}
@@ -0,0 +1,145 @@
+package db
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func openTest(t *testing.T) *DB {
+	t.Helper()
+	database, err := Open(filepath.Join(t.TempDir(), "pekka.db"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+func TestSetLastSeenNeverMovesBackwards(t *testing.T) {
+	database := openTest(t)
+
+	for _, createdAt := range []int64{100, 300, 200, 50} {
+		if err := database.SetLastSeen(createdAt); err != nil {
+			t.Fatalf("SetLastSeen(%d): %v", createdAt, err)
+		}
+	}
+
+	got, err := database.GetLastSeen()
+	if err != nil {
+		t.Fatalf("GetLastSeen: %v", err)
+	}
+	if got != 300 {
+		t.Errorf("last seen = %d, want 300", got)
+	}
+}
+
+func TestShadowLastSeenIsSeparate(t *testing.T) {
+	database := openTest(t)
+
+	if err := database.SetLastSeen(100); err != nil {
+		t.Fatalf("SetLastSeen: %v", err)
+	}
+	if err := database.Shadow().SetLastSeen(500); err != nil {
+		t.Fatalf("shadow SetLastSeen: %v", err)
+	}
+
+	live, err := database.GetLastSeen()
+	if err != nil {
+		t.Fatalf("GetLastSeen: %v", err)
+	}
+	shadow, err := database.Shadow().GetLastSeen()
+	if err != nil {
+		t.Fatalf("shadow GetLastSeen: %v", err)
+	}
+	if live != 100 || shadow != 500 {
+		t.Errorf("live = %d, shadow = %d, want 100 and 500", live, shadow)
+	}
+}
+
+func TestReadZapsLeavesOldDatabaseUntouched(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	conn, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	_, err = conn.Exec(`
+		CREATE TABLE zapped_events (
+			event_id TEXT PRIMARY KEY,
+			author_pubkey TEXT NOT NULL,
+			zapped_at INTEGER NOT NULL,
+			amount INTEGER NOT NULL,
+			event_created_at INTEGER NOT NULL
+		);
+		INSERT INTO zapped_events VALUES ('note', 'author', 100, 21, 90);
+	`)
+	conn.Close()
+	if err != nil {
+		t.Fatalf("create old schema: %v", err)
+	}
+
+	zaps, err := ReadZaps(path)
+	if err != nil {
+		t.Fatalf("ReadZaps: %v", err)
+	}
+	if len(zaps) != 1 || zaps[0].EventID != "note" || zaps[0].Status != StatusConfirmed {
+		t.Fatalf("got %+v, want one confirmed zap of note", zaps)
+	}
+
+	conn, err = sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer conn.Close()
+	columns, err := tableColumns(conn, "zapped_events")
+	if err != nil {
+		t.Fatalf("tableColumns: %v", err)
+	}
+	if columns["invoice"] || columns["status"] {
+		t.Error("ReadZaps migrated the source database")
+	}
+	if _, err := os.Stat(LockPath(path)); err == nil {
+		t.Error("ReadZaps created a lock file beside the source")
+	}
+}
+
+func TestImportZapKeepsStatus(t *testing.T) {
+	database := openTest(t)
+
+	if _, err := database.ImportZap(ZappedEvent{EventID: "note", AuthorPubkey: "author", Amount: 21, Status: StatusPending}); err != nil {
+		t.Fatalf("ImportZap: %v", err)
+	}
+
+	pending, err := database.GetPendingZaps()
+	if err != nil {
+		t.Fatalf("GetPendingZaps: %v", err)
+	}
+	if len(pending) != 1 || pending[0].EventID != "note" {
+		t.Errorf("got pending %+v, want the imported zap", pending)
+	}
+}
+
+func TestFailZapKeepsTheRow(t *testing.T) {
+	database := openTest(t)
+
+	if err := database.MarkPending("note", "author", 21, 0, "lnbc210n1p"); err != nil {
+		t.Fatalf("MarkPending: %v", err)
+	}
+	if err := database.FailZap("note"); err != nil {
+		t.Fatalf("FailZap: %v", err)
+	}
+
+	zapped, err := database.IsZapped("note")
+	if err != nil || !zapped {
+		t.Errorf("IsZapped = %v, %v, want true: the payment may have gone through", zapped, err)
+	}
+	spent, err := database.GetTotalSpent()
+	if err != nil || spent != 21 {
+		t.Errorf("GetTotalSpent = %d, %v, want 21", spent, err)
+	}
+	pending, err := database.GetPendingZaps()
+	if err != nil || len(pending) != 0 {
+		t.Errorf("GetPendingZaps = %+v, %v, want none", pending, err)
+	}
+}
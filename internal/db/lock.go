@@ -0,0 +1,74 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// LockPath returns the lock file held beside the database at path while a
+// bot runs on it
+func LockPath(path string) string {
+	return path + ".lock"
+}
+
+// runLock is an advisory lock on LockPath. The OS drops it when the process
+// exits, so a crashed bot never leaves a stale lock behind.
+type runLock struct {
+	file *os.File
+}
+
+// acquireRunLock takes the lock for the database at path without waiting,
+// failing with ErrDatabaseBusy (naming the holder's pid) if it is taken
+func acquireRunLock(path string) (*runLock, error) {
+	file, err := os.OpenFile(LockPath(path), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		holder, _ := os.ReadFile(LockPath(path))
+		file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			if pid := strings.TrimSpace(string(holder)); pid != "" {
+				return nil, fmt.Errorf("%w (pid %s)", ErrDatabaseBusy, pid)
+			}
+			return nil, ErrDatabaseBusy
+		}
+		return nil, fmt.Errorf("failed to lock %s: %w", LockPath(path), err)
+	}
+
+	// The pid is informational, the flock is what other processes check
+	if err := file.Truncate(0); err == nil {
+		file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+
+	return &runLock{file: file}, nil
+}
+
+// release drops the lock. The file stays, an unlocked file means not in use.
+func (l *runLock) release() error {
+	if err := syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN); err != nil {
+		l.file.Close()
+		return fmt.Errorf("failed to unlock: %w", err)
+	}
+	return l.file.Close()
+}
+
+// LockForRun marks the database as in use by this process until Close, so
+// Maintain refuses to run underneath a running bot
+func (db *DB) LockForRun() error {
+	if db.lock != nil {
+		return nil
+	}
+
+	lock, err := acquireRunLock(db.path)
+	if err != nil {
+		return err
+	}
+	db.lock = lock
+	return nil
+}
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrDatabaseBusy is returned by Maintain when another process (usually a
+// running bot) holds the database
+var ErrDatabaseBusy = errors.New("database is in use by another process")
+
+// Maintain compacts the database file with VACUUM and refreshes the query
+// planner statistics with ANALYZE. It refuses to run while a bot holds the
+// database (see LockForRun), and holds the same lock itself so no bot can
+// start until it is done.
+func (db *DB) Maintain(ctx context.Context) error {
+	if db.lock == nil {
+		lock, err := acquireRunLock(db.path)
+		if err != nil {
+			return err
+		}
+		defer lock.release()
+	}
+
+	if _, err := db.conn.ExecContext(ctx, `VACUUM`); err != nil {
+		if isBusy(err) {
+			return ErrDatabaseBusy
+		}
+		return fmt.Errorf("failed to vacuum: %w", err)
+	}
+
+	if _, err := db.conn.ExecContext(ctx, `ANALYZE`); err != nil {
+		return fmt.Errorf("failed to analyze: %w", err)
+	}
+
+	return nil
+}
+
+// isBusy reports whether err is SQLite's BUSY or LOCKED error
+func isBusy(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "SQLITE_BUSY") || strings.Contains(msg, "SQLITE_LOCKED") ||
+		strings.Contains(msg, "database is locked")
+}
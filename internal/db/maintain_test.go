@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestMaintainRefusesWhileBotRuns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pekka.db")
+
+	running, err := Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := running.LockForRun(); err != nil {
+		t.Fatalf("LockForRun: %v", err)
+	}
+
+	maintenance, err := Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer maintenance.Close()
+
+	if err := maintenance.Maintain(context.Background()); !errors.Is(err, ErrDatabaseBusy) {
+		t.Fatalf("Maintain with a running bot = %v, want ErrDatabaseBusy", err)
+	}
+	if err := maintenance.LockForRun(); !errors.Is(err, ErrDatabaseBusy) {
+		t.Fatalf("second LockForRun = %v, want ErrDatabaseBusy", err)
+	}
+
+	if err := running.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if err := maintenance.Maintain(context.Background()); err != nil {
+		t.Fatalf("Maintain after the bot stopped: %v", err)
+	}
+}
+
+func TestMaintainShrinksFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pekka.db")
+	database, err := Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer database.Close()
+
+	for i := range 2000 {
+		id := strconv.Itoa(i)
+		if err := database.MarkZapped("event-"+id, "author-"+id, 21, int64(i), "lnbc"+id); err != nil {
+			t.Fatalf("MarkZapped: %v", err)
+		}
+	}
+	if _, err := database.conn.Exec(`DELETE FROM zapped_events`); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	before := fileSizeOf(t, path)
+	if err := database.Maintain(context.Background()); err != nil {
+		t.Fatalf("Maintain: %v", err)
+	}
+	after := fileSizeOf(t, path)
+
+	if after >= before {
+		t.Errorf("size after VACUUM = %d, want less than %d", after, before)
+	}
+}
+
+func fileSizeOf(t *testing.T, path string) int64 {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	return info.Size()
+}
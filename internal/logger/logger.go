@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"path"
 	"path/filepath"
@@ -12,20 +14,50 @@ import (
 
 var Log zerolog.Logger
 
-func Init() error {
-	logDir := "logs"
+// Dir is the directory Init writes logs to
+const Dir = "logs"
+
+// FilePath returns the path of the current (unrotated) log file
+func FilePath() string {
+	return filepath.Join(Dir, "logs.json")
+}
+
+type ctxKey struct{}
+
+// WithContext returns a copy of ctx carrying l. Everything running on behalf
+// of that context logs to l instead of Log, so several bots embedded in one
+// process keep separate logs.
+func WithContext(ctx context.Context, l *zerolog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// Ctx returns the logger carried by ctx, or Log when there is none
+func Ctx(ctx context.Context) *zerolog.Logger {
+	if l, ok := ctx.Value(ctxKey{}).(*zerolog.Logger); ok {
+		return l
+	}
+	return &Log
+}
 
+// Init logs to rotating JSON files in Dir. When Dir cannot be created or
+// written, it falls back to plaintext warnings and errors on stderr and
+// returns the reason, so logging never stops the bot from running.
+func Init() error {
 	zerolog.CallerMarshalFunc = func(pc uintptr, file string, line int) string {
 		return path.Base(file) + ":" + strconv.Itoa(line)
 	}
 
-	// Ensure logs/ dir exists
-	if err := os.MkdirAll(logDir, 0755); err != nil {
-		return err
+	if err := checkWritable(); err != nil {
+		Log = zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr, NoColor: true}).
+			Level(zerolog.WarnLevel).
+			With().
+			Timestamp().
+			Logger()
+		return fmt.Errorf("log directory %s is not writable: %w", Dir, err)
 	}
 
 	writer := &lumberjack.Logger{
-		Filename:   filepath.Join(logDir, "logs.json"),
+		Filename:   FilePath(),
 		MaxSize:    20, // MB
 		MaxBackups: 5,
 		MaxAge:     14, // days
@@ -40,3 +72,17 @@ func Init() error {
 
 	return nil
 }
+
+// checkWritable ensures Dir exists and the log file can be opened, which
+// lumberjack would otherwise only find out on the first write
+func checkWritable() error {
+	if err := os.MkdirAll(Dir, 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(FilePath(), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
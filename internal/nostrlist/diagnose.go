@@ -0,0 +1,80 @@
+package nostrlist
+
+import (
+	"context"
+	"time"
+
+	"github.com/mistic0xb/pekka/config"
+	"github.com/mistic0xb/pekka/internal/bunker"
+	"github.com/mistic0xb/pekka/internal/logger"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// DecryptAttempt is the outcome of decrypting a list's content with one scheme
+type DecryptAttempt struct {
+	Scheme   string // config.DecryptNIP44 or config.DecryptNIP04
+	Duration time.Duration
+	Err      error
+	Members  int  // valid 'p' tags in the plaintext
+	Invalid  int  // 'p' tags whose value is not a valid pubkey
+	Parsed   bool // the plaintext was a JSON tag array
+}
+
+// DiagnoseDecryption tries NIP-44 and NIP-04 independently on the list's
+// encrypted content, without the fallback the bot uses, so a broken scheme
+// shows up even when the other one works
+func DiagnoseDecryption(
+	ctx context.Context,
+	bunkerClient *bunker.ReconnectingClient,
+	event *nostr.Event,
+) []DecryptAttempt {
+
+	schemes := []struct {
+		name    string
+		decrypt func(context.Context, string, string) (string, error)
+	}{
+		{config.DecryptNIP44, bunkerClient.DecryptNIP44},
+		{config.DecryptNIP04, bunkerClient.DecryptNIP04},
+	}
+
+	attempts := make([]DecryptAttempt, 0, len(schemes))
+	for _, scheme := range schemes {
+		attempt := DecryptAttempt{Scheme: scheme.name}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		start := time.Now()
+		plaintext, err := scheme.decrypt(attemptCtx, event.PubKey, event.Content)
+		attempt.Duration = time.Since(start)
+		cancel()
+
+		if err != nil {
+			attempt.Err = err
+		} else if tags := parseDecryptedTags(ctx, plaintext); tags != nil {
+			attempt.Parsed = true
+			for _, tag := range tags {
+				if len(tag) < 2 || tag[0] != "p" {
+					continue
+				}
+				if _, err := encodeMember(tag[1]); err != nil {
+					attempt.Invalid++
+					continue
+				}
+				attempt.Members++
+			}
+		}
+
+		logger.Ctx(ctx).Info().
+			Str("scheme", attempt.Scheme).
+			Dur("duration", attempt.Duration).
+			AnErr("error", attempt.Err).
+			Bool("parsed", attempt.Parsed).
+			Int("members", attempt.Members).
+			Int("invalid", attempt.Invalid).
+			Msg("decryption attempt")
+
+		attempts = append(attempts, attempt)
+	}
+
+	return attempts
+}
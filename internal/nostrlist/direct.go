@@ -0,0 +1,20 @@
+package nostrlist
+
+// DirectListID identifies the monitored set when it comes from zap.direct_npubs
+const DirectListID = "direct"
+
+// DirectList wraps zap.direct_npubs in a PrivateList so the bot can monitor
+// them without fetching any list. Duplicates are dropped, order is kept.
+func DirectList(npubs []string) *PrivateList {
+	seen := make(map[string]bool, len(npubs))
+	members := make([]string, 0, len(npubs))
+	for _, npub := range npubs {
+		if seen[npub] {
+			continue
+		}
+		seen[npub] = true
+		members = append(members, npub)
+	}
+
+	return &PrivateList{ID: DirectListID, Title: "direct npubs", NPubs: members}
+}
@@ -0,0 +1,10 @@
+package nostrlist
+
+import "errors"
+
+// Sentinel errors for an empty list fetch, so callers can tell the user
+// whether their lists are missing or their relays are unreachable
+var (
+	ErrNoLists           = errors.New("relays responded but no lists were found")
+	ErrNoRelaysResponded = errors.New("no relay responded")
+)
@@ -0,0 +1,86 @@
+package nostrlist
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mistic0xb/pekka/internal/logger"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+// FetchFollows fetches the author's kind-3 contact list and returns the
+// followed pubkeys as npubs
+func FetchFollows(
+	ctx context.Context,
+	relayURLs []string,
+	authorNPub string,
+	pool *nostr.SimplePool,
+) ([]string, error) {
+
+	logger.Ctx(ctx).Info().
+		Str("author_npub", authorNPub).
+		Int("relay_count", len(relayURLs)).
+		Msg("fetching follow list")
+
+	prefix, pubkeyHex, err := nip19.Decode(authorNPub)
+	if err != nil {
+		logger.Ctx(ctx).Error().
+			Err(err).
+			Str("npub", authorNPub).
+			Msg("failed to decode npub")
+		return nil, fmt.Errorf("invalid npub: %w", err)
+	}
+
+	if prefix != "npub" {
+		return nil, fmt.Errorf("expected npub prefix, got %s", prefix)
+	}
+
+	filter := nostr.Filter{
+		Kinds:   []int{3},
+		Authors: []string{pubkeyHex.(string)},
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// Kind 3 is replaceable, keep only the newest version across relays
+	var newest *nostr.Event
+	for ev := range pool.FetchMany(ctx, relayURLs, filter) {
+		if newest == nil || ev.CreatedAt > newest.CreatedAt {
+			newest = ev.Event
+		}
+	}
+
+	if newest == nil {
+		logger.Ctx(ctx).Warn().Msg("no follow list found on any relay")
+		return nil, fmt.Errorf("no follow list (kind 3) found on relays")
+	}
+
+	npubSet := make(map[string]bool)
+	for _, tag := range newest.Tags {
+		if len(tag) >= 2 && tag[0] == "p" {
+			npub, err := nip19.EncodePublicKey(tag[1])
+			if err != nil {
+				logger.Ctx(ctx).Warn().
+					Err(err).
+					Str("hex", tag[1]).
+					Msg("failed to encode followed public key")
+				continue
+			}
+			npubSet[npub] = true
+		}
+	}
+
+	npubs := npubsFromSet(npubSet)
+
+	logger.Ctx(ctx).Info().
+		Str("event_id", newest.ID).
+		Time("created_at", time.Unix(int64(newest.CreatedAt), 0)).
+		Int("follow_count", len(npubs)).
+		Msg("loaded follow list")
+
+	return npubs, nil
+}
@@ -4,10 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
 	"time"
 
+	"github.com/mistic0xb/pekka/config"
 	"github.com/mistic0xb/pekka/internal/bunker"
 	"github.com/mistic0xb/pekka/internal/logger"
+	"github.com/mistic0xb/pekka/internal/relaycheck"
 
 	"github.com/nbd-wtf/go-nostr"
 	"github.com/nbd-wtf/go-nostr/nip19"
@@ -20,18 +24,39 @@ type PrivateList struct {
 	NPubs      []string
 	EventID    string
 	CreatedAt  int64
-	HasPrivate bool
+	HasPrivate bool // the list event carries encrypted (private) content
+
+	PrivateMembers int  // members read from the encrypted content
+	DecryptFailed  bool // the encrypted content could not be decrypted
+	PrivateSkipped bool // the encrypted content was not decrypted (list.public_only)
+	InvalidPublic  int  // public 'p' tags whose value is not a valid pubkey
+	InvalidPrivate int  // same, for the encrypted members
+}
+
+// PrivateUnreadable reports whether the list has encrypted content but no
+// private members came out of it, which usually means decryption is broken
+// rather than the private section being empty
+func (l *PrivateList) PrivateUnreadable() bool {
+	return l.HasPrivate && !l.PrivateSkipped && l.PrivateMembers == 0
+}
+
+// InvalidMembers returns the total number of malformed 'p' tags
+func (l *PrivateList) InvalidMembers() int {
+	return l.InvalidPublic + l.InvalidPrivate
 }
 
-// FetchPrivateLists fetches private lists for an author
+// FetchPrivateLists fetches private lists for an author. Cancelling ctx
+// aborts the fetch, retries and decryption.
 func FetchPrivateLists(
+	ctx context.Context,
 	relayURLs []string,
 	authorNPub string,
 	bunkerClient *bunker.ReconnectingClient,
 	pool *nostr.SimplePool,
+	listCfg *config.ListConfig,
 ) ([]*PrivateList, error) {
 
-	logger.Log.Info().
+	logger.Ctx(ctx).Info().
 		Str("author_npub", authorNPub).
 		Int("relay_count", len(relayURLs)).
 		Strs("relays", relayURLs).
@@ -40,7 +65,7 @@ func FetchPrivateLists(
 	// Decode npub to hex
 	prefix, pubkeyHex, err := nip19.Decode(authorNPub)
 	if err != nil {
-		logger.Log.Error().
+		logger.Ctx(ctx).Error().
 			Err(err).
 			Str("npub", authorNPub).
 			Msg("failed to decode npub")
@@ -48,7 +73,7 @@ func FetchPrivateLists(
 	}
 
 	if prefix != "npub" {
-		logger.Log.Error().
+		logger.Ctx(ctx).Error().
 			Str("prefix", prefix).
 			Str("expected", "npub").
 			Msg("unexpected nip19 prefix")
@@ -56,7 +81,7 @@ func FetchPrivateLists(
 	}
 
 	pubkeyHexStr := pubkeyHex.(string)
-	logger.Log.Info().
+	logger.Ctx(ctx).Info().
 		Str("pubkey_hex", pubkeyHexStr).
 		Msg("decoded npub to hex pubkey")
 
@@ -65,86 +90,218 @@ func FetchPrivateLists(
 		Authors: []string{pubkeyHexStr},
 	}
 
-	logger.Log.Info().
+	logger.Ctx(ctx).Info().
 		Int("kind", 30000).
 		Str("author", pubkeyHexStr).
 		Msg("created filter for kind 30000 (NIP-51 private lists)")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
-	defer cancel()
+	// Fail fast instead of waiting out every fetch attempt on a dead network
+	if _, err := relaycheck.Require(ctx, pool, relayURLs); err != nil {
+		logger.Ctx(ctx).Error().Err(err).Msg("no list relay reachable")
+		return nil, fmt.Errorf("%w: %w", ErrNoRelaysResponded, err)
+	}
 
-	events := make([]nostr.RelayEvent, 0)
-	relayStats := make(map[string]int)
+	backoff := listCfg.FetchBackoff()
+	var events []nostr.RelayEvent
+	for attempt := 0; ; attempt++ {
+		var answered int
+		events, answered = fetchListEvents(ctx, pool, relayURLs, filter)
+		if len(events) > 0 {
+			break
+		}
 
-	logger.Log.Info().Msg("connecting to relays and fetching events")
-	fetchStart := time.Now()
+		if err := ctx.Err(); err != nil {
+			logger.Ctx(ctx).Warn().Err(err).Msg("list fetch cancelled")
+			return nil, err
+		}
+
+		// Retrying cannot help once every relay said it has nothing
+		if attempt >= listCfg.FetchRetries || answered == len(relayURLs) {
+			if answered == 0 {
+				logger.Ctx(ctx).Warn().
+					Int("relay_count", len(relayURLs)).
+					Msg("no relay responded to the list fetch")
+				return nil, ErrNoRelaysResponded
+			}
 
-	for ev := range pool.FetchMany(ctx, relayURLs, filter) {
-		relayStats[ev.Relay.URL]++
-		
-		logger.Log.Debug().
-			Str("relay", ev.Relay.URL).
-			Str("event_id", ev.ID).
-			Time("created_at", time.Unix(int64(ev.CreatedAt), 0)).
-			Int("tag_count", len(ev.Tags)).
-			Int("content_length", len(ev.Content)).
-			Bool("has_encrypted_content", ev.Content != "").
-			Str("pubkey", ev.PubKey).
-			Msg("received event from relay")
+			logger.Ctx(ctx).Warn().
+				Int("answered_relays", answered).
+				Msg("relays answered but returned no lists")
+			return nil, ErrNoLists
+		}
 
-		events = append(events, ev)
+		logger.Ctx(ctx).Warn().
+			Int("attempt", attempt+1).
+			Int("answered_relays", answered).
+			Dur("backoff", backoff).
+			Msg("no list events received, retrying")
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			logger.Ctx(ctx).Warn().Err(ctx.Err()).Msg("list fetch cancelled")
+			return nil, ctx.Err()
+		}
+		backoff *= 2
 	}
 
-	fetchDuration := time.Since(fetchStart).Milliseconds()
+	return processEvents(ctx, events, bunkerClient, pubkeyHexStr, listCfg)
+}
 
-	// Log relay statistics
-	logger.Log.Info().
-		Dur("duration", time.Duration(fetchDuration)).
-		Msg("completed relay fetch")
+// relayOutcome is how a single relay answered the list fetch
+type relayOutcome int
 
-	for relayURL, count := range relayStats {
-		logger.Log.Info().
-			Str("relay", relayURL).
-			Int("event_count", count).
-			Msg("relay response summary")
+const (
+	relayUnreachable relayOutcome = iota // could not connect or subscribe
+	relayTimedOut                        // connected but never finished sending stored events
+	relayClosed                          // refused the subscription with CLOSED
+	relayAnswered                        // sent its stored events (possibly none) and EOSE
+)
+
+func (o relayOutcome) String() string {
+	switch o {
+	case relayTimedOut:
+		return "timed out"
+	case relayClosed:
+		return "closed"
+	case relayAnswered:
+		return "answered"
+	default:
+		return "unreachable"
+	}
+}
+
+// fetchListEvents runs a single fetch of the list events and reports how many
+// relays answered it. A relay answers by sending EOSE, even with no events,
+// which tells "no lists" apart from "nobody answered".
+func fetchListEvents(ctx context.Context, pool *nostr.SimplePool, relayURLs []string, filter nostr.Filter) ([]nostr.RelayEvent, int) {
+	ctx, cancel := context.WithTimeout(ctx, 120*time.Second)
+	defer cancel()
+
+	type relayResult struct {
+		url     string
+		outcome relayOutcome
+		events  []nostr.RelayEvent
 	}
 
-	// Check for relays that didn't respond
-	silentRelays := 0
+	logger.Ctx(ctx).Info().Msg("connecting to relays and fetching events")
+	fetchStart := time.Now()
+
+	results := make(chan relayResult, len(relayURLs))
 	for _, relayURL := range relayURLs {
-		if _, found := relayStats[relayURL]; !found {
-			silentRelays++
-			logger.Log.Warn().
-				Str("relay", relayURL).
-				Msg("relay did not return any events (may be offline, no data, or slow)")
+		go func(relayURL string) {
+			outcome, events := fetchFromRelay(ctx, pool, relayURL, filter)
+			results <- relayResult{url: relayURL, outcome: outcome, events: events}
+		}(relayURL)
+	}
+
+	events := make([]nostr.RelayEvent, 0)
+	seen := make(map[string]bool)
+	answered := 0
+	outcomes := make(map[relayOutcome]int)
+
+	for range relayURLs {
+		result := <-results
+		outcomes[result.outcome]++
+		if result.outcome == relayAnswered {
+			answered++
+		}
+
+		for _, ev := range result.events {
+			if seen[ev.ID] {
+				continue
+			}
+			seen[ev.ID] = true
+			events = append(events, ev)
+		}
+
+		switch {
+		case len(result.events) > 0:
+			logger.Ctx(ctx).Info().
+				Str("relay", result.url).
+				Str("outcome", result.outcome.String()).
+				Int("event_count", len(result.events)).
+				Msg("relay response summary")
+		case result.outcome == relayAnswered:
+			logger.Ctx(ctx).Info().
+				Str("relay", result.url).
+				Msg("relay answered but has no matching events")
+		default:
+			logger.Ctx(ctx).Warn().
+				Str("relay", result.url).
+				Str("outcome", result.outcome.String()).
+				Msg("relay did not answer the list fetch")
 		}
 	}
 
-	logger.Log.Info().
+	logger.Ctx(ctx).Info().
+		Dur("duration", time.Since(fetchStart)).
 		Int("total_events", len(events)).
-		Int("responding_relays", len(relayStats)).
-		Int("silent_relays", silentRelays).
+		Int("answered_relays", answered).
+		Int("unreachable_relays", outcomes[relayUnreachable]).
+		Int("timed_out_relays", outcomes[relayTimedOut]).
+		Int("closed_relays", outcomes[relayClosed]).
 		Int("total_relays", len(relayURLs)).
 		Msg("relay fetch summary")
 
-	if len(events) == 0 {
-		logger.Log.Warn().
-			Int("relay_count", len(relayURLs)).
-			Msg("no events received from any relay - check if lists exist or relays are reachable")
-		return []*PrivateList{}, nil
+	return events, answered
+}
+
+// fetchFromRelay collects the stored events matching filter from one relay
+// and reports whether it got as far as EOSE
+func fetchFromRelay(ctx context.Context, pool *nostr.SimplePool, relayURL string, filter nostr.Filter) (relayOutcome, []nostr.RelayEvent) {
+	relay, err := pool.EnsureRelay(relayURL)
+	if err != nil {
+		logger.Ctx(ctx).Debug().Err(err).Str("relay", relayURL).Msg("failed to connect to relay")
+		return relayUnreachable, nil
+	}
+
+	sub, err := relay.Subscribe(ctx, nostr.Filters{filter})
+	if err != nil {
+		logger.Ctx(ctx).Debug().Err(err).Str("relay", relayURL).Msg("failed to subscribe")
+		return relayUnreachable, nil
 	}
+	defer sub.Unsub()
+
+	var events []nostr.RelayEvent
+	for {
+		select {
+		case <-ctx.Done():
+			return relayTimedOut, events
+		case <-sub.EndOfStoredEvents:
+			return relayAnswered, events
+		case reason := <-sub.ClosedReason:
+			logger.Ctx(ctx).Debug().Str("relay", relayURL).Str("reason", reason).Msg("relay closed the subscription")
+			return relayClosed, events
+		case ev, more := <-sub.Events:
+			if !more {
+				return relayTimedOut, events
+			}
 
-	return processEvents(events, bunkerClient, pubkeyHexStr)
+			logger.Ctx(ctx).Debug().
+				Str("relay", relayURL).
+				Str("event_id", ev.ID).
+				Time("created_at", time.Unix(int64(ev.CreatedAt), 0)).
+				Int("tag_count", len(ev.Tags)).
+				Int("content_length", len(ev.Content)).
+				Bool("has_encrypted_content", ev.Content != "").
+				Str("pubkey", ev.PubKey).
+				Msg("received event from relay")
+
+			events = append(events, nostr.RelayEvent{Event: ev, Relay: relay})
+		}
+	}
 }
 
 // processEvents converts raw events into PrivateList structs
 func processEvents(
+	ctx context.Context,
 	events []nostr.RelayEvent,
 	bunkerClient *bunker.ReconnectingClient,
 	pubkeyHex string,
+	listCfg *config.ListConfig,
 ) ([]*PrivateList, error) {
 
-	logger.Log.Info().
+	logger.Ctx(ctx).Info().
 		Int("event_count", len(events)).
 		Msg("processing events into private lists")
 
@@ -153,28 +310,34 @@ func processEvents(
 	skippedEvents := 0
 
 	for i, event := range events {
-		logger.Log.Debug().
+		logger.Ctx(ctx).Debug().
 			Int("event_index", i).
 			Str("event_id", event.ID).
 			Str("relay", event.Relay.URL).
 			Msg("processing event")
 
-		var listID string
+		if event.Kind != 30000 {
+			logger.Ctx(ctx).Warn().
+				Str("event_id", event.ID).
+				Int("kind", event.Kind).
+				Str("relay", event.Relay.URL).
+				Msg("skipping event that is not a kind 30000 list")
+			skippedEvents++
+			continue
+		}
 
-		// Find the 'd' tag (list identifier)
-		for _, tag := range event.Tags {
-			if len(tag) >= 2 && tag[0] == "d" {
-				listID = tag[1]
-				logger.Log.Debug().
-					Str("list_id", listID).
-					Str("event_id", event.ID).
-					Msg("found list ID in 'd' tag")
-				break
-			}
+		// Find the 'd' tag (list identifier), the first one wins
+		listID, extra := listIdentifier(event.Event)
+		if extra > 0 {
+			logger.Ctx(ctx).Warn().
+				Str("list_id", listID).
+				Str("event_id", event.ID).
+				Int("extra_d_tags", extra).
+				Msg("list event has several 'd' tags, using the first")
 		}
 
 		if listID == "" {
-			logger.Log.Warn().
+			logger.Ctx(ctx).Warn().
 				Str("event_id", event.ID).
 				Str("relay", event.Relay.URL).
 				Msg("skipping event without 'd' tag (no list ID)")
@@ -184,8 +347,8 @@ func processEvents(
 
 		// For replaceable events (kind 30000), keep only the newest
 		if existing, exists := seen[listID]; exists {
-			if event.CreatedAt > existing.CreatedAt {
-				logger.Log.Debug().
+			if supersedes(event.Event, existing.Event) {
+				logger.Ctx(ctx).Debug().
 					Str("list_id", listID).
 					Str("old_event_id", existing.ID).
 					Time("old_created_at", time.Unix(int64(existing.CreatedAt), 0)).
@@ -194,7 +357,7 @@ func processEvents(
 					Msg("replacing with newer event")
 				seen[listID] = &event
 			} else {
-				logger.Log.Debug().
+				logger.Ctx(ctx).Debug().
 					Str("list_id", listID).
 					Str("event_id", event.ID).
 					Msg("skipping older duplicate event")
@@ -205,15 +368,18 @@ func processEvents(
 		seen[listID] = &event
 	}
 
-	logger.Log.Info().
+	logger.Ctx(ctx).Info().
 		Int("unique_lists", len(seen)).
 		Int("duplicate_events", len(events)-len(seen)-skippedEvents).
 		Int("skipped_events", skippedEvents).
 		Msg("deduplicated events")
 
-	// Process each unique list
-	for listID, event := range seen {
-		logger.Log.Debug().
+	// Process each unique list, in a stable order so selection numbers do not
+	// shuffle between runs
+	listIDs := slices.Sorted(maps.Keys(seen))
+	for _, listID := range listIDs {
+		event := seen[listID]
+		logger.Ctx(ctx).Debug().
 			Str("list_id", listID).
 			Str("event_id", event.ID).
 			Msg("extracting list metadata and members")
@@ -223,7 +389,7 @@ func processEvents(
 		for _, tag := range event.Tags {
 			if len(tag) >= 2 && (tag[0] == "name" || tag[0] == "title") && tag[1] != "" {
 				title = tag[1]
-				logger.Log.Debug().
+				logger.Ctx(ctx).Debug().
 					Str("list_id", listID).
 					Str("title", title).
 					Str("tag_type", tag[0]).
@@ -233,60 +399,75 @@ func processEvents(
 		}
 
 		// Extract npubs
-		npubs, hasPrivate := extractAllNPubs(*event, bunkerClient, pubkeyHex)
+		list := &PrivateList{
+			ID:        listID,
+			Title:     title,
+			EventID:   event.ID,
+			CreatedAt: int64(event.CreatedAt),
+		}
+		extractAllNPubs(ctx, *event, bunkerClient, pubkeyHex, listCfg, list)
 
-		logger.Log.Info().
+		logger.Ctx(ctx).Info().
 			Str("list_id", listID).
 			Str("title", title).
-			Int("member_count", len(npubs)).
-			Bool("has_private_members", hasPrivate).
+			Int("member_count", len(list.NPubs)).
+			Bool("has_private_members", list.HasPrivate).
+			Int("invalid_public", list.InvalidPublic).
+			Int("invalid_private", list.InvalidPrivate).
 			Str("event_id", event.ID).
 			Msg("processed list")
 
-		lists = append(lists, &PrivateList{
-			ID:         listID,
-			Title:      title,
-			NPubs:      npubs,
-			EventID:    event.ID,
-			CreatedAt:  int64(event.CreatedAt),
-			HasPrivate: hasPrivate,
-		})
+		if list.InvalidMembers() > 0 {
+			logger.Ctx(ctx).Warn().
+				Str("list_id", listID).
+				Int("invalid_public", list.InvalidPublic).
+				Int("invalid_private", list.InvalidPrivate).
+				Msg("list contains malformed 'p' tags")
+		}
+
+		lists = append(lists, list)
 	}
 
-	logger.Log.Info().
+	logger.Ctx(ctx).Info().
 		Int("list_count", len(lists)).
 		Msg("completed processing private lists")
 
 	return lists, nil
 }
 
-// extractAllNPubs extracts npubs from public tags and encrypted content
+// extractAllNPubs extracts npubs from public tags and encrypted content into
+// list, counting malformed 'p' tags along the way
 func extractAllNPubs(
+	ctx context.Context,
 	event nostr.RelayEvent,
 	bunkerClient *bunker.ReconnectingClient,
 	pubkeyHex string,
-) ([]string, bool) {
+	listCfg *config.ListConfig,
+	list *PrivateList,
+) {
 
 	npubSet := make(map[string]bool)
-	hasPrivate := false
+	hasPrivate := event.Content != ""
 	publicCount := 0
+	privateCount := 0
 
-	logger.Log.Debug().
+	logger.Ctx(ctx).Debug().
 		Str("event_id", event.ID).
 		Msg("extracting npubs from event")
 
 	// Public tags
 	for _, tag := range event.Tags {
 		if len(tag) >= 2 && tag[0] == "p" {
-			if npub, err := nip19.EncodePublicKey(tag[1]); err == nil {
+			if npub, err := encodeMember(tag[1]); err == nil {
 				npubSet[npub] = true
 				publicCount++
-				logger.Log.Debug().
+				logger.Ctx(ctx).Debug().
 					Str("npub", npub).
 					Str("hex", tag[1]).
 					Msg("found public member in 'p' tag")
 			} else {
-				logger.Log.Warn().
+				list.InvalidPublic++
+				logger.Ctx(ctx).Warn().
 					Err(err).
 					Str("hex", tag[1]).
 					Msg("failed to encode public key to npub")
@@ -294,48 +475,53 @@ func extractAllNPubs(
 		}
 	}
 
-	logger.Log.Debug().
+	logger.Ctx(ctx).Debug().
 		Str("event_id", event.ID).
 		Int("public_members", publicCount).
 		Msg("extracted public members")
 
 	// Encrypted content - for NIP-51 lists, this is self-encrypted
 	// The content is encrypted by you to yourself, so we pass your own pubkey
-	if event.Content != "" {
-		logger.Log.Debug().
+	if event.Content != "" && listCfg.PublicOnly {
+		list.PrivateSkipped = true
+		logger.Ctx(ctx).Info().
+			Str("event_id", event.ID).
+			Msg("list.public_only set, not decrypting private content")
+	} else if event.Content != "" {
+		logger.Ctx(ctx).Debug().
 			Str("event_id", event.ID).
 			Int("content_length", len(event.Content)).
 			Str("author_pubkey", event.PubKey).
 			Msg("attempting to decrypt private content (self-encrypted)")
 
-		plaintext, err := decryptContent(event.Content, bunkerClient, event.PubKey)
+		plaintext, err := decryptContent(ctx, event.Content, bunkerClient, event.PubKey, listCfg.DecryptPreference)
 		if err != nil {
-			logger.Log.Error().
+			list.DecryptFailed = true
+			logger.Ctx(ctx).Error().
 				Err(err).
 				Str("event_id", event.ID).
 				Str("author_pubkey", event.PubKey).
 				Msg("failed to decrypt private list content")
 		} else if plaintext != "" {
-			logger.Log.Debug().
+			logger.Ctx(ctx).Debug().
 				Str("event_id", event.ID).
 				Int("plaintext_length", len(plaintext)).
 				Msg("decryption successful, parsing tags")
 
-			privateTags := parseDecryptedTags(plaintext)
-			privateCount := 0
+			privateTags := parseDecryptedTags(ctx, plaintext)
 
 			for _, tag := range privateTags {
 				if len(tag) >= 2 && tag[0] == "p" {
-					if npub, err := nip19.EncodePublicKey(tag[1]); err == nil {
+					if npub, err := encodeMember(tag[1]); err == nil {
 						npubSet[npub] = true
-						hasPrivate = true
 						privateCount++
-						logger.Log.Debug().
+						logger.Ctx(ctx).Debug().
 							Str("npub", npub).
 							Str("hex", tag[1]).
 							Msg("found private member")
 					} else {
-						logger.Log.Warn().
+						list.InvalidPrivate++
+						logger.Ctx(ctx).Warn().
 							Err(err).
 							Str("hex", tag[1]).
 							Msg("failed to encode private member public key")
@@ -343,71 +529,147 @@ func extractAllNPubs(
 				}
 			}
 
-			logger.Log.Info().
+			logger.Ctx(ctx).Info().
 				Str("event_id", event.ID).
 				Int("private_members", privateCount).
 				Int("total_private_tags", len(privateTags)).
 				Msg("extracted private members")
 		}
 	} else {
-		logger.Log.Debug().
+		logger.Ctx(ctx).Debug().
 			Str("event_id", event.ID).
 			Msg("no encrypted content in event")
 	}
 
 	npubs := npubsFromSet(npubSet)
 
-	logger.Log.Debug().
+	logger.Ctx(ctx).Debug().
 		Str("event_id", event.ID).
 		Int("total_unique_members", len(npubs)).
 		Int("public", publicCount).
+		Int("private", privateCount).
 		Bool("has_private", hasPrivate).
+		Int("invalid_public", list.InvalidPublic).
+		Int("invalid_private", list.InvalidPrivate).
 		Msg("completed npub extraction")
 
-	return npubs, hasPrivate
+	list.NPubs = npubs
+	list.HasPrivate = hasPrivate
+	list.PrivateMembers = privateCount
 }
 
-// decryptContent tries NIP-44 first, then NIP-04
+// listIdentifier returns the value of the event's first 'd' tag, or "" when
+// it has none, along with the number of further 'd' tags that were ignored
+func listIdentifier(event *nostr.Event) (string, int) {
+	var id string
+	found, extra := false, 0
+	for _, tag := range event.Tags {
+		if len(tag) < 2 || tag[0] != "d" {
+			continue
+		}
+		if found {
+			extra++
+			continue
+		}
+		id, found = tag[1], true
+	}
+	return id, extra
+}
+
+// supersedes reports whether candidate replaces current as the version of a
+// replaceable event: the newer one wins and, per NIP-01, the lowest id breaks
+// a created_at tie
+func supersedes(candidate, current *nostr.Event) bool {
+	if candidate.CreatedAt != current.CreatedAt {
+		return candidate.CreatedAt > current.CreatedAt
+	}
+	return candidate.ID < current.ID
+}
+
+// encodeMember converts a 'p' tag value to an npub, rejecting anything that
+// is not a 32-byte lowercase hex pubkey
+func encodeMember(hex string) (string, error) {
+	if !nostr.IsValid32ByteHex(hex) {
+		return "", fmt.Errorf("not a valid hex pubkey")
+	}
+	return nip19.EncodePublicKey(hex)
+}
+
+// decryptContent tries NIP-44 first, then NIP-04, unless a preference skips
+// straight to one of them
 func decryptContent(
+	ctx context.Context,
 	content string,
 	bunkerClient *bunker.ReconnectingClient,
 	pubkeyHex string,
+	preference string,
 ) (string, error) {
 
-	logger.Log.Debug().
+	logger.Ctx(ctx).Debug().
 		Int("ciphertext_length", len(content)).
+		Str("preference", preference).
 		Msg("attempting decryption")
 
-	// Try NIP-44 first - fresh context
-	logger.Log.Debug().Msg("trying NIP-44 decryption")
-	ctx44, cancel44 := context.WithTimeout(context.Background(), 30*time.Second)
+	if preference == config.DecryptNIP04 {
+		plaintext, err := decryptNIP04(ctx, content, bunkerClient, pubkeyHex)
+		if err != nil {
+			return "", fmt.Errorf("decryption failed (NIP-04): %w", err)
+		}
+		return plaintext, nil
+	}
+
+	// Try NIP-44 first - fresh timeout
+	logger.Ctx(ctx).Debug().Msg("trying NIP-44 decryption")
+	ctx44, cancel44 := context.WithTimeout(ctx, 30*time.Second)
 	plaintext, err := bunkerClient.DecryptNIP44(ctx44, pubkeyHex, content)
 	cancel44()
-	
+
 	if err == nil {
-		logger.Log.Info().
+		logger.Ctx(ctx).Info().
 			Int("plaintext_length", len(plaintext)).
 			Msg("NIP-44 decryption succeeded")
 		return plaintext, nil
 	}
 
-	logger.Log.Debug().
+	if preference == config.DecryptNIP44 {
+		logger.Ctx(ctx).Error().
+			Err(err).
+			Msg("NIP-44 decryption failed (NIP-04 fallback disabled by preference)")
+		return "", fmt.Errorf("decryption failed (NIP-44): %w", err)
+	}
+
+	logger.Ctx(ctx).Debug().
 		Err(err).
 		Msg("NIP-44 decryption failed, falling back to NIP-04")
 
-	// Fallback to NIP-04 - fresh context
-	ctx04, cancel04 := context.WithTimeout(context.Background(), 30*time.Second)
-	plaintext, err = bunkerClient.DecryptNIP04(ctx04, pubkeyHex, content)
+	plaintext, err = decryptNIP04(ctx, content, bunkerClient, pubkeyHex)
+	if err != nil {
+		return "", fmt.Errorf("decryption failed (tried NIP-44 and NIP-04): %w", err)
+	}
+
+	return plaintext, nil
+}
+
+// decryptNIP04 decrypts content with NIP-04 using a fresh timeout
+func decryptNIP04(
+	ctx context.Context,
+	content string,
+	bunkerClient *bunker.ReconnectingClient,
+	pubkeyHex string,
+) (string, error) {
+
+	ctx04, cancel04 := context.WithTimeout(ctx, 30*time.Second)
+	plaintext, err := bunkerClient.DecryptNIP04(ctx04, pubkeyHex, content)
 	cancel04()
-	
+
 	if err != nil {
-		logger.Log.Error().
+		logger.Ctx(ctx).Error().
 			Err(err).
-			Msg("NIP-04 decryption also failed - both methods exhausted")
-		return "", fmt.Errorf("decryption failed (tried NIP-44 and NIP-04): %w", err)
+			Msg("NIP-04 decryption failed")
+		return "", err
 	}
 
-	logger.Log.Info().
+	logger.Ctx(ctx).Info().
 		Int("plaintext_length", len(plaintext)).
 		Msg("NIP-04 decryption succeeded")
 
@@ -415,21 +677,21 @@ func decryptContent(
 }
 
 // parseDecryptedTags parses decrypted JSON tags
-func parseDecryptedTags(content string) [][]string {
-	logger.Log.Debug().
+func parseDecryptedTags(ctx context.Context, content string) [][]string {
+	logger.Ctx(ctx).Debug().
 		Int("content_length", len(content)).
 		Msg("parsing decrypted tags JSON")
 
 	var tags [][]string
 	if err := json.Unmarshal([]byte(content), &tags); err != nil {
-		logger.Log.Error().
+		logger.Ctx(ctx).Error().
 			Err(err).
 			Str("content_preview", truncate(content, 100)).
 			Msg("failed to parse decrypted tags JSON")
 		return nil
 	}
 
-	logger.Log.Debug().
+	logger.Ctx(ctx).Debug().
 		Int("tag_count", len(tags)).
 		Msg("successfully parsed tags")
 
@@ -445,42 +707,44 @@ func npubsFromSet(npubSet map[string]bool) []string {
 	return npubs
 }
 
-// GetNPubsFromList fetches a specific list by ID
-func GetNPubsFromList(
+// GetList fetches a specific list by ID
+func GetList(
+	ctx context.Context,
 	relays []string,
 	authorNPub string,
 	bunkerClient *bunker.ReconnectingClient,
 	pool *nostr.SimplePool,
+	listCfg *config.ListConfig,
 	listID string,
-) ([]string, error) {
+) (*PrivateList, error) {
 
-	logger.Log.Info().
+	logger.Ctx(ctx).Info().
 		Str("list_id", listID).
 		Str("author_npub", authorNPub).
 		Msg("fetching npubs from specific list")
 
-	lists, err := FetchPrivateLists(relays, authorNPub, bunkerClient, pool)
+	lists, err := FetchPrivateLists(ctx, relays, authorNPub, bunkerClient, pool, listCfg)
 	if err != nil {
-		logger.Log.Error().
+		logger.Ctx(ctx).Error().
 			Err(err).
 			Str("list_id", listID).
 			Msg("failed to fetch private lists")
 		return nil, err
 	}
 
-	logger.Log.Debug().
+	logger.Ctx(ctx).Debug().
 		Int("total_lists", len(lists)).
 		Str("target_list_id", listID).
 		Msg("searching for target list")
 
 	for _, list := range lists {
 		if list.ID == listID {
-			logger.Log.Info().
+			logger.Ctx(ctx).Info().
 				Str("list_id", listID).
 				Str("title", list.Title).
 				Int("member_count", len(list.NPubs)).
 				Msg("found target list")
-			return list.NPubs, nil
+			return list, nil
 		}
 	}
 
@@ -490,7 +754,7 @@ func GetNPubsFromList(
 		availableIDs[i] = list.ID
 	}
 
-	logger.Log.Error().
+	logger.Ctx(ctx).Error().
 		Str("list_id", listID).
 		Strs("available_list_ids", availableIDs).
 		Msg("list not found")
@@ -498,10 +762,62 @@ func GetNPubsFromList(
 	return nil, fmt.Errorf("list '%s' not found", listID)
 }
 
+// LatestListEvent fetches only the newest event for a list, without
+// decrypting it, so callers can cheaply tell whether the list changed.
+// Cancelling ctx stops the fetch.
+func LatestListEvent(
+	ctx context.Context,
+	relays []string,
+	authorNPub string,
+	pool *nostr.SimplePool,
+	listID string,
+) (*nostr.Event, error) {
+
+	prefix, pubkeyHex, err := nip19.Decode(authorNPub)
+	if err != nil {
+		return nil, fmt.Errorf("invalid npub: %w", err)
+	}
+	if prefix != "npub" {
+		return nil, fmt.Errorf("expected npub prefix, got %s", prefix)
+	}
+
+	filter := nostr.Filter{
+		Kinds:   []int{30000},
+		Authors: []string{pubkeyHex.(string)},
+		Tags:    nostr.TagMap{"d": []string{listID}},
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var newest *nostr.Event
+	for ev := range pool.FetchMany(ctx, relays, filter) {
+		// Relays match any 'd' tag, but only the first identifies the list
+		if id, _ := listIdentifier(ev.Event); id != listID {
+			continue
+		}
+		if newest == nil || supersedes(ev.Event, newest) {
+			newest = ev.Event
+		}
+	}
+
+	if newest == nil {
+		return nil, fmt.Errorf("list '%s' not found on relays", listID)
+	}
+
+	logger.Ctx(ctx).Debug().
+		Str("list_id", listID).
+		Str("event_id", newest.ID).
+		Time("created_at", time.Unix(int64(newest.CreatedAt), 0)).
+		Msg("fetched latest list event")
+
+	return newest, nil
+}
+
 // truncate helper for safe logging of potentially long strings
 func truncate(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
 	}
 	return s[:maxLen] + "..."
-}
\ No newline at end of file
+}
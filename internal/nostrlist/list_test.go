@@ -0,0 +1,55 @@
+package nostrlist
+
+import (
+	"testing"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+func TestListIdentifier(t *testing.T) {
+	tests := []struct {
+		name  string
+		tags  nostr.Tags
+		id    string
+		extra int
+	}{
+		{"no d tag", nostr.Tags{{"p", "aa"}}, "", 0},
+		{"single d tag", nostr.Tags{{"d", "friends"}}, "friends", 0},
+		{"first d wins", nostr.Tags{{"d", "friends"}, {"p", "aa"}, {"d", "other"}}, "friends", 1},
+		{"several duplicates", nostr.Tags{{"d", "a"}, {"d", "b"}, {"d", "c"}}, "a", 2},
+		{"empty first d wins", nostr.Tags{{"d", ""}, {"d", "friends"}}, "", 1},
+		{"bare d tag ignored", nostr.Tags{{"d"}, {"d", "friends"}}, "friends", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, extra := listIdentifier(&nostr.Event{Tags: tt.tags})
+			if id != tt.id || extra != tt.extra {
+				t.Errorf("got (%q, %d), want (%q, %d)", id, extra, tt.id, tt.extra)
+			}
+		})
+	}
+}
+
+func TestSupersedes(t *testing.T) {
+	tests := []struct {
+		name      string
+		candidate *nostr.Event
+		current   *nostr.Event
+		want      bool
+	}{
+		{"newer wins", &nostr.Event{ID: "bb", CreatedAt: 200}, &nostr.Event{ID: "aa", CreatedAt: 100}, true},
+		{"older loses", &nostr.Event{ID: "aa", CreatedAt: 100}, &nostr.Event{ID: "bb", CreatedAt: 200}, false},
+		{"tie, lower id wins", &nostr.Event{ID: "aa", CreatedAt: 100}, &nostr.Event{ID: "bb", CreatedAt: 100}, true},
+		{"tie, higher id loses", &nostr.Event{ID: "bb", CreatedAt: 100}, &nostr.Event{ID: "aa", CreatedAt: 100}, false},
+		{"same event", &nostr.Event{ID: "aa", CreatedAt: 100}, &nostr.Event{ID: "aa", CreatedAt: 100}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := supersedes(tt.candidate, tt.current); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,21 +3,35 @@ package nwc
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/mistic0xb/pekka/internal/clock"
 	"github.com/mistic0xb/pekka/internal/logger"
 	"github.com/nbd-wtf/go-nostr"
 	"github.com/nbd-wtf/go-nostr/nip04"
+	"github.com/rs/zerolog"
 )
 
 type Client struct {
 	walletPubkey string
 	secret       string
 	relay        *nostr.Relay
-	relayURL     string
+	relayURL     string   // Relay currently in use
+	relayURLs    []string // Every relay from the NWC URL, in order
+	retry        RetryConfig
+	clock        *clock.Clock // timestamps requests
+	log          *zerolog.Logger
+}
+
+// RetryConfig controls how Connect retries the wallet relay
+type RetryConfig struct {
+	Attempts int           // Total connection attempts (default 1)
+	Timeout  time.Duration // Per-attempt timeout (0 uses the caller's context only)
 }
 
 // Request represents a NIP-47 request
@@ -38,18 +52,21 @@ type ResponseError struct {
 	Message string `json:"message"`
 }
 
-// NewClient creates NWC client from nostr+walletconnect:// URL
-func NewClient(nwcURL string) (*Client, error) {
+// NewClient creates NWC client from nostr+walletconnect:// URL. The URL may
+// carry several relay parameters, the client fails over between them.
+func NewClient(ctx context.Context, nwcURL string, retry RetryConfig) (*Client, error) {
+	log := logger.Ctx(ctx)
+
 	u, err := url.Parse(nwcURL)
 	if err != nil {
-		logger.Log.Error().
+		log.Error().
 			Err(err).
 			Msg("invalid NWC URL")
 		return nil, fmt.Errorf("invalid NWC URL: %w", err)
 	}
 
 	if u.Scheme != "nostr+walletconnect" {
-		logger.Log.Error().
+		log.Error().
 			Str("scheme", u.Scheme).
 			Msg("invalid NWC URL scheme")
 		return nil, fmt.Errorf("invalid scheme: expected nostr+walletconnect, got %s", u.Scheme)
@@ -57,63 +74,182 @@ func NewClient(nwcURL string) (*Client, error) {
 
 	walletPubkey := u.Host
 	query := u.Query()
-	relayURL := query.Get("relay")
+	var relayURLs []string
+	for _, relayURL := range query["relay"] {
+		relayURL = strings.TrimSpace(relayURL)
+		if relayURL != "" && !slices.Contains(relayURLs, relayURL) {
+			relayURLs = append(relayURLs, relayURL)
+		}
+	}
 	secret := query.Get("secret")
 
-	if relayURL == "" {
-		logger.Log.Error().
+	if len(relayURLs) == 0 {
+		log.Error().
 			Msg("missing relay parameter in NWC URL")
 		return nil, fmt.Errorf("missing relay parameter")
 	}
 
 	if secret == "" {
-		logger.Log.Error().
+		log.Error().
 			Msg("missing secret parameter in NWC URL")
 		return nil, fmt.Errorf("missing secret parameter")
 	}
 
-	logger.Log.Info().
+	log.Info().
+		Int("relays", len(relayURLs)).
 		Msg("NWC client created")
 
 	return &Client{
 		walletPubkey: walletPubkey,
 		secret:       secret,
-		relayURL:     relayURL,
+		relayURL:     relayURLs[0],
+		relayURLs:    relayURLs,
+		retry:        retry,
+		clock:        clock.Ctx(ctx),
+		log:          log,
 	}, nil
 }
 
-// Connect establishes connection to wallet relay
+// Connect establishes connection to a wallet relay, trying each relay from
+// the NWC URL in turn and retrying the whole list with backoff
 func (c *Client) Connect(ctx context.Context) error {
-	relay, err := nostr.RelayConnect(ctx, c.relayURL)
+	attempts := max(c.retry.Attempts, 1)
+	backoff := 1 * time.Second
+	relays := strings.Join(c.relayURLs, ", ")
+
+	var relay *nostr.Relay
+	var relayURL string
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		relay, relayURL, err = c.connectAny(ctx)
+		if err == nil {
+			break
+		}
+
+		c.log.Warn().
+			Err(err).
+			Strs("relays", c.relayURLs).
+			Int("attempt", attempt).
+			Int("max_attempts", attempts).
+			Msg("wallet relay connection attempt failed")
+
+		if attempt == attempts {
+			break
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return fmt.Errorf("failed to connect to %s: %w", relays, ctx.Err())
+		}
+		backoff = min(backoff*2, 30*time.Second)
+	}
+
 	if err != nil {
-		logger.Log.Error().
+		c.log.Error().
 			Err(err).
-			Str("relay", c.relayURL).
+			Strs("relays", c.relayURLs).
+			Int("attempts", attempts).
 			Msg("failed to connect to wallet relay")
-		return fmt.Errorf("failed to connect to %s: %w", c.relayURL, err)
+		return fmt.Errorf("failed to connect to %s after %d attempt(s): %w", relays, attempts, err)
 	}
 
 	c.relay = relay
+	c.relayURL = relayURL
 
-	logger.Log.Info().
+	c.log.Info().
 		Str("relay", c.relayURL).
 		Msg("connected to wallet relay")
 
 	return nil
 }
 
+// connectAny tries every wallet relay once, starting with the one in use,
+// and returns the first that connects
+func (c *Client) connectAny(ctx context.Context) (*nostr.Relay, string, error) {
+	start := max(slices.Index(c.relayURLs, c.relayURL), 0)
+
+	var errs []error
+	for i := range c.relayURLs {
+		relayURL := c.relayURLs[(start+i)%len(c.relayURLs)]
+		relay, err := c.connectOnce(ctx, relayURL)
+		if err == nil {
+			return relay, relayURL, nil
+		}
+
+		if len(c.relayURLs) > 1 {
+			c.log.Warn().
+				Err(err).
+				Str("relay", relayURL).
+				Msg("wallet relay unreachable, trying next")
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", relayURL, err))
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return nil, "", errors.Join(errs...)
+}
+
+// connectOnce makes a single connection attempt bounded by the retry timeout
+func (c *Client) connectOnce(ctx context.Context, relayURL string) (*nostr.Relay, error) {
+	if c.retry.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.retry.Timeout)
+		defer cancel()
+	}
+	return nostr.RelayConnect(ctx, relayURL)
+}
+
+// failover drops the relay in use and connects to the next wallet relay that
+// answers, coming back around to the same relay when it is the only one
+func (c *Client) failover(ctx context.Context) error {
+	failed := c.relayURL
+	if c.relay != nil {
+		c.relay.Close()
+		c.relay = nil
+	}
+
+	i := slices.Index(c.relayURLs, failed)
+	c.relayURL = c.relayURLs[(i+1)%len(c.relayURLs)]
+
+	relay, relayURL, err := c.connectAny(ctx)
+	if err != nil {
+		c.log.Error().
+			Err(err).
+			Str("from", failed).
+			Msg("no wallet relay reachable")
+		return fmt.Errorf("no wallet relay reachable: %w", err)
+	}
+	c.relay = relay
+	c.relayURL = relayURL
+
+	if relayURL != failed {
+		c.log.Warn().
+			Str("from", failed).
+			Str("to", relayURL).
+			Msg("switched wallet relay")
+	}
+
+	return nil
+}
+
 // Close closes the relay connection
 func (c *Client) Close() error {
 	if c.relay != nil {
-		logger.Log.Info().
+		c.log.Info().
 			Msg("closing wallet relay connection")
 		return c.relay.Close()
 	}
 	return nil
 }
 
-// PayInvoice pays a lightning invoice
-func (c *Client) PayInvoice(ctx context.Context, invoice string) error {
+// PayInvoice pays a lightning invoice and returns the preimage the wallet
+// reports, which proves the payment settled. It is empty if the wallet
+// left it out.
+func (c *Client) PayInvoice(ctx context.Context, invoice string) (string, error) {
 	request := Request{
 		Method: "pay_invoice",
 		Params: map[string]any{
@@ -123,24 +259,27 @@ func (c *Client) PayInvoice(ctx context.Context, invoice string) error {
 
 	response, err := c.sendRequest(ctx, request)
 	if err != nil {
-		logger.Log.Error().
+		c.log.Error().
 			Err(err).
 			Msg("pay_invoice request failed")
-		return err
+		return "", err
 	}
 
 	if response.Error != nil {
-		logger.Log.Error().
+		c.log.Error().
 			Str("code", response.Error.Code).
 			Str("message", response.Error.Message).
 			Msg("wallet returned payment error")
-		return fmt.Errorf("payment failed: %s - %s", response.Error.Code, response.Error.Message)
+		return "", fmt.Errorf("payment failed: %s - %s", response.Error.Code, response.Error.Message)
 	}
 
-	logger.Log.Info().
+	preimage, _ := response.Result["preimage"].(string)
+
+	c.log.Info().
+		Bool("preimage", preimage != "").
 		Msg("invoice paid successfully")
 
-	return nil
+	return preimage, nil
 }
 
 // GetBalance gets wallet balance in millisats
@@ -152,14 +291,14 @@ func (c *Client) GetBalance(ctx context.Context) (int64, error) {
 
 	response, err := c.sendRequest(ctx, request)
 	if err != nil {
-		logger.Log.Error().
+		c.log.Error().
 			Err(err).
 			Msg("get_balance request failed")
 		return 0, err
 	}
 
 	if response.Error != nil {
-		logger.Log.Error().
+		c.log.Error().
 			Str("code", response.Error.Code).
 			Str("message", response.Error.Message).
 			Msg("wallet returned get_balance error")
@@ -168,27 +307,182 @@ func (c *Client) GetBalance(ctx context.Context) (int64, error) {
 
 	balance, ok := response.Result["balance"].(float64)
 	if !ok {
-		logger.Log.Error().
+		c.log.Error().
 			Msg("invalid balance type in wallet response")
 		return 0, fmt.Errorf("invalid balance in response")
 	}
 
-	logger.Log.Info().
+	c.log.Info().
 		Msg("wallet balance fetched")
 
 	return int64(balance), nil
 }
 
+// Info is the wallet's get_info response
+type Info struct {
+	Alias         string   `json:"alias"`
+	Color         string   `json:"color"`
+	Pubkey        string   `json:"pubkey"`
+	Network       string   `json:"network"`
+	BlockHeight   int64    `json:"block_height"`
+	BlockHash     string   `json:"block_hash"`
+	Methods       []string `json:"methods"`
+	Notifications []string `json:"notifications"`
+}
+
+// Budget is the wallet's get_budget response (amounts in millisats)
+type Budget struct {
+	UsedBudget    int64  `json:"used_budget"`
+	TotalBudget   int64  `json:"total_budget"`
+	RenewsAt      int64  `json:"renews_at"`
+	RenewalPeriod string `json:"renewal_period"`
+}
+
+// GetInfo fetches the wallet's advertised methods and node information
+func (c *Client) GetInfo(ctx context.Context) (*Info, error) {
+	var info Info
+	if err := c.call(ctx, "get_info", map[string]any{}, &info); err != nil {
+		return nil, err
+	}
+
+	c.log.Info().
+		Strs("methods", info.Methods).
+		Str("network", info.Network).
+		Msg("wallet info fetched")
+
+	return &info, nil
+}
+
+// GetBudget fetches the connection's spending budget, if the wallet supports it
+func (c *Client) GetBudget(ctx context.Context) (*Budget, error) {
+	var budget Budget
+	if err := c.call(ctx, "get_budget", map[string]any{}, &budget); err != nil {
+		return nil, err
+	}
+
+	return &budget, nil
+}
+
+// Transaction is a single entry from list_transactions (amounts in millisats)
+type Transaction struct {
+	Type            string `json:"type"` // "incoming" or "outgoing"
+	Invoice         string `json:"invoice"`
+	Description     string `json:"description"`
+	DescriptionHash string `json:"description_hash"`
+	Preimage        string `json:"preimage"`
+	PaymentHash     string `json:"payment_hash"`
+	Amount          int64  `json:"amount"`
+	FeesPaid        int64  `json:"fees_paid"`
+	CreatedAt       int64  `json:"created_at"`
+	ExpiresAt       int64  `json:"expires_at"`
+	SettledAt       int64  `json:"settled_at"`
+}
+
+// ListTransactionsParams filters a list_transactions request. Zero values
+// are omitted so the wallet applies its own defaults.
+type ListTransactionsParams struct {
+	Type   string // "incoming", "outgoing" or empty for both
+	From   int64  // unix seconds, inclusive
+	Until  int64  // unix seconds, inclusive
+	Limit  int
+	Offset int
+}
+
+// ListTransactions fetches the wallet's settled payments
+func (c *Client) ListTransactions(ctx context.Context, params ListTransactionsParams) ([]Transaction, error) {
+	p := map[string]any{}
+	if params.Type != "" {
+		p["type"] = params.Type
+	}
+	if params.From > 0 {
+		p["from"] = params.From
+	}
+	if params.Until > 0 {
+		p["until"] = params.Until
+	}
+	if params.Limit > 0 {
+		p["limit"] = params.Limit
+	}
+	if params.Offset > 0 {
+		p["offset"] = params.Offset
+	}
+
+	var result struct {
+		Transactions []Transaction `json:"transactions"`
+	}
+	if err := c.call(ctx, "list_transactions", p, &result); err != nil {
+		return nil, err
+	}
+
+	c.log.Debug().
+		Int("count", len(result.Transactions)).
+		Msg("wallet transactions fetched")
+
+	return result.Transactions, nil
+}
+
+// LookupInvoice fetches the state of a payment by its bolt11 invoice
+func (c *Client) LookupInvoice(ctx context.Context, invoice string) (*Transaction, error) {
+	var tx Transaction
+	if err := c.call(ctx, "lookup_invoice", map[string]any{"invoice": invoice}, &tx); err != nil {
+		return nil, err
+	}
+
+	return &tx, nil
+}
+
+// Settled reports whether the wallet considers the payment complete
+func (t *Transaction) Settled() bool {
+	return t.SettledAt > 0 || t.Preimage != ""
+}
+
+// call sends a request and decodes its result into out
+func (c *Client) call(ctx context.Context, method string, params map[string]any, out any) error {
+	response, err := c.sendRequest(ctx, Request{Method: method, Params: params})
+	if err != nil {
+		c.log.Error().
+			Err(err).
+			Str("method", method).
+			Msg("NWC request failed")
+		return err
+	}
+
+	if response.Error != nil {
+		c.log.Error().
+			Str("method", method).
+			Str("code", response.Error.Code).
+			Str("message", response.Error.Message).
+			Msg("wallet returned error")
+		return fmt.Errorf("%s failed: %s - %s", method, response.Error.Code, response.Error.Message)
+	}
+
+	// Result is decoded as a generic map, round-trip it into the typed struct
+	raw, err := json.Marshal(response.Result)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s result: %w", method, err)
+	}
+
+	if err := json.Unmarshal(raw, out); err != nil {
+		c.log.Error().
+			Err(err).
+			Str("method", method).
+			Msg("invalid wallet response")
+		return fmt.Errorf("invalid %s result: %w", method, err)
+	}
+
+	return nil
+}
+
 func (c *Client) sendRequest(ctx context.Context, req Request) (*Response, error) {
 	if c.relay == nil {
-		logger.Log.Error().
+		c.log.Error().
 			Msg("sendRequest called without relay connection")
 		return nil, fmt.Errorf("not connected to relay")
 	}
 
 	sharedSecret, err := nip04.ComputeSharedSecret(c.walletPubkey, c.secret)
 	if err != nil {
-		logger.Log.Error().
+		c.log.Error().
 			Err(err).
 			Msg("failed to compute shared secret")
 		return nil, fmt.Errorf("failed to compute shared secret: %w", err)
@@ -196,7 +490,7 @@ func (c *Client) sendRequest(ctx context.Context, req Request) (*Response, error
 
 	ourPubkey, err := nostr.GetPublicKey(c.secret)
 	if err != nil {
-		logger.Log.Error().
+		c.log.Error().
 			Err(err).
 			Msg("invalid client secret")
 		return nil, fmt.Errorf("invalid secret: %w", err)
@@ -204,14 +498,14 @@ func (c *Client) sendRequest(ctx context.Context, req Request) (*Response, error
 
 	event := nostr.Event{
 		PubKey:    ourPubkey,
-		CreatedAt: nostr.Now(),
+		CreatedAt: c.clock.Now(),
 		Kind:      23194,
 		Tags:      nostr.Tags{{"p", c.walletPubkey}},
 	}
 
 	reqJSON, err := json.Marshal(req)
 	if err != nil {
-		logger.Log.Error().
+		c.log.Error().
 			Err(err).
 			Msg("failed to marshal NWC request")
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
@@ -219,7 +513,7 @@ func (c *Client) sendRequest(ctx context.Context, req Request) (*Response, error
 
 	encrypted, err := nip04.Encrypt(string(reqJSON), sharedSecret)
 	if err != nil {
-		logger.Log.Error().
+		c.log.Error().
 			Err(err).
 			Msg("failed to encrypt NWC request")
 		return nil, fmt.Errorf("failed to encrypt request: %w", err)
@@ -229,25 +523,28 @@ func (c *Client) sendRequest(ctx context.Context, req Request) (*Response, error
 	event.ID = event.GetID()
 	event.Sign(c.secret)
 
-	// retry logic
-	for range 3 {
+	// Nothing reached the wallet yet, so a failed publish is safe to retry on
+	// the next wallet relay
+	for attempt := 1; attempt <= 3; attempt++ {
 		err = c.relay.Publish(ctx, event)
-		if err == nil {
+		if err == nil || attempt == 3 {
 			break
 		}
 
-		if strings.Contains(err.Error(), "connection closed") {
-			// Reconnect and retry
-			time.Sleep(1 * time.Second)
-			c.relay, _ = nostr.RelayConnect(ctx, c.relayURL)
-			continue
-		}
+		c.log.Warn().
+			Err(err).
+			Str("relay", c.relayURL).
+			Msg("failed to publish NWC request, failing over")
 
-		break
+		time.Sleep(1 * time.Second)
+		if ferr := c.failover(ctx); ferr != nil {
+			err = ferr
+			break
+		}
 	}
 
 	if err != nil {
-		logger.Log.Error().
+		c.log.Error().
 			Err(err).
 			Msg("failed to publish NWC request")
 		return nil, fmt.Errorf("failed to publish request: %w", err)
@@ -264,9 +561,13 @@ func (c *Client) sendRequest(ctx context.Context, req Request) (*Response, error
 
 	sub, err := c.relay.Subscribe(responseCtx, filters)
 	if err != nil {
-		logger.Log.Error().
+		c.log.Error().
 			Err(err).
+			Str("relay", c.relayURL).
 			Msg("failed to subscribe to wallet response")
+		// The request may already be with the wallet, so it is not resent;
+		// the next request goes to a working relay
+		_ = c.failover(ctx)
 		return nil, fmt.Errorf("failed to subscribe to response: %w", err)
 	}
 
@@ -274,7 +575,7 @@ func (c *Client) sendRequest(ctx context.Context, req Request) (*Response, error
 	case responseEvent := <-sub.Events:
 		decrypted, err := nip04.Decrypt(responseEvent.Content, sharedSecret)
 		if err != nil {
-			logger.Log.Error().
+			c.log.Error().
 				Err(err).
 				Msg("failed to decrypt wallet response")
 			return nil, fmt.Errorf("failed to decrypt response: %w", err)
@@ -282,7 +583,7 @@ func (c *Client) sendRequest(ctx context.Context, req Request) (*Response, error
 
 		var response Response
 		if err := json.Unmarshal([]byte(decrypted), &response); err != nil {
-			logger.Log.Error().
+			c.log.Error().
 				Err(err).
 				Msg("failed to parse wallet response")
 			return nil, fmt.Errorf("failed to parse response: %w", err)
@@ -291,7 +592,7 @@ func (c *Client) sendRequest(ctx context.Context, req Request) (*Response, error
 		return &response, nil
 
 	case <-responseCtx.Done():
-		logger.Log.Error().
+		c.log.Error().
 			Msg("timeout waiting for wallet response")
 		return nil, fmt.Errorf("timeout waiting for wallet response")
 	}
@@ -0,0 +1,143 @@
+// Package price converts fiat amounts to sats using a public BTC price API
+package price
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mistic0xb/pekka/config"
+	"github.com/mistic0xb/pekka/internal/logger"
+)
+
+const (
+	cacheTTL = 5 * time.Minute // reuse a fetched rate this long
+	maxStale = time.Hour       // fall back to a cached rate this old when fetching fails
+)
+
+// Converter turns fiat amounts into sats at the current BTC price
+type Converter struct {
+	source   string
+	currency string
+	client   *http.Client
+
+	mu        sync.Mutex
+	rate      float64 // fiat per BTC
+	fetchedAt time.Time
+}
+
+// NewConverter creates a converter for currency (e.g. "USD") using source
+func NewConverter(source, currency string) *Converter {
+	if source == "" {
+		source = config.FiatSourceCoinbase
+	}
+	return &Converter{
+		source:   source,
+		currency: strings.ToUpper(currency),
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// ToSats converts amount (in the converter's currency) to sats, rounding up
+// so a zap is never smaller than intended
+func (c *Converter) ToSats(ctx context.Context, amount float64) (int, error) {
+	rate, err := c.Rate(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return int(math.Ceil(amount / rate * 1e8)), nil
+}
+
+// Rate returns the BTC price, refreshing it when the cache is older than
+// cacheTTL. A failed refresh falls back to a rate up to maxStale old.
+func (c *Converter) Rate(ctx context.Context) (float64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.rate > 0 && time.Since(c.fetchedAt) < cacheTTL {
+		return c.rate, nil
+	}
+
+	rate, err := c.fetch(ctx)
+	if err == nil {
+		c.rate, c.fetchedAt = rate, time.Now()
+		logger.Ctx(ctx).Info().
+			Str("source", c.source).
+			Str("currency", c.currency).
+			Float64("rate", rate).
+			Msg("fetched BTC price")
+		return rate, nil
+	}
+
+	if c.rate > 0 && time.Since(c.fetchedAt) < maxStale {
+		logger.Ctx(ctx).Warn().
+			Err(err).
+			Float64("cached_rate", c.rate).
+			Dur("age", time.Since(c.fetchedAt)).
+			Msg("price fetch failed, using cached rate")
+		return c.rate, nil
+	}
+
+	return 0, fmt.Errorf("failed to fetch BTC/%s price: %w", c.currency, err)
+}
+
+// fetch asks the configured source for the current BTC price
+func (c *Converter) fetch(ctx context.Context) (float64, error) {
+	switch c.source {
+	case config.FiatSourceCoinbase:
+		var body struct {
+			Data struct {
+				Amount string `json:"amount"`
+			} `json:"data"`
+		}
+		if err := c.getJSON(ctx, "https://api.coinbase.com/v2/prices/BTC-"+c.currency+"/spot", &body); err != nil {
+			return 0, err
+		}
+		return parseRate(body.Data.Amount)
+	case config.FiatSourceMempool:
+		var body map[string]any
+		if err := c.getJSON(ctx, "https://mempool.space/api/v1/prices", &body); err != nil {
+			return 0, err
+		}
+		value, ok := body[c.currency].(float64)
+		if !ok {
+			return 0, fmt.Errorf("mempool has no %s price", c.currency)
+		}
+		return parseRate(strconv.FormatFloat(value, 'f', -1, 64))
+	default:
+		return 0, fmt.Errorf("unknown price source %q", c.source)
+	}
+}
+
+func (c *Converter) getJSON(ctx context.Context, url string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("price API returned status %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func parseRate(value string) (float64, error) {
+	rate, err := strconv.ParseFloat(value, 64)
+	if err != nil || rate <= 0 {
+		return 0, fmt.Errorf("invalid price %q", value)
+	}
+	return rate, nil
+}
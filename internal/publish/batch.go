@@ -0,0 +1,239 @@
+package publish
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mistic0xb/pekka/internal/logger"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/rs/zerolog"
+)
+
+// Options tune how a Batcher publishes
+type Options struct {
+	Window           time.Duration // coalesce events published within this window (0 publishes immediately)
+	RateLimitRetries int           // times to retry relays that answer rate-limited, with backoff
+	KeepBlocked      bool          // keep publishing to relays that answered blocked, restricted or auth-required
+}
+
+// Batcher publishes events for the whole session. Events published within a
+// short window are coalesced, so a burst of reactions connects to each relay
+// once and then writes every event over that connection instead of each
+// event setting up its own. Relays that refuse us for good are remembered and
+// skipped for the rest of the session.
+type Batcher struct {
+	pool *nostr.SimplePool
+	opts Options
+	log  *zerolog.Logger
+
+	mu      sync.Mutex
+	pending []*batchItem
+
+	blockedMu sync.Mutex
+	blocked   map[string]error // relay URL -> the rejection that blocked it
+}
+
+type batchItem struct {
+	ctx    context.Context
+	relays []string
+	event  nostr.Event
+	done   chan []RelayResult
+}
+
+// NewBatcher returns a batcher flushing every opts.Window. A window of 0 or
+// less publishes each event immediately. It logs to ctx's logger.
+func NewBatcher(ctx context.Context, pool *nostr.SimplePool, opts Options) *Batcher {
+	return &Batcher{pool: pool, opts: opts, log: logger.Ctx(ctx), blocked: make(map[string]error)}
+}
+
+// Publish sends event to relays and succeeds only if at least minSuccess of
+// them accepted it. Relays that could not be connected to are retried once
+// and rate-limited ones up to Options.RateLimitRetries times; relays blocked
+// earlier in the session count as failed without being contacted.
+func (b *Batcher) Publish(ctx context.Context, relays []string, event nostr.Event, minSuccess int) ([]RelayResult, error) {
+	if len(relays) == 0 {
+		return nil, fmt.Errorf("no relays to publish to")
+	}
+	minSuccess = max(minSuccess, 1)
+
+	usable, skipped := b.skipBlocked(relays)
+
+	var results []RelayResult
+	if len(usable) > 0 {
+		var err error
+		results, err = b.dispatch(ctx, usable, event)
+		if err != nil {
+			return nil, err
+		}
+		results = retryRateLimited(ctx, b.pool, results, event, b.opts.RateLimitRetries)
+		b.noteBlocked(results)
+	}
+	results = append(results, skipped...)
+
+	return results, evaluate(ctx, event, len(relays), results, minSuccess)
+}
+
+// dispatch publishes now, or queues the event for the next flush and waits
+// for its results
+func (b *Batcher) dispatch(ctx context.Context, relays []string, event nostr.Event) ([]RelayResult, error) {
+	if b.opts.Window <= 0 {
+		return publishNow(ctx, b.pool, relays, event), nil
+	}
+
+	item := &batchItem{
+		ctx:    ctx,
+		relays: relays,
+		event:  event,
+		done:   make(chan []RelayResult, 1),
+	}
+
+	b.mu.Lock()
+	b.pending = append(b.pending, item)
+	if len(b.pending) == 1 {
+		time.AfterFunc(b.opts.Window, b.flush)
+	}
+	b.mu.Unlock()
+
+	select {
+	case results := <-item.done:
+		return results, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// skipBlocked splits relays into those still in use and failed results for
+// those blocked earlier in the session
+func (b *Batcher) skipBlocked(relays []string) ([]string, []RelayResult) {
+	if b.opts.KeepBlocked {
+		return relays, nil
+	}
+
+	b.blockedMu.Lock()
+	defer b.blockedMu.Unlock()
+
+	var usable []string
+	var skipped []RelayResult
+	for _, url := range relays {
+		if reason, ok := b.blocked[url]; ok {
+			skipped = append(skipped, RelayResult{
+				URL:       url,
+				Err:       fmt.Errorf("skipped, blocked earlier this session: %w", reason),
+				Rejection: RejectionBlocked,
+			})
+			continue
+		}
+		usable = append(usable, url)
+	}
+	return usable, skipped
+}
+
+// noteBlocked stops using relays that answered blocked for the rest of the
+// session
+func (b *Batcher) noteBlocked(results []RelayResult) {
+	if b.opts.KeepBlocked {
+		return
+	}
+
+	b.blockedMu.Lock()
+	defer b.blockedMu.Unlock()
+
+	for _, r := range results {
+		if r.Rejection != RejectionBlocked {
+			continue
+		}
+		if _, ok := b.blocked[r.URL]; ok {
+			continue
+		}
+		b.blocked[r.URL] = r.Err
+		b.log.Warn().
+			Err(r.Err).
+			Str("relay", r.URL).
+			Msg("relay blocked our events, not publishing to it for the rest of the session")
+	}
+}
+
+// flush connects to every relay the queued events need, once, and publishes
+// the events concurrently over those connections
+func (b *Batcher) flush() {
+	b.mu.Lock()
+	items := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+
+	conns := b.connect(items)
+
+	b.log.Debug().
+		Int("events", len(items)).
+		Int("relays", len(conns)).
+		Msg("flushing publish batch")
+
+	for _, item := range items {
+		go func() {
+			results, unreachable := b.send(item, conns)
+			item.done <- append(results, retryUnreachable(item.ctx, b.pool, unreachable, item.event)...)
+		}()
+	}
+}
+
+// connect ensures one connection per distinct relay in the batch; relays
+// that could not be reached map to nil
+func (b *Batcher) connect(items []*batchItem) map[string]*nostr.Relay {
+	conns := make(map[string]*nostr.Relay)
+	for _, item := range items {
+		for _, url := range item.relays {
+			conns[url] = nil
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for url := range conns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			relay, err := b.pool.EnsureRelay(url)
+			if err != nil {
+				b.log.Debug().Err(err).Str("relay", url).Msg("batch relay connect failed")
+				return
+			}
+			mu.Lock()
+			conns[url] = relay
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return conns
+}
+
+// send publishes one item to its relays and returns their results, plus the
+// relays that could not be connected to
+func (b *Batcher) send(item *batchItem, conns map[string]*nostr.Relay) ([]RelayResult, []string) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var results []RelayResult
+	var unreachable []string
+
+	for _, url := range item.relays {
+		relay := conns[url]
+		if relay == nil {
+			unreachable = append(unreachable, url)
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := relay.Publish(item.ctx, item.event)
+			mu.Lock()
+			results = append(results, newResult(url, err, false))
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return results, unreachable
+}
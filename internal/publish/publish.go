@@ -0,0 +1,145 @@
+package publish
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mistic0xb/pekka/internal/logger"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// RelayResult holds the outcome of publishing to a single relay
+type RelayResult struct {
+	URL       string
+	Err       error
+	Retried   bool      // retried after failing to connect or being rate-limited
+	Rejection Rejection // why the relay refused the event, empty if it accepted
+}
+
+// connectRetryDelay is the pause before retrying relays that failed to
+// connect, and the first pause before retrying rate-limited ones
+const connectRetryDelay = 2 * time.Second
+
+// newResult classifies a relay's publish outcome
+func newResult(url string, err error, retried bool) RelayResult {
+	return RelayResult{URL: url, Err: err, Retried: retried, Rejection: Classify(err)}
+}
+
+// publishNow sends an event to all relays concurrently over the shared pool's
+// connections. Relays that could not be connected to are retried once; a
+// relay that answered with a rejection is not.
+func publishNow(ctx context.Context, pool *nostr.SimplePool, relays []string, event nostr.Event) []RelayResult {
+	results := make([]RelayResult, 0, len(relays))
+	var unreachable []string
+	for res := range pool.PublishMany(ctx, relays, event) {
+		if res.Error != nil && res.Relay == nil {
+			unreachable = append(unreachable, res.RelayURL)
+			continue
+		}
+		results = append(results, newResult(res.RelayURL, res.Error, false))
+	}
+	return append(results, retryUnreachable(ctx, pool, unreachable, event)...)
+}
+
+// retryUnreachable publishes once more to relays that could not be connected
+// to, after a short pause
+func retryUnreachable(ctx context.Context, pool *nostr.SimplePool, unreachable []string, event nostr.Event) []RelayResult {
+	if len(unreachable) == 0 {
+		return nil
+	}
+
+	logger.Ctx(ctx).Info().
+		Strs("relays", unreachable).
+		Str("event_id", event.ID).
+		Msg("retrying relays that failed to connect")
+
+	results := make([]RelayResult, 0, len(unreachable))
+	select {
+	case <-time.After(connectRetryDelay):
+		for res := range pool.PublishMany(ctx, unreachable, event) {
+			results = append(results, newResult(res.RelayURL, res.Error, true))
+		}
+	case <-ctx.Done():
+		for _, url := range unreachable {
+			results = append(results, newResult(url, fmt.Errorf("failed to connect: %w", ctx.Err()), true))
+		}
+	}
+	return results
+}
+
+// retryRateLimited publishes again to relays that answered rate-limited, up
+// to retries times, doubling the pause before each round
+func retryRateLimited(ctx context.Context, pool *nostr.SimplePool, results []RelayResult, event nostr.Event, retries int) []RelayResult {
+	backoff := connectRetryDelay
+	for attempt := 1; attempt <= retries; attempt++ {
+		index := make(map[string]int)
+		var limited []string
+		for i, r := range results {
+			if r.Rejection == RejectionRateLimited {
+				index[r.URL] = i
+				limited = append(limited, r.URL)
+			}
+		}
+		if len(limited) == 0 {
+			break
+		}
+
+		logger.Ctx(ctx).Info().
+			Strs("relays", limited).
+			Str("event_id", event.ID).
+			Int("attempt", attempt).
+			Dur("backoff", backoff).
+			Msg("relays rate-limited publish, retrying")
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return results
+		}
+
+		for res := range pool.PublishMany(ctx, limited, event) {
+			results[index[res.RelayURL]] = newResult(res.RelayURL, res.Error, true)
+		}
+		backoff *= 2
+	}
+	return results
+}
+
+// evaluate logs per-relay outcomes and fails unless minSuccess relays accepted
+func evaluate(ctx context.Context, event nostr.Event, attempted int, results []RelayResult, minSuccess int) error {
+	succeeded := 0
+	for _, r := range results {
+		if r.Err != nil {
+			logger.Ctx(ctx).Warn().
+				Err(r.Err).
+				Str("relay", r.URL).
+				Str("event_id", event.ID).
+				Int("kind", event.Kind).
+				Bool("retried", r.Retried).
+				Str("rejection", string(r.Rejection)).
+				Msg("relay rejected publish")
+			continue
+		}
+		succeeded++
+		logger.Ctx(ctx).Debug().
+			Str("relay", r.URL).
+			Str("event_id", event.ID).
+			Int("kind", event.Kind).
+			Msg("relay accepted publish")
+	}
+
+	logger.Ctx(ctx).Info().
+		Str("event_id", event.ID).
+		Int("kind", event.Kind).
+		Int("succeeded", succeeded).
+		Int("attempted", attempted).
+		Int("required", minSuccess).
+		Msg("publish summary")
+
+	if succeeded < minSuccess {
+		return fmt.Errorf("published to %d/%d relays, need at least %d", succeeded, attempted, minSuccess)
+	}
+
+	return nil
+}
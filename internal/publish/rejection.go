@@ -0,0 +1,41 @@
+package publish
+
+import "strings"
+
+// Rejection classifies why a relay refused an event, from the
+// machine-readable prefix of its OK message (NIP-01)
+type Rejection string
+
+const (
+	RejectionNone        Rejection = ""             // accepted, or no answer to classify
+	RejectionRateLimited Rejection = "rate-limited" // worth retrying after a pause
+	RejectionBlocked     Rejection = "blocked"      // blocked, restricted or auth-required: will keep refusing us
+	RejectionOther       Rejection = "other"        // any other refusal or failure
+)
+
+// okReason marks an OK=false answer in go-nostr's publish errors
+const okReason = "msg: "
+
+// Classify returns the rejection class of a publish error
+func Classify(err error) Rejection {
+	if err == nil {
+		return RejectionNone
+	}
+
+	msg := err.Error()
+	i := strings.Index(msg, okReason)
+	if i < 0 {
+		return RejectionOther
+	}
+
+	reason := strings.ToLower(strings.TrimSpace(msg[i+len(okReason):]))
+	prefix, _, _ := strings.Cut(reason, ":")
+	switch strings.TrimSpace(prefix) {
+	case "rate-limited":
+		return RejectionRateLimited
+	case "blocked", "restricted", "auth-required":
+		return RejectionBlocked
+	default:
+		return RejectionOther
+	}
+}
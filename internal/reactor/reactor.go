@@ -3,68 +3,143 @@ package reaction
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
 
 	"github.com/mistic0xb/pekka/config"
-	"github.com/mistic0xb/pekka/internal/bunker"
+	"github.com/mistic0xb/pekka/internal/clock"
+	"github.com/mistic0xb/pekka/internal/publish"
+	"github.com/mistic0xb/pekka/internal/signer"
 	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
 )
 
-// React creates and publishes a reaction (kind 7) to an event
-func React(ctx context.Context, eventID, authorPubkey string, cfg *config.ReactionConfig, bunkerClient *bunker.ReconnectingClient, relays []string) error {
-	if !cfg.Enabled {
-		return nil // Reactions disabled
-	}
+// MaxContentLength caps rendered reaction content (in characters), well under
+// the content limits relays commonly advertise
+const MaxContentLength = 280
+
+// ReactResult summarizes how the relays handled a published reaction
+type ReactResult struct {
+	EventID   string            // ID of the kind 7 reaction event
+	Attempted int               // relays the reaction was sent to
+	Succeeded int               // relays that accepted it
+	Failed    int               // relays that rejected it or could not be reached
+	Retried   int               // relays retried after failing to connect or being rate-limited
+	Failures  map[string]string // relay URL -> rejection reason, after any retry
+}
 
-	// Get our pubkey from bunker
-	ourPubkey, err := bunkerClient.GetPublicKey(ctx)
+// FailedRelays returns the relays that ultimately did not accept the reaction
+func (r *ReactResult) FailedRelays() []string {
+	return slices.Sorted(maps.Keys(r.Failures))
+}
+
+// React creates and publishes a reaction (kind 7) to an event. The result is
+// returned whenever publishing was attempted, even if too few relays accepted.
+func React(ctx context.Context, eventID, authorPubkey string, emoji config.ReactionEmoji, eventSigner signer.Signer, publisher *publish.Batcher, relays []string, minSuccess int) (*ReactResult, error) {
+	// Get our pubkey from the signer
+	ourPubkey, err := signer.PublicKey(ctx, eventSigner)
 	if err != nil {
-		return fmt.Errorf("failed to get pubkey: %w", err)
+		return nil, fmt.Errorf("failed to get pubkey: %w", err)
 	}
 
 	// Create reaction event (kind 7)
 	reaction := nostr.Event{
 		PubKey:    ourPubkey,
-		CreatedAt: nostr.Now(),
+		CreatedAt: clock.Ctx(ctx).Now(),
 		Kind:      7,
 		Tags: nostr.Tags{
 			{"e", eventID},      // Event being reacted to
 			{"p", authorPubkey}, // Author of the event
 			{"k", "1"},          // Kind of event being reacted to
 		},
-		Content: cfg.Content, //":catJAM:" or "🔥"
+		Content: renderContent(emoji.Content, authorPubkey), //":catJAM:" or "🔥"
 	}
 
 	// Add custom emoji tag if provided
-	if cfg.EmojiName != "" && cfg.EmojiURL != "" {
-		reaction.Tags = append(reaction.Tags, nostr.Tag{"emoji", cfg.EmojiName, cfg.EmojiURL})
+	if emoji.EmojiName != "" && emoji.EmojiURL != "" {
+		reaction.Tags = append(reaction.Tags, nostr.Tag{"emoji", emoji.EmojiName, emoji.EmojiURL})
 	}
 
 	// Calculate event ID
 	reaction.ID = reaction.GetID()
 
-	// Sign with bunker
-	if err := bunkerClient.SignEvent(ctx, &reaction); err != nil {
-		return fmt.Errorf("failed to sign reaction: %w", err)
+	// Sign with the configured signer
+	if err := eventSigner.SignEvent(ctx, &reaction); err != nil {
+		return nil, fmt.Errorf("failed to sign reaction: %w", err)
 	}
 
 	// Publish to relays
-	publishedCount := 0
-	for _, relayURL := range relays {
-		relay, err := nostr.RelayConnect(ctx, relayURL)
-		if err != nil {
+	results, err := publisher.Publish(ctx, relays, reaction, minSuccess)
+	result := summarize(reaction.ID, relays, results)
+	if err != nil {
+		return result, fmt.Errorf("failed to publish reaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// summarize counts per-relay publish outcomes. Relays that never reported
+// back (e.g. the context expired first) count as failed.
+func summarize(eventID string, relays []string, results []publish.RelayResult) *ReactResult {
+	result := &ReactResult{
+		EventID:   eventID,
+		Attempted: len(relays),
+		Failures:  make(map[string]string),
+	}
+
+	reported := make(map[string]bool, len(results))
+	for _, r := range results {
+		reported[r.URL] = true
+		if r.Retried {
+			result.Retried++
+		}
+		if r.Err != nil {
+			result.Failures[r.URL] = r.Err.Error()
 			continue
 		}
+		result.Succeeded++
+	}
+	result.Failed = result.Attempted - result.Succeeded
 
-		if err := relay.Publish(ctx, reaction); err == nil {
-			publishedCount++
+	for _, url := range relays {
+		if !reported[url] {
+			result.Failures[url] = "no response"
 		}
+	}
+
+	return result
+}
 
-		relay.Close()
+// renderContent fills in placeholders in the reaction content. {author}
+// becomes a NIP-27 mention of the note's author.
+func renderContent(content, authorPubkey string) string {
+	if strings.Contains(content, "{author}") {
+		mention := authorPubkey
+		if npub, err := nip19.EncodePublicKey(authorPubkey); err == nil {
+			mention = "nostr:" + npub
+		}
+		content = strings.ReplaceAll(content, "{author}", mention)
+	}
+
+	runes := []rune(content)
+	if len(runes) <= MaxContentLength {
+		return content
 	}
 
-	if publishedCount == 0 {
-		return fmt.Errorf("failed to publish reaction to any relay")
+	// Cut before a word that straddles the limit if it holds a nostr:
+	// mention, half an npub would render as garbage
+	start, end := MaxContentLength, MaxContentLength
+	for start > 0 && !unicode.IsSpace(runes[start-1]) {
+		start--
+	}
+	for end < len(runes) && !unicode.IsSpace(runes[end]) {
+		end++
+	}
+	if strings.Contains(string(runes[start:end]), "nostr:") {
+		return strings.TrimRightFunc(string(runes[:start]), unicode.IsSpace)
 	}
 
-	return nil
+	return string(runes[:MaxContentLength])
 }
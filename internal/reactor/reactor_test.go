@@ -0,0 +1,47 @@
+package reaction
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+func TestRenderContentKeepsMentionsWhole(t *testing.T) {
+	const author = "3bf0c63fcb93463407af97a5e5ee64fa883d107ef9e558472c4eb9aaaefa459d"
+	npub, _ := nip19.EncodePublicKey(author)
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "short content",
+			content: "gm {author}",
+			want:    "gm nostr:" + npub,
+		},
+		{
+			name:    "mention across the limit is dropped",
+			content: strings.Repeat("a", 250) + " {author}",
+			want:    strings.Repeat("a", 250),
+		},
+		{
+			name:    "mention before the limit is kept",
+			content: "{author} " + strings.Repeat("b", 300),
+			want:    ("nostr:" + npub + " " + strings.Repeat("b", 300))[:MaxContentLength],
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderContent(tt.content, author)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if n := len([]rune(got)); n > MaxContentLength {
+				t.Errorf("got %d runes, want at most %d", n, MaxContentLength)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package relaycheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mistic0xb/pekka/internal/logger"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// ErrNoRelays is returned by Require when not a single relay connected
+var ErrNoRelays = errors.New("could not connect to any relay")
+
+// Result is the outcome of connecting to a set of relays
+type Result struct {
+	Connected []string
+	Failed    map[string]error // relay URL -> connection error
+}
+
+// Connect opens a connection to every relay through the pool, in parallel,
+// so later subscriptions reuse them. Each relay gets the pool's own connect
+// timeout (15s). Results are logged to ctx's logger.
+func Connect(ctx context.Context, pool *nostr.SimplePool, relayURLs []string) Result {
+	result := Result{Failed: make(map[string]error)}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, url := range relayURLs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := pool.EnsureRelay(url)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.Failed[url] = err
+				return
+			}
+			result.Connected = append(result.Connected, url)
+		}()
+	}
+	wg.Wait()
+
+	for url, err := range result.Failed {
+		logger.Ctx(ctx).Warn().Err(err).Str("relay", url).Msg("relay unreachable")
+	}
+	logger.Ctx(ctx).Info().
+		Int("connected", len(result.Connected)).
+		Int("total", len(relayURLs)).
+		Msg("relay connectivity checked")
+
+	return result
+}
+
+// Require connects to the relays and fails with ErrNoRelays, naming every
+// relay and why it failed, when none of them could be reached
+func Require(ctx context.Context, pool *nostr.SimplePool, relayURLs []string) (Result, error) {
+	result := Connect(ctx, pool, relayURLs)
+	if len(result.Connected) > 0 || len(relayURLs) == 0 {
+		return result, nil
+	}
+
+	reasons := make([]string, 0, len(relayURLs))
+	for _, url := range relayURLs {
+		reasons = append(reasons, fmt.Sprintf("%s (%v)", url, result.Failed[url]))
+	}
+	return result, fmt.Errorf("%w: %s", ErrNoRelays, strings.Join(reasons, ", "))
+}
@@ -0,0 +1,110 @@
+package signer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mistic0xb/pekka/config"
+	"github.com/mistic0xb/pekka/internal/bunker"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+// Signer signs events on behalf of an identity. The bunker client satisfies
+// it directly.
+type Signer interface {
+	GetPublicKey(ctx context.Context) (string, error)
+	SignEvent(ctx context.Context, event *nostr.Event) error
+}
+
+// New returns the signer for kind (one of the config.Signer* values).
+// bunkerClient is only used (and required)
+// for the bunker kind and nsec only for the local kind.
+func New(kind string, bunkerClient *bunker.ReconnectingClient, nsec string) (Signer, error) {
+	switch kind {
+	case "", config.SignerBunker:
+		if bunkerClient == nil {
+			return nil, fmt.Errorf("bunker signer requires a bunker connection")
+		}
+		return bunkerClient, nil
+	case config.SignerAnon:
+		return Anon{}, nil
+	case config.SignerLocal:
+		return NewLocal(nsec)
+	default:
+		return nil, fmt.Errorf("unknown signer %q", kind)
+	}
+}
+
+// PublicKey asks s for its pubkey and rejects anything that is not a
+// 64-character hex key, which would otherwise produce events relays reject
+func PublicKey(ctx context.Context, s Signer) (string, error) {
+	pubkey, err := s.GetPublicKey(ctx)
+	if err != nil {
+		return "", err
+	}
+	if !nostr.IsValidPublicKey(pubkey) {
+		return "", fmt.Errorf("%w: %q", bunker.ErrInvalidPubkey, pubkey)
+	}
+	return pubkey, nil
+}
+
+// IsAnonymous reports whether s signs with throwaway keys
+func IsAnonymous(s Signer) bool {
+	_, ok := s.(Anon)
+	return ok
+}
+
+// Anon signs every event with a freshly generated key, so nothing it signs
+// can be linked to the user or to other anonymous events
+type Anon struct{}
+
+// GetPublicKey returns a fresh random pubkey. SignEvent replaces it, so the
+// value only serves callers that fill in PubKey before signing.
+func (Anon) GetPublicKey(ctx context.Context) (string, error) {
+	return nostr.GetPublicKey(nostr.GeneratePrivateKey())
+}
+
+// SignEvent signs with a new key, overwriting the event's PubKey and ID
+func (Anon) SignEvent(ctx context.Context, event *nostr.Event) error {
+	return event.Sign(nostr.GeneratePrivateKey())
+}
+
+// Local signs with a private key held in memory
+type Local struct {
+	secretKey string
+	publicKey string
+}
+
+// NewLocal creates a local signer from an nsec
+func NewLocal(nsec string) (*Local, error) {
+	if nsec == "" {
+		return nil, fmt.Errorf("local signer requires author.nsec")
+	}
+
+	prefix, data, err := nip19.Decode(nsec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid author.nsec: %w", err)
+	}
+	if prefix != "nsec" {
+		return nil, fmt.Errorf("author.nsec: expected nsec prefix, got %s", prefix)
+	}
+
+	secretKey := data.(string)
+	publicKey, err := nostr.GetPublicKey(secretKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid author.nsec: %w", err)
+	}
+
+	return &Local{secretKey: secretKey, publicKey: publicKey}, nil
+}
+
+// GetPublicKey returns the local key's pubkey
+func (l *Local) GetPublicKey(ctx context.Context) (string, error) {
+	return l.publicKey, nil
+}
+
+// SignEvent signs the event with the local key
+func (l *Local) SignEvent(ctx context.Context, event *nostr.Event) error {
+	return event.Sign(l.secretKey)
+}
@@ -0,0 +1,28 @@
+package ui
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+type outputKey struct{}
+
+// WithOutput returns a copy of ctx whose console output goes to w
+func WithOutput(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, outputKey{}, w)
+}
+
+// Output returns where console output for ctx goes, stdout by default
+func Output(ctx context.Context) io.Writer {
+	if w, ok := ctx.Value(outputKey{}).(io.Writer); ok {
+		return w
+	}
+	return os.Stdout
+}
+
+// OnTerminal reports whether ctx's output is stdout. Spinners and the banner
+// are only shown there, never in output an embedding program captures.
+func OnTerminal(ctx context.Context) bool {
+	return Output(ctx) == io.Writer(os.Stdout)
+}
@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// IsInteractive reports whether stdin is attached to a terminal
+func IsInteractive() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// ReadLine reads one line from stdin, giving up when ctx is done. The read
+// itself cannot be interrupted, so on cancellation it is left to finish in
+// the background and its input is discarded.
+func ReadLine(ctx context.Context) (string, error) {
+	line := make(chan string, 1)
+	go func() {
+		input, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		line <- input
+	}()
+
+	select {
+	case input := <-line:
+		return strings.TrimSpace(input), nil
+	case <-ctx.Done():
+		fmt.Println()
+		return "", ctx.Err()
+	}
+}
+
+// Prompter asks the user to confirm decisions the config leaves open
+type Prompter interface {
+	// Interactive reports whether there is anyone to ask
+	Interactive() bool
+	// Confirm asks a yes/no question and reports whether the answer was yes
+	Confirm(question string) bool
+}
+
+// Terminal prompts on stdin, when it is a terminal
+type Terminal struct{}
+
+func (Terminal) Interactive() bool { return IsInteractive() }
+
+func (Terminal) Confirm(question string) bool { return Confirm(question) }
+
+// NoPrompts never asks, so callers always take their non-interactive path
+type NoPrompts struct{}
+
+func (NoPrompts) Interactive() bool { return false }
+
+func (NoPrompts) Confirm(string) bool { return false }
+
+// Confirm asks a yes/no question on stdin and reports whether the answer was yes
+func Confirm(question string) bool {
+	fmt.Printf("%s (y/n): ", question)
+
+	reader := bufio.NewReader(os.Stdin)
+	input, _ := reader.ReadString('\n')
+	input = strings.TrimSpace(strings.ToLower(input))
+
+	return input == "y" || input == "yes"
+}
@@ -0,0 +1,29 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// escapeSequence matches ANSI CSI and OSC sequences as well as lone escapes
+var escapeSequence = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)?|\x1b.?`)
+
+// Sanitize makes untrusted text safe to print to a terminal or write to a
+// log: escape sequences and control characters are removed, line breaks and
+// tabs become spaces, and bidirectional overrides are dropped so content
+// cannot reorder or fake surrounding output.
+func Sanitize(s string) string {
+	s = escapeSequence.ReplaceAllString(s, "")
+
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\r' || r == '\t':
+			return ' '
+		case unicode.IsControl(r), unicode.Is(unicode.Bidi_Control, r):
+			return -1
+		default:
+			return r
+		}
+	}, strings.ToValidUTF8(s, string(unicode.ReplacementChar)))
+}
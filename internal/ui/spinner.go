@@ -21,6 +21,11 @@ func NewSpinner(msg string, charset int, color string) *Spinner {
 	return &Spinner{spinner: s}
 }
 
+// Stop stops the spinner. A nil Spinner, for output where none is shown, is
+// a no-op.
 func (s *Spinner) Stop() {
+	if s == nil {
+		return
+	}
 	s.spinner.Stop()
 }
@@ -0,0 +1,9 @@
+package ui
+
+// Console verbosity levels, raised with -v and -vv. They only affect what the
+// bot prints to stdout, the file log level is separate.
+const (
+	LevelNormal   = iota // zaps, reactions and errors
+	LevelDecision        // also skipped notes and budget decisions
+	LevelDetail          // also every incoming note as it arrives
+)
@@ -0,0 +1,92 @@
+package version
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ReleasesURL is the GitHub API endpoint for the latest published release
+const ReleasesURL = "https://api.github.com/repos/mistic0xb/pekka/releases/latest"
+
+// Latest fetches the tag of the latest release from a GitHub-style releases
+// endpoint (a JSON object with a "tag_name" field)
+func Latest(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("release check returned status %d", resp.StatusCode)
+	}
+
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", fmt.Errorf("failed to parse release: %w", err)
+	}
+	if release.TagName == "" {
+		return "", fmt.Errorf("release has no tag")
+	}
+
+	return release.TagName, nil
+}
+
+// IsNewer reports whether latest is a higher version than current. Both are
+// compared as dotted numbers with an optional "v" prefix; ok is false when
+// either cannot be parsed (e.g. a "dev" build).
+func IsNewer(latest, current string) (newer bool, ok bool) {
+	l, lok := parse(latest)
+	c, cok := parse(current)
+	if !lok || !cok {
+		return false, false
+	}
+
+	for i := range max(len(l), len(c)) {
+		var lv, cv int
+		if i < len(l) {
+			lv = l[i]
+		}
+		if i < len(c) {
+			cv = c[i]
+		}
+		if lv != cv {
+			return lv > cv, true
+		}
+	}
+	return false, true
+}
+
+// parse splits "v1.2.3" (ignoring any -pre or +build suffix) into numbers
+func parse(v string) ([]int, bool) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	if v == "" {
+		return nil, false
+	}
+
+	parts := strings.Split(v, ".")
+	nums := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		nums[i] = n
+	}
+	return nums, true
+}
@@ -0,0 +1,129 @@
+// Package webhook posts bot events as JSON to an HTTP endpoint
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mistic0xb/pekka/internal/logger"
+	"github.com/rs/zerolog"
+)
+
+// IdempotencyHeader carries Delivery.IdempotencyKey, so a receiver can drop
+// retries of a delivery it already handled
+const IdempotencyHeader = "Idempotency-Key"
+
+// Delivery types
+const (
+	TypeZap           = "zap"            // sent after each zap
+	TypeLifetimeLimit = "lifetime_limit" // sent once budget.lifetime_limit stops the bot zapping
+)
+
+// DefaultTimeout is used when no per-attempt timeout is set
+const DefaultTimeout = 10 * time.Second
+
+// Delivery is the JSON body posted to the webhook
+type Delivery struct {
+	IdempotencyKey string `json:"idempotency_key"` // same on every retry, also sent as IdempotencyHeader
+	Type           string `json:"type"`
+	EventID        string `json:"event_id,omitempty"`       // the zapped note
+	Author         string `json:"author,omitempty"`         // hex pubkey of the note's author
+	Payee          string `json:"payee,omitempty"`          // hex pubkey paid, when not the author
+	AmountSats     int    `json:"amount_sats,omitempty"`    // sats actually zapped
+	ZapRequestID   string `json:"zap_request_id,omitempty"` // kind 9734 event ID
+	SpentSats      int    `json:"spent_sats,omitempty"`     // lifetime_limit: sats spent in total
+	LimitSats      int    `json:"limit_sats,omitempty"`     // lifetime_limit: budget.lifetime_limit
+	Shadow         bool   `json:"shadow"`                   // recorded in shadow mode, not paid
+	CreatedAt      int64  `json:"created_at"`               // unix seconds the delivery was created
+}
+
+// Sender delivers to one webhook URL
+type Sender struct {
+	url     string
+	retries int
+	client  *http.Client
+	log     *zerolog.Logger
+}
+
+// New returns a Sender posting to url that retries a failed delivery up to
+// retries times. It logs to ctx's logger.
+func New(ctx context.Context, url string, retries int, timeout time.Duration) *Sender {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Sender{
+		url:     url,
+		retries: retries,
+		client:  &http.Client{Timeout: timeout},
+		log:     logger.Ctx(ctx),
+	}
+}
+
+// Send posts d, retrying after 1s, 2s, 4s, ... when the request fails, the
+// receiver answers 429 or a 5xx. Every attempt sends the same body and
+// idempotency key. Cancelling ctx stops the retries.
+func (s *Sender) Send(ctx context.Context, d Delivery) error {
+	body, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook delivery: %w", err)
+	}
+
+	backoff := time.Second
+	for attempt := 1; ; attempt++ {
+		retry, err := s.post(ctx, d.IdempotencyKey, body)
+		if err == nil {
+			s.log.Info().
+				Str("idempotency_key", d.IdempotencyKey).
+				Int("attempt", attempt).
+				Msg("webhook delivered")
+			return nil
+		}
+
+		s.log.Warn().
+			Err(err).
+			Str("idempotency_key", d.IdempotencyKey).
+			Int("attempt", attempt).
+			Msg("webhook delivery failed")
+
+		if !retry || attempt > s.retries {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// post makes one delivery attempt and reports whether a failure is worth
+// retrying
+func (s *Sender) post(ctx context.Context, key string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("invalid webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IdempotencyHeader, key)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, fmt.Errorf("webhook request failed: %w", err)
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+}
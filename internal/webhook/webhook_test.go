@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSendRetriesWithSameKey(t *testing.T) {
+	var headers, bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var d Delivery
+		if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		headers = append(headers, r.Header.Get(IdempotencyHeader))
+		bodies = append(bodies, d.IdempotencyKey)
+
+		if len(headers) < 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	sender := New(context.Background(), server.URL, 2, 0)
+	if err := sender.Send(context.Background(), Delivery{IdempotencyKey: "zap-request-id", Type: TypeZap}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if len(headers) != 2 {
+		t.Fatalf("got %d attempts, want 2", len(headers))
+	}
+	for i := range headers {
+		if headers[i] != "zap-request-id" || bodies[i] != "zap-request-id" {
+			t.Errorf("attempt %d: header %q, body %q, want both zap-request-id", i+1, headers[i], bodies[i])
+		}
+	}
+}
+
+func TestSendDoesNotRetryClientErrors(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	sender := New(context.Background(), server.URL, 3, 0)
+	if err := sender.Send(context.Background(), Delivery{IdempotencyKey: "key"}); err == nil {
+		t.Fatal("expected an error for a 400 response")
+	}
+	if attempts != 1 {
+		t.Errorf("got %d attempts, want 1", attempts)
+	}
+}
@@ -0,0 +1,31 @@
+package zap
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors returned (wrapped) by the zap pipeline so callers can
+// branch with errors.Is instead of matching strings
+var (
+	ErrNoLightningAddress = errors.New("no lightning address")
+	ErrProfileNotFound    = errors.New("profile not found")
+	ErrAmountOutOfBounds  = errors.New("amount out of bounds")
+	ErrLNURLUnavailable   = errors.New("LNURL endpoint unavailable")
+	ErrInvoiceMismatch    = errors.New("invoice does not match the requested amount")
+	ErrPaymentFailed      = errors.New("payment failed")
+	ErrSigningFailed      = errors.New("signing failed")
+	ErrNoRelays           = errors.New("no relays configured")
+)
+
+// wrap tags cause with a sentinel while keeping both in the chain
+func wrap(sentinel, cause error) error {
+	return fmt.Errorf("%w: %w", sentinel, cause)
+}
+
+// IsPermanent reports whether retrying the zap cannot succeed.
+// ErrProfileNotFound is not: relays may just have missed the profile.
+func IsPermanent(err error) bool {
+	return errors.Is(err, ErrNoLightningAddress) || errors.Is(err, ErrAmountOutOfBounds) ||
+		errors.Is(err, ErrInvoiceMismatch) || errors.Is(err, ErrNoRelays)
+}
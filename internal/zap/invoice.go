@@ -0,0 +1,59 @@
+package zap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// msatsPerUnit maps a BOLT11 amount multiplier to millisats per unit
+var msatsPerUnit = map[byte]int64{
+	'm': 100_000_000,
+	'u': 100_000,
+	'n': 100,
+}
+
+// InvoiceAmount decodes the amount in millisats from a bolt11 invoice's
+// human-readable part. Invoices without an amount return 0.
+func InvoiceAmount(invoice string) (int64, error) {
+	invoice = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(invoice), "lightning:"))
+
+	sep := strings.LastIndexByte(invoice, '1')
+	if sep < 0 || !strings.HasPrefix(invoice, "ln") {
+		return 0, fmt.Errorf("not a bolt11 invoice")
+	}
+
+	// Skip the currency prefix (bc, tb, bcrt, ...) to reach the amount
+	hrp := invoice[2:sep]
+	start := strings.IndexAny(hrp, "0123456789")
+	if start < 0 {
+		return 0, nil
+	}
+	amount := hrp[start:]
+
+	multiplier := amount[len(amount)-1]
+	digits := amount
+	if multiplier < '0' || multiplier > '9' {
+		digits = amount[:len(amount)-1]
+	}
+
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid invoice amount %q", amount)
+	}
+
+	switch {
+	case digits == amount:
+		return n * 100_000_000_000, nil
+	case multiplier == 'p':
+		// Pico-bitcoin must land on whole millisats
+		if n%10 != 0 {
+			return 0, fmt.Errorf("invalid invoice amount %q", amount)
+		}
+		return n / 10, nil
+	case msatsPerUnit[multiplier] > 0:
+		return n * msatsPerUnit[multiplier], nil
+	default:
+		return 0, fmt.Errorf("invalid invoice multiplier %q", multiplier)
+	}
+}
@@ -0,0 +1,118 @@
+package zap
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+const (
+	outboxCacheTTL     = time.Hour       // how long a recipient's relay list is reused
+	outboxFetchTimeout = 5 * time.Second // per kind 10002 lookup
+	maxRecipientRelays = 4               // recipient relays added to a zap request at most
+)
+
+// outboxCache remembers recipients' NIP-65 write relays, including the
+// absence of a list, so a busy author is looked up once an hour at most
+type outboxCache struct {
+	mu      sync.Mutex
+	entries map[string]outboxEntry
+}
+
+type outboxEntry struct {
+	relays    []string
+	fetchedAt time.Time
+}
+
+// recipientRelays returns the write relays from pubkey's kind 10002 relay
+// list, or nil when they have none (or it could not be fetched)
+func (z *Zapper) recipientRelays(ctx context.Context, pubkey string) []string {
+	z.outbox.mu.Lock()
+	entry, ok := z.outbox.entries[pubkey]
+	z.outbox.mu.Unlock()
+	if ok && time.Since(entry.fetchedAt) < outboxCacheTTL {
+		return entry.relays
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, outboxFetchTimeout)
+	defer cancel()
+
+	var latest *nostr.Event
+	for event := range z.pool.FetchMany(fetchCtx, z.relays.Read, nostr.Filter{
+		Kinds:   []int{10002},
+		Authors: []string{pubkey},
+		Limit:   1,
+	}) {
+		if latest == nil || event.CreatedAt > latest.CreatedAt {
+			latest = event.Event
+		}
+	}
+
+	var relays []string
+	if latest != nil {
+		relays = writeRelays(latest)
+	}
+	if ctx.Err() != nil {
+		return relays // cancelled, not a real answer; don't cache
+	}
+
+	z.log.Debug().
+		Str("pubkey", pubkey).
+		Strs("relays", relays).
+		Msg("fetched recipient relay list")
+
+	z.outbox.mu.Lock()
+	if z.outbox.entries == nil {
+		z.outbox.entries = make(map[string]outboxEntry)
+	}
+	z.outbox.entries[pubkey] = outboxEntry{relays: relays, fetchedAt: time.Now()}
+	z.outbox.mu.Unlock()
+
+	return relays
+}
+
+// writeRelays extracts the write relays of a NIP-65 list: "r" tags marked
+// "write" or without a marker (both read and write)
+func writeRelays(list *nostr.Event) []string {
+	var relays []string
+	for tag := range list.Tags.FindAll("r") {
+		if len(tag) >= 3 && tag[2] != "write" {
+			continue
+		}
+		if url := nostr.NormalizeURL(tag[1]); nostr.IsValidRelayURL(url) && !slices.Contains(relays, url) {
+			relays = append(relays, url)
+		}
+	}
+	return relays
+}
+
+// receiptRelays returns the relays for the zap request's "relays" tag: ours,
+// plus a few of the recipient's write relays when enabled
+func (z *Zapper) receiptRelays(ctx context.Context, recipient string) []string {
+	relays := slices.Clone(z.relays.Write)
+	if !z.relays.Recipient {
+		return relays
+	}
+
+	added := 0
+	for _, url := range z.recipientRelays(ctx, recipient) {
+		if added == maxRecipientRelays {
+			break
+		}
+		if !slices.ContainsFunc(relays, func(r string) bool { return nostr.NormalizeURL(r) == url }) {
+			relays = append(relays, url)
+			added++
+		}
+	}
+
+	if added > 0 {
+		z.log.Info().
+			Str("recipient", recipient).
+			Int("added", added).
+			Msg("added recipient relays to zap request")
+	}
+	return relays
+}
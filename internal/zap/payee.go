@@ -0,0 +1,62 @@
+package zap
+
+import (
+	"strings"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// payee is who a zap is paid to
+type payee struct {
+	pubkey  string // goes in the zap request's "p" tag
+	address string // lightning address; empty means resolve it from pubkey's profile
+}
+
+// resolvePayee honors a single NIP-57 "zap" tag on the note, so the zap goes
+// where the author asked. Notes without one, or with several (zap splits,
+// not supported), are paid to the author as usual.
+func (z *Zapper) resolvePayee(target *nostr.Event) payee {
+	author := payee{pubkey: target.PubKey}
+
+	var tags []nostr.Tag
+	for tag := range target.Tags.FindAll("zap") {
+		tags = append(tags, tag)
+	}
+
+	switch {
+	case len(tags) == 0:
+		return author
+	case len(tags) > 1:
+		z.log.Info().
+			Str("event_id", target.ID).
+			Int("zap_tags", len(tags)).
+			Msg("zap splits not supported, paying the author")
+		return author
+	}
+
+	tag := tags[0]
+	var p payee
+	switch {
+	case nostr.IsValidPublicKey(tag[1]):
+		p = payee{pubkey: tag[1]}
+	case strings.Contains(tag[1], "@"):
+		// Older form: ["zap", "name@domain", "lud16"]
+		p = payee{pubkey: target.PubKey, address: tag[1]}
+	default:
+		z.log.Warn().
+			Str("event_id", target.ID).
+			Str("zap_tag", tag[1]).
+			Msg("ignoring invalid zap tag, paying the author")
+		return author
+	}
+
+	if p != author {
+		z.log.Info().
+			Str("event_id", target.ID).
+			Str("author", target.PubKey).
+			Str("payee", p.pubkey).
+			Str("address", p.address).
+			Msg("note's zap tag names a different payee")
+	}
+	return p
+}
@@ -0,0 +1,72 @@
+package zap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// Receipt is a kind 9735 zap receipt matched to one of our zap requests
+type Receipt struct {
+	ID     string
+	Bolt11 string
+}
+
+// WaitForReceipt subscribes to zap receipts for eventID and returns the first
+// one whose embedded zap request matches zapRequestID
+func (z *Zapper) WaitForReceipt(ctx context.Context, eventID, zapRequestID string) (*Receipt, error) {
+	since := z.clock.Now() - 60
+	filter := nostr.Filter{
+		Kinds: []int{9735},
+		Tags:  nostr.TagMap{"e": []string{eventID}},
+		Since: &since,
+	}
+
+	z.log.Debug().
+		Str("event_id", eventID).
+		Str("zap_request_id", zapRequestID).
+		Msg("waiting for zap receipt")
+
+	for ev := range z.pool.SubscribeMany(ctx, z.relays.Write, filter) {
+		if receipt := z.matchReceipt(ev.Event, zapRequestID); receipt != nil {
+			z.log.Info().
+				Str("event_id", eventID).
+				Str("receipt_id", receipt.ID).
+				Str("relay", ev.Relay.URL).
+				Msg("zap receipt found")
+			return receipt, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no zap receipt found for zap request %s", zapRequestID)
+}
+
+// matchReceipt returns the receipt if its description tag embeds our zap request
+func (z *Zapper) matchReceipt(event *nostr.Event, zapRequestID string) *Receipt {
+	description := event.Tags.Find("description")
+	if description == nil {
+		return nil
+	}
+
+	var zapRequest nostr.Event
+	if err := json.Unmarshal([]byte(description[1]), &zapRequest); err != nil {
+		z.log.Debug().
+			Err(err).
+			Str("receipt_id", event.ID).
+			Msg("failed to parse zap receipt description")
+		return nil
+	}
+
+	if zapRequest.ID != zapRequestID {
+		return nil
+	}
+
+	receipt := &Receipt{ID: event.ID}
+	if bolt11 := event.Tags.Find("bolt11"); bolt11 != nil {
+		receipt.Bolt11 = bolt11[1]
+	}
+
+	return receipt
+}
@@ -3,35 +3,73 @@ package zap
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/mistic0xb/pekka/internal/bunker"
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/mistic0xb/pekka/internal/clock"
 	"github.com/mistic0xb/pekka/internal/logger"
 	"github.com/mistic0xb/pekka/internal/nwc"
+	"github.com/mistic0xb/pekka/internal/signer"
+	"github.com/mistic0xb/pekka/internal/ui"
 	"github.com/nbd-wtf/go-nostr"
+	"github.com/rs/zerolog"
 )
 
+// Zap is a prepared zap: the signed zap request and the invoice to pay
+type Zap struct {
+	RequestID string // ID of the kind 9734 zap request event
+	Invoice   string // bolt11 invoice returned by the LNURL callback
+	Amount    int    // Sats actually requested, higher than asked when bumped to the LNURL minimum
+	Payee     string // Pubkey paid, differs from the note author when the note has a zap tag
+	Preimage  string // Returned by the wallet on payment, proof it settled; empty if not returned
+}
+
+// SignPolicy controls how long signing a zap request may take. Remote
+// signers that need manual approval often miss the first deadline while the
+// user unlocks their phone; GraceRetry asks once more on a timeout.
+type SignPolicy struct {
+	Timeout    time.Duration // per attempt (0 uses the signer's own timeout)
+	GraceRetry bool
+}
+
+// Relays are the relays a Zapper uses: profiles are read from Read, and
+// Write goes in the zap request so receipts are published (and looked for) there
+type Relays struct {
+	Read  []string
+	Write []string
+
+	Recipient bool // also list a few of the recipient's NIP-65 write relays in zap requests
+}
+
 type Zapper struct {
 	nwcClient *nwc.Client
 	pool      *nostr.SimplePool
-	relays    []string
+	relays    Relays
+	sign      SignPolicy
+	outbox    outboxCache // recipients' NIP-65 relays, when Relays.Recipient is set
+	clock     *clock.Clock
+	log       *zerolog.Logger
 }
 
-// New creates a new Zapper
-func New(nwcURL string, relays []string, pool *nostr.SimplePool) (*Zapper, error) {
-	logger.Log.Info().
+// New creates a new Zapper that logs to ctx's logger and timestamps zap
+// requests with ctx's clock
+func New(ctx context.Context, nwcURL string, retry nwc.RetryConfig, sign SignPolicy, relays Relays, pool *nostr.SimplePool) (*Zapper, error) {
+	log := logger.Ctx(ctx)
+	log.Info().
 		Str("component", "zapper").
 		Msg("initializing zapper")
 
-	client, err := nwc.NewClient(nwcURL)
+	client, err := nwc.NewClient(ctx, nwcURL, retry)
 	if err != nil {
-		logger.Log.Error().
+		log.Error().
 			Err(err).
 			Msg("failed to create NWC client")
 		return nil, err
@@ -41,15 +79,18 @@ func New(nwcURL string, relays []string, pool *nostr.SimplePool) (*Zapper, error
 		nwcClient: client,
 		pool:      pool,
 		relays:    relays,
+		sign:      sign,
+		clock:     clock.Ctx(ctx),
+		log:       log,
 	}, nil
 }
 
 // Connect establishes connection to NWC wallet relay
 func (z *Zapper) Connect(ctx context.Context) error {
-	logger.Log.Info().Msg("connecting to NWC wallet")
+	z.log.Info().Msg("connecting to NWC wallet")
 
 	if err := z.nwcClient.Connect(ctx); err != nil {
-		logger.Log.Error().
+		z.log.Error().
 			Err(err).
 			Msg("failed to connect to NWC wallet")
 		return err
@@ -60,121 +101,262 @@ func (z *Zapper) Connect(ctx context.Context) error {
 
 // Close closes NWC connection
 func (z *Zapper) Close() {
-	logger.Log.Info().Msg("closing NWC connection")
+	z.log.Info().Msg("closing NWC connection")
 	z.nwcClient.Close()
 }
 
-// ZapNote sends a zap to a note
+// ZapNote sends a zap to a note (or any other event, including addressable ones).
+// When the recipient's LNURL minimum is above amountSats the zap is bumped to
+// that minimum as long as it does not exceed maxSats (0 never bumps).
 func (z *Zapper) ZapNote(
 	ctx context.Context,
-	eventID,
-	authorPubkey string,
+	target *nostr.Event,
+	amountSats int,
+	maxSats int,
+	comment string,
+	eventSigner signer.Signer,
+) (*Zap, error) {
+
+	zap, err := z.PrepareZap(ctx, target, amountSats, maxSats, comment, eventSigner)
+	if err != nil {
+		return nil, err
+	}
+
+	zap.Preimage, err = z.nwcClient.PayInvoice(ctx, zap.Invoice)
+	if err != nil {
+		z.log.Error().
+			Err(err).
+			Msg("failed to pay invoice")
+		return nil, wrap(ErrPaymentFailed, err)
+	}
+
+	z.log.Info().
+		Str("event_id", target.ID).
+		Str("zap_request_id", zap.RequestID).
+		Msg("zap successful")
+
+	return zap, nil
+}
+
+// PrepareZap runs the whole zap flow up to (but not including) payment and
+// returns the invoice that would be paid
+func (z *Zapper) PrepareZap(
+	ctx context.Context,
+	target *nostr.Event,
 	amountSats int,
+	maxSats int,
 	comment string,
-	bunkerClient *bunker.ReconnectingClient,
-) error {
+	eventSigner signer.Signer,
+) (*Zap, error) {
 
-	logger.Log.Info().
-		Str("event_id", eventID).
+	z.log.Info().
+		Str("event_id", target.ID).
 		Int("amount_sats", amountSats).
 		Msg("starting zap")
 
-	lightningAddress, err := z.getLightningAddress(ctx, authorPubkey)
+	recipient := z.resolvePayee(target)
+
+	lightningAddress := recipient.address
+	if lightningAddress == "" {
+		var err error
+		lightningAddress, err = z.getLightningAddress(ctx, recipient.pubkey)
+		if err != nil {
+			z.log.Error().
+				Err(err).
+				Str("author_pubkey", target.PubKey).
+				Str("payee_pubkey", recipient.pubkey).
+				Msg("failed to get lightning address")
+			return nil, fmt.Errorf("failed to get lightning address: %w", err)
+		}
+	}
+
+	lnurlEndpoint := z.lightningAddressToLNURL(lightningAddress)
+	if lnurlEndpoint == "" {
+		return nil, fmt.Errorf("%w: invalid lightning address %q", ErrNoLightningAddress, lightningAddress)
+	}
+
+	metadata, err := z.fetchLNURLMetadata(ctx, lnurlEndpoint)
 	if err != nil {
-		logger.Log.Error().
-			Err(err).
-			Str("author_pubkey", authorPubkey).
-			Msg("failed to get lightning address")
-		return fmt.Errorf("failed to get lightning address: %w", err)
+		return nil, wrap(ErrLNURLUnavailable, err)
 	}
 
-	zapRequest, err := z.createZapRequest(ctx, eventID, authorPubkey, amountSats, comment, bunkerClient)
+	// The amount is signed into the zap request, so settle it before creating one
+	amountSats, err = z.checkAmount(metadata, lnurlEndpoint, amountSats, maxSats)
 	if err != nil {
-		logger.Log.Error().
+		z.log.Error().Err(err).Msg("invalid zap amount")
+		return nil, err
+	}
+
+	zapRequest, err := z.createZapRequest(ctx, target, recipient.pubkey, lnurlEndpoint, amountSats, comment, eventSigner)
+	if err != nil {
+		z.log.Error().
 			Err(err).
 			Msg("failed to create zap request")
-		return fmt.Errorf("failed to create zap request: %w", err)
+		return nil, fmt.Errorf("failed to create zap request: %w", err)
 	}
 
-	lnurlEndpoint := z.lightningAddressToLNURL(lightningAddress)
+	zapRequestJSON, err := json.Marshal(zapRequest)
+	if err != nil {
+		z.log.Error().
+			Err(err).
+			Msg("failed to marshal zap request")
+		return nil, fmt.Errorf("failed to marshal zap request: %w", err)
+	}
 
-	invoice, err := z.requestInvoice(ctx, lnurlEndpoint, amountSats, zapRequest)
+	invoice, err := z.requestInvoice(ctx, metadata, amountSats, string(zapRequestJSON))
 	if err != nil {
-		logger.Log.Error().
+		z.log.Error().
 			Err(err).
 			Str("lnurl", lnurlEndpoint).
 			Msg("failed to request invoice")
-		return err
+		return nil, err
 	}
 
-	if err := z.nwcClient.PayInvoice(ctx, invoice); err != nil {
-		logger.Log.Error().
-			Err(err).
-			Msg("failed to pay invoice")
-		return err
+	return &Zap{RequestID: zapRequest.ID, Invoice: invoice, Amount: amountSats, Payee: recipient.pubkey}, nil
+}
+
+// BuildZapRequest creates and signs the zap request PrepareZap would send
+// for target, without contacting the LNURL endpoint. The amount is used as
+// given, since checking it against the LNURL limits needs that endpoint.
+func (z *Zapper) BuildZapRequest(
+	ctx context.Context,
+	target *nostr.Event,
+	amountSats int,
+	comment string,
+	eventSigner signer.Signer,
+) (*nostr.Event, error) {
+
+	recipient := z.resolvePayee(target)
+
+	lightningAddress := recipient.address
+	if lightningAddress == "" {
+		var err error
+		lightningAddress, err = z.getLightningAddress(ctx, recipient.pubkey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get lightning address: %w", err)
+		}
 	}
 
-	logger.Log.Info().
-		Str("event_id", eventID).
-		Msg("zap successful")
+	lnurlEndpoint := z.lightningAddressToLNURL(lightningAddress)
+	if lnurlEndpoint == "" {
+		return nil, fmt.Errorf("%w: invalid lightning address %q", ErrNoLightningAddress, lightningAddress)
+	}
 
-	return nil
+	return z.createZapRequest(ctx, target, recipient.pubkey, lnurlEndpoint, amountSats, comment, eventSigner)
 }
 
-// createZapRequest creates a kind 9734 zap request event
+// createZapRequest creates a kind 9734 zap request event paying recipient
 func (z *Zapper) createZapRequest(
 	ctx context.Context,
-	eventID,
-	recipientPubkey string,
+	target *nostr.Event,
+	recipient string,
+	lnurlEndpoint string,
 	amountSats int,
 	comment string,
-	bunkerClient *bunker.ReconnectingClient,
-) (string, error) {
+	eventSigner signer.Signer,
+) (*nostr.Event, error) {
 
-	zapperPubkey, err := bunkerClient.GetPublicKey(ctx)
+	zapperPubkey, err := signer.PublicKey(ctx, eventSigner)
 	if err != nil {
-		logger.Log.Error().
+		z.log.Error().
 			Err(err).
 			Msg("failed to get zapper pubkey")
-		return "", err
+		return nil, wrap(ErrSigningFailed, err)
+	}
+
+	// NIP-57: the recipient's wallet publishes the receipt to every relay
+	// listed here, which is also where WaitForReceipt looks for it
+	if len(z.relays.Write) == 0 {
+		return nil, ErrNoRelays
 	}
 
 	event := nostr.Event{
 		PubKey:    zapperPubkey,
-		CreatedAt: nostr.Now(),
+		CreatedAt: z.clock.Now(),
 		Kind:      9734,
-		Tags: nostr.Tags{
-			{"e", eventID},
-			{"p", recipientPubkey},
-			{"amount", fmt.Sprintf("%d", amountSats*1000)},
-			{"relays", z.relays[0]},
-		},
+		Tags: append(targetTags(target, recipient),
+			nostr.Tag{"amount", fmt.Sprintf("%d", amountSats*1000)},
+			append(nostr.Tag{"relays"}, z.receiptRelays(ctx, recipient)...),
+		),
 		Content: comment,
 	}
 
+	// NIP-57 anonymous zap: the recipient sees no sender
+	if signer.IsAnonymous(eventSigner) {
+		event.Tags = append(event.Tags, nostr.Tag{"anon"})
+	}
+
+	// Recommended by NIP-57 so the recipient's server can check the request
+	if encoded, err := bech32.EncodeFromBase256("lnurl", []byte(lnurlEndpoint)); err == nil {
+		event.Tags = append(event.Tags, nostr.Tag{"lnurl", encoded})
+	}
+
 	event.ID = event.GetID()
 
-	if err := bunkerClient.SignEvent(ctx, &event); err != nil {
-		logger.Log.Error().
+	if err := z.signZapRequest(ctx, &event, eventSigner); err != nil {
+		z.log.Error().
 			Err(err).
 			Msg("failed to sign zap request")
-		return "", fmt.Errorf("failed to sign zap request: %w", err)
+		return nil, wrap(ErrSigningFailed, err)
 	}
 
-	eventJSON, err := json.Marshal(event)
-	if err != nil {
-		logger.Log.Error().
-			Err(err).
-			Msg("failed to marshal zap request")
-		return "", fmt.Errorf("failed to marshal zap request: %w", err)
+	return &event, nil
+}
+
+// signZapRequest signs within the sign policy's timeout and, with
+// GraceRetry, tries once more when the signer timed out but ctx is still live
+func (z *Zapper) signZapRequest(ctx context.Context, event *nostr.Event, eventSigner signer.Signer) error {
+	attempt := func() error {
+		if z.sign.Timeout <= 0 {
+			return eventSigner.SignEvent(ctx, event)
+		}
+		signCtx, cancel := context.WithTimeout(ctx, z.sign.Timeout)
+		defer cancel()
+		return eventSigner.SignEvent(signCtx, event)
 	}
 
-	return string(eventJSON), nil
+	err := attempt()
+	if err == nil || !z.sign.GraceRetry || !errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil {
+		return err
+	}
+
+	z.log.Warn().
+		Err(err).
+		Str("zap_request_id", event.ID).
+		Msg("signer timed out, approval may be pending; retrying once")
+	fmt.Fprintln(ui.Output(ctx), "⏳ Signer did not answer in time, asking once more (approve the request in your signer)")
+
+	return attempt()
 }
 
-// getLightningAddress fetches the author's lightning address from profile (kind 0)
+// targetTags returns the tags identifying what is being zapped. Per NIP-57
+// addressable events (e.g. kind 30023 articles) get an "a" tag so the zap
+// follows the latest version, alongside the "e" tag for this exact version.
+// The "k" tag carries the zapped event's kind so clients can render context,
+// and "p" is the recipient: the author unless the note named another payee.
+func targetTags(target *nostr.Event, recipient string) nostr.Tags {
+	tags := nostr.Tags{{"e", target.ID}}
+
+	if nostr.IsAddressableKind(target.Kind) {
+		address := fmt.Sprintf("%d:%s:%s", target.Kind, target.PubKey, target.Tags.GetD())
+		tags = append(tags, nostr.Tag{"a", address})
+	}
+
+	return append(tags,
+		nostr.Tag{"p", recipient},
+		nostr.Tag{"k", strconv.Itoa(target.Kind)},
+	)
+}
+
+// LightningAddress resolves the lightning address from an author's profile
+func (z *Zapper) LightningAddress(ctx context.Context, pubkey string) (string, error) {
+	return z.getLightningAddress(ctx, pubkey)
+}
+
+// getLightningAddress fetches the author's lightning address from profile
+// (kind 0): lud16, or the lud06 LNURL when there is no lud16
 func (z *Zapper) getLightningAddress(ctx context.Context, pubkey string) (string, error) {
-	logger.Log.Debug().
+	z.log.Debug().
 		Str("pubkey", pubkey).
 		Msg("fetching lightning address")
 
@@ -187,28 +369,63 @@ func (z *Zapper) getLightningAddress(ctx context.Context, pubkey string) (string
 	profileCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	for event := range z.pool.FetchMany(profileCtx, z.relays, filters[0]) {
+	received := 0
+	parsed := 0
+
+	for event := range z.pool.FetchMany(profileCtx, z.relays.Read, filters[0]) {
+		received++
+
 		var profile struct {
 			LUD16 string `json:"lud16"`
+			LUD06 string `json:"lud06"`
 		}
 
 		if err := json.Unmarshal([]byte(event.Content), &profile); err != nil {
-			logger.Log.Debug().
+			z.log.Debug().
 				Err(err).
+				Str("relay", event.Relay.URL).
 				Msg("failed to parse profile metadata")
 			continue
 		}
+		parsed++
 
 		if profile.LUD16 != "" {
 			return profile.LUD16, nil
 		}
+		// lud06 is a bech32 LNURL, which lightningAddressToLNURL decodes
+		if profile.LUD06 != "" {
+			return profile.LUD06, nil
+		}
 	}
 
-	return "", fmt.Errorf("no lightning address found in profile")
+	switch {
+	case received == 0:
+		z.log.Warn().
+			Str("pubkey", pubkey).
+			Msg("no kind-0 profile received from any relay")
+		return "", fmt.Errorf("%w on relays", ErrProfileNotFound)
+	case parsed == 0:
+		z.log.Warn().
+			Str("pubkey", pubkey).
+			Int("profiles_received", received).
+			Msg("received profiles could not be parsed")
+		return "", fmt.Errorf("%w: profile metadata could not be parsed", ErrNoLightningAddress)
+	default:
+		z.log.Info().
+			Str("pubkey", pubkey).
+			Int("profiles_received", received).
+			Msg("profile has no lightning address")
+		return "", fmt.Errorf("%w: profile has no lud16/lud06", ErrNoLightningAddress)
+	}
 }
 
-// lightningAddressToLNURL converts address to LNURL endpoint
+// lightningAddressToLNURL converts address to LNURL endpoint. It accepts a
+// lud16 address (name@domain) or a lud06 bech32 LNURL (lnurl1...).
 func (z *Zapper) lightningAddressToLNURL(address string) string {
+	if strings.HasPrefix(strings.ToLower(address), "lnurl1") {
+		return decodeLNURL(address)
+	}
+
 	parts := strings.Split(address, "@")
 	if len(parts) != 2 {
 		return ""
@@ -216,22 +433,88 @@ func (z *Zapper) lightningAddressToLNURL(address string) string {
 	return fmt.Sprintf("https://%s/.well-known/lnurlp/%s", parts[1], parts[0])
 }
 
-// requestInvoice requests a lightning invoice
-func (z *Zapper) requestInvoice(ctx context.Context, lnurlEndpoint string, amountSats int, zapRequest string) (string, error) {
-	metadata, err := z.fetchLNURLMetadata(lnurlEndpoint)
+// decodeLNURL returns the URL encoded in a bech32 LNURL, or "" if invalid.
+// LNURLs are longer than the 90 characters bech32 normally allows.
+func decodeLNURL(lnurl string) string {
+	hrp, data, err := bech32.DecodeNoLimit(strings.ToLower(lnurl))
+	if err != nil || hrp != "lnurl" {
+		return ""
+	}
+
+	decoded, err := bech32.ConvertBits(data, 5, 8, false)
 	if err != nil {
-		return "", err
+		return ""
+	}
+
+	endpoint := string(decoded)
+	if !strings.HasPrefix(endpoint, "https://") && !strings.HasPrefix(endpoint, "http://") {
+		return ""
 	}
+	return endpoint
+}
 
-	amountMillisats := int64(amountSats * 1000)
+// checkAmount validates amountSats against the LNURL bounds and returns the
+// amount to zap. An amount under the minimum is raised to the minimum when
+// that stays within maxSats; otherwise it is rejected.
+func (z *Zapper) checkAmount(metadata *LNURLPayMetadata, lnurlEndpoint string, amountSats, maxSats int) (int, error) {
+	amountMillisats := int64(amountSats) * 1000
 
-	if amountMillisats < metadata.MinSendable || amountMillisats > metadata.MaxSendable {
-		err := fmt.Errorf("amount %d out of bounds (%d-%d)", amountMillisats, metadata.MinSendable, metadata.MaxSendable)
-		logger.Log.Error().Err(err).Msg("invalid zap amount")
-		return "", err
+	// Some LNURL servers report zero/absent bounds; treat those as "no limit"
+	if metadata.MinSendable <= 0 || metadata.MaxSendable <= 0 {
+		z.log.Warn().
+			Str("lnurl", lnurlEndpoint).
+			Int64("min_sendable_msat", metadata.MinSendable).
+			Int64("max_sendable_msat", metadata.MaxSendable).
+			Msg("LNURL reported zero bounds, treating as unlimited")
+	}
+
+	if metadata.MinSendable > 0 && amountMillisats < metadata.MinSendable {
+		minSats := msatToSats(metadata.MinSendable)
+		if maxSats <= 0 || minSats > int64(maxSats) {
+			return 0, fmt.Errorf("%w: %d sats below minimum %d sats", ErrAmountOutOfBounds, amountSats, minSats)
+		}
+
+		z.log.Info().
+			Str("lnurl", lnurlEndpoint).
+			Int("amount_sats", amountSats).
+			Int64("min_sats", minSats).
+			Int("max_sats", maxSats).
+			Msg("amount below LNURL minimum, bumping to minimum")
+		amountSats = int(minSats)
+		amountMillisats = minSats * 1000
 	}
 
-	return z.fetchInvoice(metadata.Callback, amountMillisats, zapRequest)
+	if metadata.MaxSendable > 0 && amountMillisats > metadata.MaxSendable {
+		return 0, fmt.Errorf("%w: %d sats above maximum %d sats", ErrAmountOutOfBounds, amountSats, msatToSats(metadata.MaxSendable))
+	}
+
+	return amountSats, nil
+}
+
+// requestInvoice requests a lightning invoice from the LNURL callback and
+// checks it is for exactly amountSats, so what is paid is what was signed
+// into the zap request and what gets recorded
+func (z *Zapper) requestInvoice(ctx context.Context, metadata *LNURLPayMetadata, amountSats int, zapRequest string) (string, error) {
+	requested := int64(amountSats) * 1000
+	invoice, err := z.fetchInvoice(ctx, metadata.Callback, requested, zapRequest)
+	if err != nil {
+		return "", wrap(ErrLNURLUnavailable, err)
+	}
+
+	msats, err := InvoiceAmount(invoice)
+	if err != nil {
+		return "", wrap(ErrInvoiceMismatch, err)
+	}
+	if msats != requested {
+		return "", fmt.Errorf("%w: invoice is for %d msat, requested %d msat", ErrInvoiceMismatch, msats, requested)
+	}
+
+	return invoice, nil
+}
+
+// msatToSats converts millisats to sats, rounding up so minimums stay payable
+func msatToSats(msat int64) int64 {
+	return (msat + 999) / 1000
 }
 
 // LNURLPayMetadata represents LNURL-pay metadata
@@ -246,39 +529,44 @@ type LNURLPayMetadata struct {
 }
 
 // fetchLNURLMetadata fetches LNURL metadata
-func (z *Zapper) fetchLNURLMetadata(endpoint string) (*LNURLPayMetadata, error) {
-	logger.Log.Debug().
+func (z *Zapper) fetchLNURLMetadata(ctx context.Context, endpoint string) (*LNURLPayMetadata, error) {
+	z.log.Debug().
 		Str("endpoint", endpoint).
 		Msg("fetching LNURL metadata")
 
-	resp, err := http.Get(endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
-		logger.Log.Error().Err(err).Msg("LNURL request failed")
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		z.log.Error().Err(err).Msg("LNURL request failed")
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
 		err := fmt.Errorf("LNURL returned status %d", resp.StatusCode)
-		logger.Log.Error().Err(err).Msg("invalid LNURL response")
+		z.log.Error().Err(err).Msg("invalid LNURL response")
 		return nil, err
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		logger.Log.Error().Err(err).Msg("failed to read LNURL response")
+		z.log.Error().Err(err).Msg("failed to read LNURL response")
 		return nil, err
 	}
 
 	var metadata LNURLPayMetadata
 	if err := json.Unmarshal(body, &metadata); err != nil {
-		logger.Log.Error().Err(err).Msg("failed to parse LNURL metadata")
+		z.log.Error().Err(err).Msg("failed to parse LNURL metadata")
 		return nil, err
 	}
 
 	if metadata.Tag != "payRequest" {
 		err := fmt.Errorf("invalid tag %s", metadata.Tag)
-		logger.Log.Error().Err(err).Msg("invalid LNURL tag")
+		z.log.Error().Err(err).Msg("invalid LNURL tag")
 		return nil, err
 	}
 
@@ -286,10 +574,10 @@ func (z *Zapper) fetchLNURLMetadata(endpoint string) (*LNURLPayMetadata, error)
 }
 
 // fetchInvoice requests an invoice from callback
-func (z *Zapper) fetchInvoice(callback string, amountMillisats int64, zapRequest string) (string, error) {
+func (z *Zapper) fetchInvoice(ctx context.Context, callback string, amountMillisats int64, zapRequest string) (string, error) {
 	callbackURL, err := url.Parse(callback)
 	if err != nil {
-		logger.Log.Error().Err(err).Msg("invalid callback URL")
+		z.log.Error().Err(err).Msg("invalid callback URL")
 		return "", err
 	}
 
@@ -298,22 +586,27 @@ func (z *Zapper) fetchInvoice(callback string, amountMillisats int64, zapRequest
 	q.Set("nostr", zapRequest)
 	callbackURL.RawQuery = q.Encode()
 
-	resp, err := http.Get(callbackURL.String())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, callbackURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		logger.Log.Error().Err(err).Msg("invoice request failed")
+		z.log.Error().Err(err).Msg("invoice request failed")
 		return "", err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
 		err := fmt.Errorf("callback returned status %d", resp.StatusCode)
-		logger.Log.Error().Err(err).Msg("invoice callback error")
+		z.log.Error().Err(err).Msg("invoice callback error")
 		return "", err
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		logger.Log.Error().Err(err).Msg("failed to read invoice response")
+		z.log.Error().Err(err).Msg("failed to read invoice response")
 		return "", err
 	}
 
@@ -324,19 +617,19 @@ func (z *Zapper) fetchInvoice(callback string, amountMillisats int64, zapRequest
 	}
 
 	if err := json.Unmarshal(body, &invoiceResponse); err != nil {
-		logger.Log.Error().Err(err).Msg("failed to parse invoice response")
+		z.log.Error().Err(err).Msg("failed to parse invoice response")
 		return "", err
 	}
 
 	if invoiceResponse.Status == "ERROR" {
 		err := fmt.Errorf("LNURL error: %s", invoiceResponse.Reason)
-		logger.Log.Error().Err(err).Msg("LNURL returned error")
+		z.log.Error().Err(err).Msg("LNURL returned error")
 		return "", err
 	}
 
 	if invoiceResponse.PR == "" {
 		err := fmt.Errorf("no invoice in response")
-		logger.Log.Error().Err(err).Msg("empty invoice")
+		z.log.Error().Err(err).Msg("empty invoice")
 		return "", err
 	}
 
@@ -347,3 +640,23 @@ func (z *Zapper) fetchInvoice(callback string, amountMillisats int64, zapRequest
 func (z *Zapper) GetBalance(ctx context.Context) (int64, error) {
 	return z.nwcClient.GetBalance(ctx)
 }
+
+// WalletSupports reports whether the wallet advertises an NWC method in
+// get_info
+func (z *Zapper) WalletSupports(ctx context.Context, method string) (bool, error) {
+	info, err := z.nwcClient.GetInfo(ctx)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(info.Methods, method), nil
+}
+
+// InvoiceSettled asks the wallet (lookup_invoice) whether an invoice we paid
+// has settled
+func (z *Zapper) InvoiceSettled(ctx context.Context, invoice string) (bool, error) {
+	tx, err := z.nwcClient.LookupInvoice(ctx, invoice)
+	if err != nil {
+		return false, err
+	}
+	return tx.Settled(), nil
+}
@@ -0,0 +1,103 @@
+package zap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/rs/zerolog"
+)
+
+func TestTargetTags(t *testing.T) {
+	const (
+		author = "aa"
+		payee  = "bb"
+	)
+
+	tests := []struct {
+		name   string
+		target *nostr.Event
+		want   nostr.Tags
+	}{
+		{
+			name:   "plain note",
+			target: &nostr.Event{ID: "note", PubKey: author, Kind: 1},
+			want: nostr.Tags{
+				{"e", "note"},
+				{"p", payee},
+				{"k", "1"},
+			},
+		},
+		{
+			name: "article",
+			target: &nostr.Event{ID: "article", PubKey: author, Kind: 30023,
+				Tags: nostr.Tags{{"d", "my-post"}, {"title", "Post"}}},
+			want: nostr.Tags{
+				{"e", "article"},
+				{"a", "30023:aa:my-post"},
+				{"p", payee},
+				{"k", "30023"},
+			},
+		},
+		{
+			name:   "article without d tag",
+			target: &nostr.Event{ID: "article", PubKey: author, Kind: 30023},
+			want: nostr.Tags{
+				{"e", "article"},
+				{"a", "30023:aa:"},
+				{"p", payee},
+				{"k", "30023"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := targetTags(tt.target, payee); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestInvoiceChecksAmount(t *testing.T) {
+	var cancelled bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("amount") == "0" {
+			// Hang until the caller gives up
+			<-r.Context().Done()
+			cancelled = true
+			return
+		}
+		// 210n is 21 sats whatever was asked for
+		json.NewEncoder(w).Encode(map[string]string{"pr": "lnbc210n1pexample"})
+	}))
+	defer server.Close()
+
+	nop := zerolog.Nop()
+	z := &Zapper{log: &nop}
+	metadata := &LNURLPayMetadata{Callback: server.URL}
+
+	if _, err := z.requestInvoice(context.Background(), metadata, 21, "{}"); err != nil {
+		t.Errorf("matching invoice: %v", err)
+	}
+	if _, err := z.requestInvoice(context.Background(), metadata, 100, "{}"); !errors.Is(err, ErrInvoiceMismatch) {
+		t.Errorf("invoice for 21 sats when 100 were asked: got %v, want ErrInvoiceMismatch", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := z.requestInvoice(ctx, metadata, 0, "{}"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("hanging callback: got %v, want context.DeadlineExceeded", err)
+	}
+	server.Close()
+	if !cancelled {
+		t.Error("callback request was not cancelled")
+	}
+}
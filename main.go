@@ -1,7 +1,8 @@
 package main
 
 import (
-	"log"
+	"fmt"
+	"os"
 
 	"github.com/mistic0xb/pekka/cmd"
 	"github.com/mistic0xb/pekka/internal/logger"
@@ -9,7 +10,7 @@ import (
 
 func main() {
 	if err := logger.Init(); err != nil {
-		log.Fatal(err)
+		fmt.Fprintf(os.Stderr, "⚠️  %v, logging warnings and errors to stderr instead\n", err)
 	}
 	cmd.Execute()
 }
@@ -0,0 +1,144 @@
+// Package pekka exposes the auto-zap bot as a library so it can be embedded
+// in other Go programs without the CLI, viper or the default file logger.
+// Each Bot has its own logger, output and clock correction, and never prompts
+// unless given a Prompter, so several bots can run in one process.
+package pekka
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mistic0xb/pekka/config"
+	"github.com/mistic0xb/pekka/internal/bot"
+	"github.com/mistic0xb/pekka/internal/db"
+	"github.com/mistic0xb/pekka/internal/ui"
+	"github.com/rs/zerolog"
+)
+
+// Config is the bot configuration, populated directly instead of via viper
+type Config = config.Config
+
+// Option customizes a Bot
+type Option func(*options)
+
+type options struct {
+	logger   *zerolog.Logger
+	out      io.Writer
+	prompter Prompter
+}
+
+// Prompter answers the questions the CLI would ask on the terminal: before
+// monitoring an oversized list, zapping above budget.confirm_above or
+// backfilling missed notes
+type Prompter interface {
+	// Interactive reports whether there is anyone to ask
+	Interactive() bool
+	// Confirm asks a yes/no question and reports whether the answer was yes
+	Confirm(question string) bool
+}
+
+// WithLogger routes the bot's logs to the given logger. Without it the bot
+// logs nothing.
+func WithLogger(l zerolog.Logger) Option {
+	return func(o *options) {
+		o.logger = &l
+	}
+}
+
+// WithOutput writes the bot's console lines (zaps, reactions, summaries) to
+// w. Without it they are discarded.
+func WithOutput(w io.Writer) Option {
+	return func(o *options) {
+		o.out = w
+	}
+}
+
+// WithPrompter lets the bot ask p where the CLI would prompt. Without it the
+// bot is non-interactive and takes the same path as the CLI without a terminal.
+func WithPrompter(p Prompter) Option {
+	return func(o *options) {
+		o.prompter = p
+	}
+}
+
+// Bot is an embeddable auto-zap bot
+type Bot struct {
+	bot       *bot.Bot
+	db        *db.DB
+	started   atomic.Bool
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// New validates cfg, opens the database and connects to the bunker
+func New(cfg *Config, opts ...Option) (*Bot, error) {
+	nop := zerolog.Nop()
+	o := &options{logger: &nop, out: io.Discard, prompter: ui.NoPrompts{}}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.out == nil {
+		o.out = io.Discard
+	}
+	if o.prompter == nil {
+		o.prompter = ui.NoPrompts{}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	database, err := db.Open(cfg.Database.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := database.LockForRun(); err != nil {
+		database.Close()
+		return nil, fmt.Errorf("failed to lock database: %w", err)
+	}
+
+	b, err := bot.New(cfg, database, bot.Options{
+		Logger:   o.logger,
+		Out:      o.out,
+		Prompter: o.prompter,
+	})
+	if err != nil {
+		database.Close()
+		return nil, err
+	}
+
+	return &Bot{bot: b, db: database}, nil
+}
+
+// Run starts zapping and blocks until ctx is cancelled or Stop is called.
+// It closes the Bot when it returns.
+func (b *Bot) Run(ctx context.Context) error {
+	b.started.Store(true)
+	defer b.closeDB()
+	return b.bot.Run(ctx)
+}
+
+// Stop stops a running bot
+func (b *Bot) Stop() {
+	b.bot.Stop()
+}
+
+// Close releases the database and its run lock, so another bot or pekka
+// process can use it. Run already does this when it returns, Close is for a
+// Bot that is never run. Calling it more than once is safe.
+func (b *Bot) Close() error {
+	if !b.started.Load() {
+		b.bot.Stop()
+	}
+	return b.closeDB()
+}
+
+func (b *Bot) closeDB() error {
+	b.closeOnce.Do(func() {
+		b.closeErr = b.db.Close()
+	})
+	return b.closeErr
+}